@@ -0,0 +1,43 @@
+package adapter
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+)
+
+// temporaryPrefix starts the names of temporary files, which are hidden so listings of stored files skip them
+const temporaryPrefix = ".tmp-"
+
+// writeFileAtomically writes the data to a temporary file in the same directory and renames it to the given file, so
+// readers never see a partially written file
+func writeFileAtomically(filename string, data []byte, perm os.FileMode) error {
+	file, err := ioutil.TempFile(path.Dir(filename), temporaryPrefix+"*")
+	if err != nil {
+		return err
+	}
+	temporary := file.Name()
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Chmod(perm)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temporary, filename)
+	}
+	if err != nil {
+		_ = os.Remove(temporary)
+		return err
+	}
+
+	return nil
+}
+
+// isTemporary checks if the file name belongs to a temporary file written by writeFileAtomically
+func isTemporary(name string) bool {
+	return strings.HasPrefix(name, temporaryPrefix)
+}
@@ -0,0 +1,143 @@
+package adapter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"maibornwolff/vbump/model"
+)
+
+var (
+	cacheLookups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vbump_cache_lookups_total",
+			Help: "Number of project lookups in the storage cache, labelled with result hit or miss",
+		},
+		[]string{"result"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(cacheLookups)
+}
+
+// CachingProvider caches projects read from another storage provider for a limited time. Every write of a project
+// advances its generation, so a read overlapping the write does not cache the project it read before.
+type CachingProvider struct {
+	provider    StorageProvider
+	ttl         time.Duration
+	now         func() time.Time
+	mutex       sync.Mutex
+	projects    map[string]cachedProject
+	generations map[string]uint64
+}
+
+type cachedProject struct {
+	project model.Project
+	expires time.Time
+}
+
+// NewCachingProvider constructs a caching provider keeping projects for the given time to live
+func NewCachingProvider(provider StorageProvider, ttl time.Duration) StorageProvider {
+	return &CachingProvider{
+		provider:    provider,
+		ttl:         ttl,
+		now:         time.Now,
+		projects:    make(map[string]cachedProject),
+		generations: make(map[string]uint64),
+	}
+}
+
+// ReadVersion reads the given project's version from the cache or the underlying provider
+func (provider *CachingProvider) ReadVersion(project string) (model.Version, error) {
+	storedProject, err := provider.ReadProject(project)
+	return storedProject.Version, err
+}
+
+// StoreVersion writes the given project's version to the underlying provider and invalidates the cached project
+func (provider *CachingProvider) StoreVersion(project string, version model.Version) error {
+	defer provider.invalidate(project)
+	return provider.provider.StoreVersion(project, version)
+}
+
+// ReadProject reads the given project from the cache or the underlying provider
+func (provider *CachingProvider) ReadProject(project string) (model.Project, error) {
+	provider.mutex.Lock()
+	cached, found := provider.projects[project]
+	generation := provider.generations[project]
+	provider.mutex.Unlock()
+
+	if found && provider.now().Before(cached.expires) {
+		cacheLookups.With(prometheus.Labels{"result": "hit"}).Inc()
+		return cached.project, nil
+	}
+
+	cacheLookups.With(prometheus.Labels{"result": "miss"}).Inc()
+	storedProject, err := provider.provider.ReadProject(project)
+	if err != nil {
+		return storedProject, err
+	}
+
+	provider.mutex.Lock()
+	if provider.generations[project] == generation {
+		provider.projects[project] = cachedProject{project: storedProject, expires: provider.now().Add(provider.ttl)}
+	}
+	provider.mutex.Unlock()
+
+	return storedProject, nil
+}
+
+// StoreProject writes the given project to the underlying provider and invalidates the cached project
+func (provider *CachingProvider) StoreProject(project string, storedProject model.Project) error {
+	defer provider.invalidate(project)
+	return provider.provider.StoreProject(project, storedProject)
+}
+
+// DeleteProject deletes the given project from the underlying provider and invalidates the cached project
+func (provider *CachingProvider) DeleteProject(project string) error {
+	defer provider.invalidate(project)
+	return provider.provider.DeleteProject(project)
+}
+
+// ListProjects lists the projects of the underlying provider
+func (provider *CachingProvider) ListProjects() ([]string, error) {
+	return provider.provider.ListProjects()
+}
+
+// ReadHistory reads the given project's history from the underlying provider
+func (provider *CachingProvider) ReadHistory(project string) ([]model.HistoryEntry, error) {
+	return provider.provider.ReadHistory(project)
+}
+
+// AppendHistory appends to the given project's history in the underlying provider
+func (provider *CachingProvider) AppendHistory(project string, entry model.HistoryEntry) error {
+	return provider.provider.AppendHistory(project, entry)
+}
+
+// StoreHistory replaces the given project's history in the underlying provider
+func (provider *CachingProvider) StoreHistory(project string, history []model.HistoryEntry) error {
+	return provider.provider.StoreHistory(project, history)
+}
+
+// ReadNamespace reads the given namespace from the underlying provider
+func (provider *CachingProvider) ReadNamespace(namespace string) (model.Namespace, error) {
+	return provider.provider.ReadNamespace(namespace)
+}
+
+// StoreNamespace writes the given namespace to the underlying provider
+func (provider *CachingProvider) StoreNamespace(namespace string, storedNamespace model.Namespace) error {
+	return provider.provider.StoreNamespace(namespace, storedNamespace)
+}
+
+// ListNamespaces lists the namespaces with settings of the underlying provider
+func (provider *CachingProvider) ListNamespaces() ([]string, error) {
+	return provider.provider.ListNamespaces()
+}
+
+func (provider *CachingProvider) invalidate(project string) {
+	provider.mutex.Lock()
+	delete(provider.projects, project)
+	provider.generations[project]++
+	provider.mutex.Unlock()
+}
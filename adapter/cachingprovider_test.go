@@ -0,0 +1,86 @@
+package adapter
+
+import (
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"maibornwolff/vbump/model"
+)
+
+func TestCachingProviderServesFromCacheUntilExpired(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	_ = os.WriteFile(path.Join(basePath, "A"), []byte("1.0.0"), 0644)
+	provider := NewCachingProvider(NewFileProvider(basePath), time.Minute)
+	provider.(*CachingProvider).now = func() time.Time { return base }
+	hits := testutil.ToFloat64(cacheLookups.WithLabelValues("hit"))
+
+	_, _ = provider.ReadVersion("A")
+	_ = os.WriteFile(path.Join(basePath, "A"), []byte("2.0.0"), 0644)
+	actual, _ := provider.ReadVersion("A")
+	Ω.Expect(actual).To(Equal(model.NewVersion(1, 0, 0)))
+	Ω.Expect(testutil.ToFloat64(cacheLookups.WithLabelValues("hit"))).To(Equal(hits + 1))
+
+	provider.(*CachingProvider).now = func() time.Time { return base.Add(time.Minute) }
+	actual, _ = provider.ReadVersion("A")
+	Ω.Expect(actual).To(Equal(model.NewVersion(2, 0, 0)))
+}
+
+func TestCachingProviderInvalidatesOnWrite(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	provider := NewCachingProvider(NewFileProvider(t.TempDir()), time.Hour)
+
+	_ = provider.StoreVersion("A", model.NewVersion(1, 0, 0))
+	_, _ = provider.ReadVersion("A")
+	_ = provider.StoreVersion("A", model.NewVersion(1, 1, 0))
+	actual, _ := provider.ReadVersion("A")
+
+	Ω.Expect(actual).To(Equal(model.NewVersion(1, 1, 0)))
+}
+
+// pausingProvider pauses the first read of a project after reading it until it is resumed
+type pausingProvider struct {
+	StorageProvider
+	paused  chan struct{}
+	resumed chan struct{}
+	once    sync.Once
+}
+
+func (provider *pausingProvider) ReadProject(project string) (model.Project, error) {
+	storedProject, err := provider.StorageProvider.ReadProject(project)
+	provider.once.Do(func() {
+		close(provider.paused)
+		<-provider.resumed
+	})
+	return storedProject, err
+}
+
+func TestCachingProviderDoesNotCacheReadOverlappingWrite(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	fileProvider := NewFileProvider(t.TempDir())
+	_ = fileProvider.StoreVersion("A", model.NewVersion(1, 0, 0))
+	pausing := &pausingProvider{StorageProvider: fileProvider, paused: make(chan struct{}), resumed: make(chan struct{})}
+	provider := NewCachingProvider(pausing, time.Hour)
+
+	read := make(chan struct{})
+	go func() {
+		_, _ = provider.ReadVersion("A")
+		close(read)
+	}()
+	<-pausing.paused
+	_ = provider.StoreVersion("A", model.NewVersion(1, 1, 0))
+	close(pausing.resumed)
+	<-read
+
+	actual, _ := provider.ReadVersion("A")
+	Ω.Expect(actual).To(Equal(model.NewVersion(1, 1, 0)))
+}
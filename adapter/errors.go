@@ -0,0 +1,22 @@
+package adapter
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Kinds of errors returned by storage providers as cause of the errors wrapping them
+var (
+	// ErrNotFound is returned when a project or another requested entity does not exist
+	ErrNotFound = errors.New("Not found")
+	// ErrInvalidInput is returned for names or values which cannot be stored
+	ErrInvalidInput = errors.New("Invalid input")
+	// ErrConflict is returned when a change conflicts with the stored state
+	ErrConflict = errors.New("Conflict")
+	// ErrUnavailable is returned when the storage cannot be read or written
+	ErrUnavailable = errors.New("Storage unavailable")
+)
+
+// withKind returns an error of the given kind, keeping the message of the error it is caused by
+func withKind(kind error, err error, format string, args ...interface{}) error {
+	return errors.Wrapf(kind, "%v: %v", errors.Errorf(format, args...), err)
+}
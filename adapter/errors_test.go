@@ -0,0 +1,29 @@
+package adapter
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/model"
+)
+
+func TestFileProviderErrorKinds(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	provider := NewFileProvider(t.TempDir())
+	_ = provider.StoreVersion("team/A", model.NewVersion(1, 0, 0))
+
+	_, err := provider.ReadProject("B")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+
+	_, err = provider.ReadProject("../B")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+
+	err = provider.StoreProject("team", model.Project{Version: model.NewVersion(1, 0, 0)})
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrConflict))
+
+	_, err = NewFileProvider("dirnotexist").ReadProject("A")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrUnavailable))
+	Ω.Expect(err.Error()).To(ContainSubstring("Base directory dirnotexist does not exist"))
+}
@@ -0,0 +1,138 @@
+package adapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"maibornwolff/vbump/model"
+)
+
+const eventsFile = ".events.jsonl"
+
+// EventStore allows to read and write the log of retained events
+type EventStore interface {
+	ReadEvents() ([]model.Event, error)
+	AppendEvents(events ...model.Event) error
+	StoreEvents(events []model.Event) error
+}
+
+// FileEventStore reads and writes the event log from/to a file in the data directory holding one JSON event per line
+type FileEventStore struct {
+	basePath string
+}
+
+// NewFileEventStore constructs a new file event store
+func NewFileEventStore(basePath string) EventStore {
+	return &FileEventStore{basePath: basePath}
+}
+
+// ReadEvents reads all events in the order they were appended. A torn trailing line left by an interrupted append is
+// truncated, so later appends start on a new line.
+func (store *FileEventStore) ReadEvents() ([]model.Event, error) {
+	filename := path.Join(store.basePath, eventsFile)
+	events := make([]model.Event, 0)
+
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return events, nil
+	}
+	if err != nil {
+		return nil, withKind(ErrUnavailable, err, "Failed to read events file %v", filename)
+	}
+
+	for offset := 0; offset < len(data); {
+		end := bytes.IndexByte(data[offset:], '\n')
+		if end < 0 {
+			end = len(data)
+		} else {
+			end += offset
+		}
+		line := bytes.TrimSpace(data[offset:end])
+
+		var event model.Event
+		if len(line) > 0 {
+			if err := json.Unmarshal(line, &event); err != nil {
+				if end < len(data) {
+					return nil, errors.Wrapf(err, "Failed to decode events file %v", filename)
+				}
+				log.Warn().Err(err).Str("file", filename).Msg("Truncated torn line of events file")
+				if err := os.Truncate(filename, int64(offset)); err != nil {
+					return nil, withKind(ErrUnavailable, err, "Failed to truncate events file %v", filename)
+				}
+				break
+			}
+			events = append(events, event)
+		}
+		if end == len(data) && len(line) > 0 {
+			// the last event is complete but its line is not
+			if err := store.appendData([]byte("\n")); err != nil {
+				return nil, err
+			}
+		}
+		offset = end + 1
+	}
+
+	return events, nil
+}
+
+// AppendEvents appends events to the events file
+func (store *FileEventStore) AppendEvents(events ...model.Event) error {
+	data, err := encodeEvents(events)
+	if err != nil {
+		return err
+	}
+
+	return store.appendData(data)
+}
+
+// appendData appends the data to the events file
+func (store *FileEventStore) appendData(data []byte) error {
+	filename := path.Join(store.basePath, eventsFile)
+
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return withKind(ErrUnavailable, err, "Failed to open events file %v", filename)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return withKind(ErrUnavailable, err, "Failed to append to events file %v", filename)
+	}
+
+	return nil
+}
+
+// StoreEvents replaces all events in the events file, e.g. to drop events no longer retained
+func (store *FileEventStore) StoreEvents(events []model.Event) error {
+	filename := path.Join(store.basePath, eventsFile)
+
+	data, err := encodeEvents(events)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomically(filename, data, 0644); err != nil {
+		return withKind(ErrUnavailable, err, "Failed to store events file %v", filename)
+	}
+
+	return nil
+}
+
+func encodeEvents(events []model.Event) ([]byte, error) {
+	var buffer bytes.Buffer
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to encode event")
+		}
+		buffer.Write(data)
+		buffer.WriteByte('\n')
+	}
+
+	return buffer.Bytes(), nil
+}
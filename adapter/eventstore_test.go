@@ -0,0 +1,69 @@
+package adapter
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"maibornwolff/vbump/model"
+)
+
+func TestAppendAndStoreEvents(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	store := NewFileEventStore(basePath)
+	empty, err := store.ReadEvents()
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(empty).To(BeEmpty())
+
+	Ω.Expect(store.AppendEvents(model.Event{ID: "1", Project: "p1"}, model.Event{ID: "2", Project: "p2"})).To(Succeed())
+	Ω.Expect(store.AppendEvents(model.Event{ID: "3", Project: "p1"})).To(Succeed())
+	events, err := NewFileEventStore(basePath).ReadEvents()
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(events).To(HaveLen(3))
+	Ω.Expect(events[2].ID).To(Equal("3"))
+
+	Ω.Expect(store.StoreEvents(events[1:])).To(Succeed())
+	events, _ = store.ReadEvents()
+	Ω.Expect(events).To(HaveLen(2))
+	Ω.Expect(events[0].ID).To(Equal("2"))
+
+	projects, _ := NewFileProvider(basePath).ListProjects()
+	Ω.Expect(projects).To(BeEmpty())
+}
+
+func TestTruncateTornEvents(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	store := NewFileEventStore(basePath)
+	_ = store.AppendEvents(model.Event{ID: "1", Project: "p1"})
+	filename := path.Join(basePath, eventsFile)
+	file, _ := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+	_, _ = file.WriteString(`{"id":"2","proj`)
+	_ = file.Close()
+
+	events, err := store.ReadEvents()
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(events).To(HaveLen(1))
+	Ω.Expect(store.AppendEvents(model.Event{ID: "2", Project: "p2"})).To(Succeed())
+	events, _ = store.ReadEvents()
+	Ω.Expect(events).To(HaveLen(2))
+	Ω.Expect(events[1].Project).To(Equal("p2"))
+
+	// a complete event missing its line break is kept
+	_ = ioutil.WriteFile(filename, []byte(`{"id":"1"}`+"\n"+`{"id":"2"}`), 0644)
+	events, _ = store.ReadEvents()
+	Ω.Expect(events).To(HaveLen(2))
+	_ = store.AppendEvents(model.Event{ID: "3"})
+	events, err = store.ReadEvents()
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(events).To(HaveLen(3))
+
+	_ = ioutil.WriteFile(filename, []byte(`{"id":`+"\n"+`{"id":"2"}`+"\n"), 0644)
+	_, err = store.ReadEvents()
+	Ω.Expect(err).NotTo(BeNil())
+}
@@ -0,0 +1,222 @@
+package adapter
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/model"
+)
+
+// AllOperations selects every storage operation in a fault spec
+const AllOperations = "*"
+
+// Fault configures the misbehaviour of a storage operation. Rates are probabilities between 0 and 1.
+type Fault struct {
+	Latency      time.Duration
+	ErrorRate    float64
+	NotFoundRate float64
+	PartialRate  float64
+}
+
+// FaultProvider injects latency, errors and partial failures into the operations of another storage provider
+type FaultProvider struct {
+	provider StorageProvider
+	faults   map[string]Fault
+	random   func() float64
+	sleep    func(time.Duration)
+}
+
+// NewFaultProvider constructs a fault provider misbehaving with the given faults per operation name,
+// e.g. "ReadProject", or AllOperations
+func NewFaultProvider(provider StorageProvider, faults map[string]Fault) StorageProvider {
+	return &FaultProvider{
+		provider: provider,
+		faults:   faults,
+		random:   rand.Float64,
+		sleep:    time.Sleep,
+	}
+}
+
+// ParseFaults parses fault specs of the form <operation>:<key>=<value>,... with the keys latency, error,
+// notfound and partial, e.g. "StoreProject:latency=200ms,error=0.1,partial=0.05"
+func ParseFaults(specs []string) (map[string]Fault, error) {
+	faults := make(map[string]Fault, len(specs))
+
+	for _, spec := range specs {
+		parts := strings.SplitN(spec, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.Errorf("%v is not a valid fault spec", spec)
+		}
+
+		var fault Fault
+		for _, setting := range strings.Split(parts[1], ",") {
+			keyValue := strings.SplitN(setting, "=", 2)
+			if len(keyValue) != 2 {
+				return nil, errors.Errorf("%v in fault spec %v is not a key=value pair", setting, spec)
+			}
+
+			var err error
+			switch keyValue[0] {
+			case "latency":
+				fault.Latency, err = time.ParseDuration(keyValue[1])
+			case "error":
+				fault.ErrorRate, err = parseRate(keyValue[1])
+			case "notfound":
+				fault.NotFoundRate, err = parseRate(keyValue[1])
+			case "partial":
+				fault.PartialRate, err = parseRate(keyValue[1])
+			default:
+				err = errors.Errorf("Unknown key %v", keyValue[0])
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to parse fault spec %v", spec)
+			}
+		}
+
+		faults[parts[0]] = fault
+	}
+
+	return faults, nil
+}
+
+func parseRate(value string) (float64, error) {
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if rate < 0 || rate > 1 {
+		return 0, errors.Errorf("Rate %v is not between 0 and 1", value)
+	}
+
+	return rate, nil
+}
+
+// ReadVersion reads the given project's version, possibly misbehaving
+func (provider *FaultProvider) ReadVersion(project string) (version model.Version, err error) {
+	err = provider.inject("ReadVersion", func() (callErr error) {
+		version, callErr = provider.provider.ReadVersion(project)
+		return
+	})
+	return
+}
+
+// StoreVersion writes the given project's version, possibly misbehaving
+func (provider *FaultProvider) StoreVersion(project string, version model.Version) error {
+	return provider.inject("StoreVersion", func() error {
+		return provider.provider.StoreVersion(project, version)
+	})
+}
+
+// ReadProject reads the given project, possibly misbehaving
+func (provider *FaultProvider) ReadProject(project string) (storedProject model.Project, err error) {
+	err = provider.inject("ReadProject", func() (callErr error) {
+		storedProject, callErr = provider.provider.ReadProject(project)
+		return
+	})
+	return
+}
+
+// StoreProject writes the given project, possibly misbehaving
+func (provider *FaultProvider) StoreProject(project string, storedProject model.Project) error {
+	return provider.inject("StoreProject", func() error {
+		return provider.provider.StoreProject(project, storedProject)
+	})
+}
+
+// DeleteProject deletes the given project, possibly misbehaving
+func (provider *FaultProvider) DeleteProject(project string) error {
+	return provider.inject("DeleteProject", func() error {
+		return provider.provider.DeleteProject(project)
+	})
+}
+
+// ListProjects lists all projects, possibly misbehaving
+func (provider *FaultProvider) ListProjects() (projects []string, err error) {
+	err = provider.inject("ListProjects", func() (callErr error) {
+		projects, callErr = provider.provider.ListProjects()
+		return
+	})
+	return
+}
+
+// ReadHistory reads the given project's history, possibly misbehaving
+func (provider *FaultProvider) ReadHistory(project string) (history []model.HistoryEntry, err error) {
+	err = provider.inject("ReadHistory", func() (callErr error) {
+		history, callErr = provider.provider.ReadHistory(project)
+		return
+	})
+	return
+}
+
+// AppendHistory appends to the given project's history, possibly misbehaving
+func (provider *FaultProvider) AppendHistory(project string, entry model.HistoryEntry) error {
+	return provider.inject("AppendHistory", func() error {
+		return provider.provider.AppendHistory(project, entry)
+	})
+}
+
+// StoreHistory replaces the given project's history, possibly misbehaving
+func (provider *FaultProvider) StoreHistory(project string, history []model.HistoryEntry) error {
+	return provider.inject("StoreHistory", func() error {
+		return provider.provider.StoreHistory(project, history)
+	})
+}
+
+// ReadNamespace reads the given namespace, possibly misbehaving
+func (provider *FaultProvider) ReadNamespace(namespace string) (storedNamespace model.Namespace, err error) {
+	err = provider.inject("ReadNamespace", func() (callErr error) {
+		storedNamespace, callErr = provider.provider.ReadNamespace(namespace)
+		return
+	})
+	return
+}
+
+// StoreNamespace writes the given namespace, possibly misbehaving
+func (provider *FaultProvider) StoreNamespace(namespace string, storedNamespace model.Namespace) error {
+	return provider.inject("StoreNamespace", func() error {
+		return provider.provider.StoreNamespace(namespace, storedNamespace)
+	})
+}
+
+// ListNamespaces lists all namespaces with settings, possibly misbehaving
+func (provider *FaultProvider) ListNamespaces() (namespaces []string, err error) {
+	err = provider.inject("ListNamespaces", func() (callErr error) {
+		namespaces, callErr = provider.provider.ListNamespaces()
+		return
+	})
+	return
+}
+
+// inject delays the operation and then fails it before calling, reports nothing found, fails it after calling
+// or just calls it, depending on the operation's configured fault rates
+func (provider *FaultProvider) inject(operation string, call func() error) error {
+	fault, found := provider.faults[operation]
+	if !found {
+		fault, found = provider.faults[AllOperations]
+	}
+	if !found {
+		return call()
+	}
+
+	if fault.Latency > 0 {
+		provider.sleep(fault.Latency)
+	}
+
+	roll := provider.random()
+	switch {
+	case roll < fault.ErrorRate:
+		return errors.Wrapf(ErrUnavailable, "%v failed on purpose", operation)
+	case roll < fault.ErrorRate+fault.NotFoundRate:
+		return errors.Wrapf(ErrNotFound, "%v found nothing on purpose", operation)
+	case roll < fault.ErrorRate+fault.NotFoundRate+fault.PartialRate:
+		if err := call(); err != nil {
+			return err
+		}
+		return errors.Wrapf(ErrUnavailable, "%v failed on purpose after completing", operation)
+	}
+
+	return call()
+}
@@ -0,0 +1,58 @@
+package adapter
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/model"
+)
+
+func TestParseFaults(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	actual, err := ParseFaults([]string{"StoreProject:latency=200ms,error=0.1,partial=0.05", "*:notfound=1"})
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(actual).To(Equal(map[string]Fault{
+		"StoreProject": {Latency: 200 * time.Millisecond, ErrorRate: 0.1, PartialRate: 0.05},
+		AllOperations:  {NotFoundRate: 1},
+	}))
+
+	_, err = ParseFaults([]string{"StoreProject:error=2"})
+	Ω.Expect(err).NotTo(BeNil())
+
+	_, err = ParseFaults([]string{"StoreProject"})
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func TestFaultProviderInjectsFaults(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	fileProvider := NewFileProvider(t.TempDir())
+	_ = fileProvider.StoreVersion("A", model.NewVersion(1, 0, 0))
+	provider := NewFaultProvider(fileProvider, map[string]Fault{
+		"ReadProject":  {Latency: time.Second, NotFoundRate: 1},
+		"StoreVersion": {PartialRate: 1},
+		"ListProjects": {ErrorRate: 1},
+	})
+	var slept time.Duration
+	provider.(*FaultProvider).sleep = func(duration time.Duration) { slept += duration }
+
+	_, err := provider.ReadProject("A")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+	Ω.Expect(slept).To(Equal(time.Second))
+
+	err = provider.StoreVersion("A", model.NewVersion(2, 0, 0))
+	actual, _ := fileProvider.ReadVersion("A")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrUnavailable))
+	Ω.Expect(actual).To(Equal(model.NewVersion(2, 0, 0)))
+
+	_, err = provider.ListProjects()
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrUnavailable))
+
+	actual, err = provider.ReadVersion("A")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(actual).To(Equal(model.NewVersion(2, 0, 0)))
+}
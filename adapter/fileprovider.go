@@ -1,14 +1,51 @@
 package adapter
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 	"maibornwolff/vbump/model"
 )
 
+const (
+	historyDir     = ".history"
+	namespaceFile  = ".namespace"
+	documentFormat = 2
+)
+
+var errChecksumMismatch = errors.New("Checksum mismatch")
+
+// projectDocument is the JSON format of a project's version file, checksummed since format 2
+type projectDocument struct {
+	Format   int            `json:"format"`
+	Version  model.Version  `json:"version"`
+	Metadata model.Metadata `json:"metadata"`
+	Checksum string         `json:"checksum,omitempty"`
+}
+
+func (document projectDocument) checksum() (string, error) {
+	document.Checksum = ""
+	data, err := json.Marshal(document)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to encode version for checksum")
+	}
+
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
 // FileProvider reads and writes version data from/to files
 type FileProvider struct {
 	basePath string
@@ -20,41 +57,350 @@ func NewFileProvider(basePath string) StorageProvider {
 }
 
 // ReadVersion reads the given project's version from a file
-func (provider *FileProvider) ReadVersion(project string) (version model.Version, err error) {
+func (provider *FileProvider) ReadVersion(project string) (model.Version, error) {
+	storedProject, err := provider.ReadProject(project)
+	return storedProject.Version, err
+}
+
+// ReadProject reads the given project's version and metadata from a file in plain text or JSON format
+func (provider *FileProvider) ReadProject(project string) (storedProject model.Project, err error) {
+	if err = model.ValidateName(project); err != nil {
+		return storedProject, withKind(ErrInvalidInput, err, "Failed to read project")
+	}
 	filename := path.Join(provider.basePath, project)
 
 	if _, err = os.Stat(provider.basePath); os.IsNotExist(err) {
-		return version, errors.Wrapf(err, "Base directory %v does not exist", provider.basePath)
+		return storedProject, withKind(ErrUnavailable, err, "Base directory %v does not exist", provider.basePath)
 	}
 
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		return version, errors.Wrapf(err, "File %v does not exist", filename)
+	if info, err := os.Stat(filename); os.IsNotExist(err) || err == nil && info.IsDir() {
+		return storedProject, errors.Wrapf(ErrNotFound, "Project %v does not exist", project)
 	}
 
 	versionData, err := ioutil.ReadFile(filename)
 	if err != nil {
-		return version, errors.Wrapf(err, "Failed to read version from file %v", filename)
+		return storedProject, withKind(ErrUnavailable, err, "Failed to read version from file %v", filename)
 	}
 
-	version, err = model.FromVersionString(string(versionData))
+	storedProject, err = decodeProject(versionData)
 	if err != nil {
-		return version, errors.Wrapf(err, "Failed to convert version")
+		return storedProject, errors.Wrapf(err, "Failed to convert version")
 	}
 
 	return
 }
 
-// StoreVersion writes the given project's version to a file
+// StoreVersion writes the given project's version to a file, keeping its metadata. A project which cannot be read is
+// left untouched rather than losing its metadata.
 func (provider *FileProvider) StoreVersion(project string, version model.Version) error {
+	storedProject, err := provider.ReadProject(project)
+	if err != nil && errors.Cause(err) != ErrNotFound {
+		return errors.Wrapf(err, "Failed to store version of project %v", project)
+	}
+
+	storedProject.Version = version
+	storedProject.Metadata = storedProject.Metadata.Touch("", time.Now().UTC())
+
+	return provider.StoreProject(project, storedProject)
+}
+
+// StoreProject writes the given project's version and metadata to a file in JSON format,
+// migrating a file still in plain text format
+func (provider *FileProvider) StoreProject(project string, storedProject model.Project) error {
+	if err := model.ValidateName(project); err != nil {
+		return withKind(ErrInvalidInput, err, "Failed to store project")
+	}
 	filename := path.Join(provider.basePath, project)
 
-	versionString := version.String()
-	versionBytes := []byte(versionString)
+	if info, err := os.Stat(filename); err == nil && info.IsDir() {
+		return errors.Wrapf(ErrConflict, "Project %v conflicts with the namespace of the same name", project)
+	}
+	if err := os.MkdirAll(path.Dir(filename), 0755); err != nil {
+		return withKind(ErrUnavailable, err, "Failed to create namespace directory for project %v", project)
+	}
+
+	if existingData, err := ioutil.ReadFile(filename); err == nil && isPlainText(existingData) {
+		log.Info().Str("file", filename).Int("format", documentFormat).Msg("Migrating version file from plain text to JSON format")
+	}
+
+	document := projectDocument{
+		Format:   documentFormat,
+		Version:  storedProject.Version,
+		Metadata: storedProject.Metadata,
+	}
+	checksum, err := document.checksum()
+	if err != nil {
+		return err
+	}
+	document.Checksum = checksum
+
+	versionBytes, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode version")
+	}
 
-	err := ioutil.WriteFile(filename, versionBytes, 0644)
+	err = writeFileAtomically(filename, versionBytes, 0644)
 	if err != nil {
-		return errors.Wrap(err, "Failed to store version in file")
+		return withKind(ErrUnavailable, err, "Failed to store version in file %v", filename)
 	}
 
 	return nil
 }
+
+// DeleteProject removes the given project's version file and history file
+func (provider *FileProvider) DeleteProject(project string) error {
+	if err := model.ValidateName(project); err != nil {
+		return withKind(ErrInvalidInput, err, "Failed to delete project")
+	}
+
+	for _, filename := range []string{path.Join(provider.basePath, project), path.Join(provider.basePath, historyDir, project)} {
+		if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+			return withKind(ErrUnavailable, err, "Failed to delete file %v", filename)
+		}
+	}
+
+	return nil
+}
+
+func isPlainText(data []byte) bool {
+	return !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+func decodeProject(data []byte) (model.Project, error) {
+	if isPlainText(data) {
+		version, err := model.FromVersionString(string(bytes.TrimSpace(data)))
+		return model.Project{Version: version}, err
+	}
+
+	var document projectDocument
+	if err := json.Unmarshal(data, &document); err != nil {
+		return model.Project{}, err
+	}
+	if document.Format > documentFormat {
+		return model.Project{}, errors.Errorf("Version file format %v is newer than supported format %v", document.Format, documentFormat)
+	}
+	if document.Format >= 2 {
+		checksum, err := document.checksum()
+		if err != nil {
+			return model.Project{}, err
+		}
+		if checksum != document.Checksum {
+			return model.Project{}, errors.Wrapf(errChecksumMismatch, "Expected checksum %v, but content has %v", document.Checksum, checksum)
+		}
+	}
+
+	return model.Project{Version: document.Version, Metadata: document.Metadata}, nil
+}
+
+// ListProjects returns the names of all projects with a version file, including those in namespace directories
+func (provider *FileProvider) ListProjects() ([]string, error) {
+	projects, err := listFiles(provider.basePath)
+	if err != nil {
+		return nil, withKind(ErrUnavailable, err, "Failed to list projects in directory %v", provider.basePath)
+	}
+
+	return projects, nil
+}
+
+// listFiles returns the slash separated paths of all files below a directory, skipping hidden files and directories
+func listFiles(dirname string) ([]string, error) {
+	files := make([]string, 0)
+
+	err := filepath.Walk(dirname, func(filename string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if filename == dirname {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			relativePath, err := filepath.Rel(dirname, filename)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(relativePath))
+		}
+		return nil
+	})
+	sort.Strings(files)
+
+	return files, err
+}
+
+// ReadHistory reads the given project's history from its history file
+func (provider *FileProvider) ReadHistory(project string) ([]model.HistoryEntry, error) {
+	if err := model.ValidateName(project); err != nil {
+		return nil, withKind(ErrInvalidInput, err, "Failed to read history")
+	}
+	filename := path.Join(provider.basePath, historyDir, project)
+
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return []model.HistoryEntry{}, nil
+	}
+	if err != nil {
+		return nil, withKind(ErrUnavailable, err, "Failed to open history file %v", filename)
+	}
+	defer file.Close()
+
+	history := make([]model.HistoryEntry, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		entry, err := model.FromHistoryString(scanner.Text())
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read history file %v", filename)
+		}
+		history = append(history, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, withKind(ErrUnavailable, err, "Failed to read history file %v", filename)
+	}
+
+	return history, nil
+}
+
+// AppendHistory appends an entry to the given project's history file
+func (provider *FileProvider) AppendHistory(project string, entry model.HistoryEntry) error {
+	if err := model.ValidateName(project); err != nil {
+		return withKind(ErrInvalidInput, err, "Failed to append history")
+	}
+	filename := path.Join(provider.basePath, historyDir, project)
+	dirname := path.Dir(filename)
+	if err := os.MkdirAll(dirname, 0755); err != nil {
+		return withKind(ErrUnavailable, err, "Failed to create history directory %v", dirname)
+	}
+
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return withKind(ErrUnavailable, err, "Failed to open history file %v", filename)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(entry.String() + "\n"); err != nil {
+		return withKind(ErrUnavailable, err, "Failed to append to history file %v", filename)
+	}
+
+	return nil
+}
+
+// StoreHistory replaces the given project's history file
+func (provider *FileProvider) StoreHistory(project string, history []model.HistoryEntry) error {
+	if err := model.ValidateName(project); err != nil {
+		return withKind(ErrInvalidInput, err, "Failed to store history")
+	}
+	filename := path.Join(provider.basePath, historyDir, project)
+	dirname := path.Dir(filename)
+	if err := os.MkdirAll(dirname, 0755); err != nil {
+		return withKind(ErrUnavailable, err, "Failed to create history directory %v", dirname)
+	}
+
+	var builder strings.Builder
+	for _, entry := range history {
+		builder.WriteString(entry.String() + "\n")
+	}
+
+	if err := writeFileAtomically(filename, []byte(builder.String()), 0644); err != nil {
+		return withKind(ErrUnavailable, err, "Failed to store history file %v", filename)
+	}
+
+	return nil
+}
+
+// ReadNamespace reads the given namespace's settings from the namespace file in its directory, an unknown
+// namespace having no settings
+func (provider *FileProvider) ReadNamespace(namespace string) (model.Namespace, error) {
+	var storedNamespace model.Namespace
+	if namespace != "" {
+		if err := model.ValidateName(namespace); err != nil {
+			return storedNamespace, withKind(ErrInvalidInput, err, "Failed to read namespace")
+		}
+	}
+	filename := path.Join(provider.basePath, namespace, namespaceFile)
+
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return storedNamespace, nil
+	}
+	if err != nil {
+		return storedNamespace, withKind(ErrUnavailable, err, "Failed to read namespace file %v", filename)
+	}
+
+	if err := json.Unmarshal(data, &storedNamespace); err != nil {
+		return storedNamespace, errors.Wrapf(err, "Failed to decode namespace file %v", filename)
+	}
+
+	return storedNamespace, nil
+}
+
+// StoreNamespace writes the given namespace's settings to the namespace file in its directory
+func (provider *FileProvider) StoreNamespace(namespace string, storedNamespace model.Namespace) error {
+	if namespace != "" {
+		if err := model.ValidateName(namespace); err != nil {
+			return withKind(ErrInvalidInput, err, "Failed to store namespace")
+		}
+	}
+	dirname := path.Join(provider.basePath, namespace)
+
+	if info, err := os.Stat(dirname); err == nil && !info.IsDir() {
+		return errors.Wrapf(ErrConflict, "Namespace %v conflicts with the project of the same name", namespace)
+	}
+	if err := os.MkdirAll(dirname, 0755); err != nil {
+		return withKind(ErrUnavailable, err, "Failed to create namespace directory %v", dirname)
+	}
+
+	data, err := json.MarshalIndent(storedNamespace, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode namespace")
+	}
+
+	filename := path.Join(dirname, namespaceFile)
+	if err := writeFileAtomically(filename, data, 0644); err != nil {
+		return withKind(ErrUnavailable, err, "Failed to store namespace file %v", filename)
+	}
+
+	return nil
+}
+
+// ListNamespaces returns the names of all namespaces having a namespace file, the root namespace being ""
+func (provider *FileProvider) ListNamespaces() ([]string, error) {
+	namespaces := make([]string, 0)
+
+	err := filepath.Walk(provider.basePath, func(filename string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if filename != provider.basePath && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(filename, namespaceFile)); err != nil {
+			return nil
+		}
+		relativePath, err := filepath.Rel(provider.basePath, filename)
+		if err != nil {
+			return err
+		}
+		if relativePath == "." {
+			relativePath = ""
+		}
+		namespaces = append(namespaces, filepath.ToSlash(relativePath))
+		return nil
+	})
+	if err != nil {
+		return nil, withKind(ErrUnavailable, err, "Failed to list namespaces in directory %v", provider.basePath)
+	}
+	sort.Strings(namespaces)
+
+	return namespaces, nil
+}
@@ -1,6 +1,8 @@
 package adapter
 
 import (
+	"sort"
+
 	"maibornwolff/vbump/model"
 )
 
@@ -8,14 +10,18 @@ import (
 type FileProviderMock struct {
 	version       model.Version
 	project       string
+	history       map[string][]model.HistoryEntry
+	namespaces    map[string]model.Namespace
 	VersionStored bool
 }
 
 // NewMock constructs a new mock for a file provider
 func NewMock(version model.Version, project string) StorageProvider {
 	return &FileProviderMock{
-		version: version,
-		project: project,
+		version:    version,
+		project:    project,
+		history:    make(map[string][]model.HistoryEntry),
+		namespaces: make(map[string]model.Namespace),
 	}
 }
 
@@ -33,3 +39,65 @@ func (provider *FileProviderMock) StoreVersion(string, model.Version) error {
 	provider.VersionStored = true
 	return nil
 }
+
+// ReadProject returns the current version from FileProviderMock without metadata
+func (provider *FileProviderMock) ReadProject(project string) (model.Project, error) {
+	version, err := provider.ReadVersion(project)
+	return model.Project{Version: version}, err
+}
+
+// StoreProject sets VersionStored to true
+func (provider *FileProviderMock) StoreProject(string, model.Project) error {
+	provider.VersionStored = true
+	return nil
+}
+
+// DeleteProject removes the history appended to FileProviderMock for the given project
+func (provider *FileProviderMock) DeleteProject(project string) error {
+	delete(provider.history, project)
+	return nil
+}
+
+// ListProjects returns the mocked project
+func (provider *FileProviderMock) ListProjects() ([]string, error) {
+	return []string{provider.project}, nil
+}
+
+// ReadHistory returns the entries appended to FileProviderMock for the given project
+func (provider *FileProviderMock) ReadHistory(project string) ([]model.HistoryEntry, error) {
+	return provider.history[project], nil
+}
+
+// AppendHistory records the entry in FileProviderMock
+func (provider *FileProviderMock) AppendHistory(project string, entry model.HistoryEntry) error {
+	provider.history[project] = append(provider.history[project], entry)
+	return nil
+}
+
+// StoreHistory replaces the history in FileProviderMock for the given project
+func (provider *FileProviderMock) StoreHistory(project string, history []model.HistoryEntry) error {
+	provider.history[project] = history
+	return nil
+}
+
+// ReadNamespace returns the namespace stored in FileProviderMock
+func (provider *FileProviderMock) ReadNamespace(namespace string) (model.Namespace, error) {
+	return provider.namespaces[namespace], nil
+}
+
+// StoreNamespace records the namespace in FileProviderMock
+func (provider *FileProviderMock) StoreNamespace(namespace string, storedNamespace model.Namespace) error {
+	provider.namespaces[namespace] = storedNamespace
+	return nil
+}
+
+// ListNamespaces returns the namespaces stored in FileProviderMock
+func (provider *FileProviderMock) ListNamespaces() ([]string, error) {
+	namespaces := make([]string, 0, len(provider.namespaces))
+	for namespace := range provider.namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	return namespaces, nil
+}
@@ -3,9 +3,12 @@ package adapter
 import (
 	"log"
 	"os"
+	"path"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 	"maibornwolff/vbump/model"
 )
 
@@ -57,11 +60,209 @@ func TestCorrectVersionScope(t *testing.T) {
 	Ω.Expect(actual2).To(Equal(model.NewVersion(2, 0, 0)))
 }
 
+func TestAppendAndReadHistory(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	filename := "history_project"
+	removeFile(filename)
+	provider := NewFileProvider("../adapter")
+	entry := model.HistoryEntry{Timestamp: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), Action: "set", Version: model.NewVersion(1, 0, 0)}
+
+	_ = provider.AppendHistory(filename, entry)
+	actual, err := provider.ReadHistory(filename)
+
+	removeFile(filename)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(actual).To(Equal([]model.HistoryEntry{entry}))
+}
+
+func TestListProjects(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	provider := NewFileProvider(t.TempDir())
+	_ = provider.StoreVersion("b", model.NewVersion(1, 0, 0))
+	_ = provider.StoreVersion("a", model.NewVersion(2, 0, 0))
+	_ = provider.AppendHistory("a", model.HistoryEntry{Timestamp: time.Now(), Action: "set", Version: model.NewVersion(2, 0, 0)})
+
+	actual, err := provider.ListProjects()
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(actual).To(Equal([]string{"a", "b"}))
+}
+
 func removeFile(filename string) {
-	if _, err := os.Stat(filename); err == nil {
-		var err = os.Remove(filename)
-		if err != nil {
-			log.Fatal(err)
+	for _, name := range []string{filename, path.Join(historyDir, filename)} {
+		if _, err := os.Stat(name); err == nil {
+			var err = os.Remove(name)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+	_ = os.Remove(historyDir)
+}
+
+func TestReadPlainTextAndMigrateToJSON(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	filename := path.Join(basePath, "legacy")
+	_ = os.WriteFile(filename, []byte("1.2.3\n"), 0644)
+	provider := NewFileProvider(basePath)
+
+	actual, err := provider.ReadVersion("legacy")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(actual).To(Equal(model.NewVersion(1, 2, 3)))
+
+	_ = provider.StoreVersion("legacy", model.NewVersion(1, 2, 4))
+	data, _ := os.ReadFile(filename)
+	Ω.Expect(string(data)).To(HavePrefix("{"))
+	Ω.Expect(string(data)).To(ContainSubstring(`"version": "1.2.4"`))
+
+	stored, _ := provider.ReadProject("legacy")
+	Ω.Expect(stored.Version).To(Equal(model.NewVersion(1, 2, 4)))
+	Ω.Expect(stored.Metadata.Scheme).To(Equal(model.DefaultScheme))
+	Ω.Expect(stored.Metadata.Created.IsZero()).To(BeFalse())
+}
+
+func TestStoreAndReadProjectWithMetadata(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	provider := NewFileProvider(t.TempDir())
+	project := model.Project{
+		Version: model.NewVersion(2, 0, 0),
+		Metadata: model.Metadata{
+			Scheme:     model.DefaultScheme,
+			Created:    time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+			Modified:   time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC),
+			ModifiedBy: "ci",
+			Settings:   map[string]string{"owner": "team-a"},
+		},
+	}
+
+	_ = provider.StoreProject("p", project)
+	actual, err := provider.ReadProject("p")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(actual).To(Equal(project))
+}
+
+func TestReadProjectWithNewerFormat(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	_ = os.WriteFile(path.Join(basePath, "future"), []byte(`{"format": 99, "version": "1.0.0"}`), 0644)
+	provider := NewFileProvider(basePath)
+
+	_, err := provider.ReadProject("future")
+
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func TestStoreVersionKeepsUnreadableProject(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	_ = os.WriteFile(path.Join(basePath, "future"), []byte(`{"format": 99, "version": "1.0.0"}`), 0644)
+	provider := NewFileProvider(basePath)
+
+	Ω.Expect(provider.StoreVersion("future", model.NewVersion(2, 0, 0))).NotTo(Succeed())
+	data, _ := os.ReadFile(path.Join(basePath, "future"))
+	Ω.Expect(string(data)).To(Equal(`{"format": 99, "version": "1.0.0"}`))
+}
+
+func TestStoreAndListNamespacedProjects(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	provider := NewFileProvider(basePath)
+
+	_ = provider.StoreVersion("team/service/component", model.NewVersion(1, 0, 0))
+	_ = provider.StoreVersion("team/other", model.NewVersion(2, 0, 0))
+	_ = provider.AppendHistory("team/other", model.HistoryEntry{Timestamp: time.Now(), Action: "set", Version: model.NewVersion(2, 0, 0)})
+	_ = provider.StoreNamespace("team", model.Namespace{Settings: map[string]string{"owner": "team-a"}})
+	actual, _ := provider.ReadVersion("team/service/component")
+	projects, _ := provider.ListProjects()
+	namespace, _ := provider.ReadNamespace("team")
+	history, _ := provider.ReadHistory("team/other")
+
+	Ω.Expect(actual).To(Equal(model.NewVersion(1, 0, 0)))
+	Ω.Expect(projects).To(Equal([]string{"team/other", "team/service/component"}))
+	Ω.Expect(namespace.Settings).To(Equal(map[string]string{"owner": "team-a"}))
+	Ω.Expect(history).To(HaveLen(1))
+	Ω.Expect(path.Join(basePath, "team", "service", "component")).To(BeARegularFile())
+
+	Ω.Expect(provider.StoreVersion("team", model.NewVersion(1, 0, 0))).NotTo(BeNil())
+	Ω.Expect(provider.StoreVersion("team/../escape", model.NewVersion(1, 0, 0))).NotTo(BeNil())
+}
+
+func TestListNamespaces(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	provider := NewFileProvider(t.TempDir())
+
+	_ = provider.StoreVersion("team/service/component", model.NewVersion(1, 0, 0))
+	_ = provider.StoreNamespace("", model.Namespace{Settings: map[string]string{"owner": "ops"}})
+	_ = provider.StoreNamespace("team/service", model.Namespace{Settings: map[string]string{"owner": "team-a"}})
+	namespaces, err := provider.ListNamespaces()
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(namespaces).To(Equal([]string{"", "team/service"}))
+}
+
+func TestDeleteProject(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	provider := NewFileProvider(t.TempDir())
+	_ = provider.StoreVersion("team/A", model.NewVersion(1, 0, 0))
+	_ = provider.AppendHistory("team/A", model.HistoryEntry{Timestamp: time.Now(), Action: "set", Version: model.NewVersion(1, 0, 0)})
+
+	Ω.Expect(provider.DeleteProject("team/A")).To(Succeed())
+	Ω.Expect(provider.DeleteProject("team/B")).To(Succeed())
+
+	projects, _ := provider.ListProjects()
+	history, _ := provider.ReadHistory("team/A")
+	Ω.Expect(projects).To(BeEmpty())
+	Ω.Expect(history).To(BeEmpty())
+}
+
+func TestRejectPathTraversal(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	_ = os.WriteFile(path.Join(basePath, namespaceFile), []byte(`{"settings": {"secret": "x"}}`), 0644)
+	provider := NewFileProvider(path.Join(basePath, "data"))
+
+	_, err := provider.ReadNamespace("..")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+	_, err = provider.ReadNamespace("team/../..")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+	_, err = provider.ReadHistory("../../history")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+	Ω.Expect(errors.Cause(provider.AppendHistory("../x", model.HistoryEntry{}))).To(Equal(ErrInvalidInput))
+	Ω.Expect(errors.Cause(provider.StoreHistory("../x", nil))).To(Equal(ErrInvalidInput))
+}
+
+func TestStoreFilesAtomically(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	provider := NewFileProvider(basePath)
+	Ω.Expect(provider.StoreProject("team/p1", model.Project{Version: model.NewVersion(1, 0, 0)})).To(Succeed())
+	Ω.Expect(provider.StoreHistory("team/p1", []model.HistoryEntry{{Action: "set", Version: model.NewVersion(1, 0, 0)}})).To(Succeed())
+	Ω.Expect(provider.StoreNamespace("team", model.Namespace{})).To(Succeed())
+
+	// a temporary file left by an interrupted write is no project
+	leftover, _ := os.Create(path.Join(basePath, "team", temporaryPrefix+"1"))
+	_ = leftover.Close()
+	projects, _ := provider.ListProjects()
+	Ω.Expect(projects).To(Equal([]string{"team/p1"}))
+
+	for _, dirname := range []string{path.Join(basePath, "team"), path.Join(basePath, historyDir, "team")} {
+		entries, _ := os.ReadDir(dirname)
+		for _, entry := range entries {
+			Ω.Expect(entry.Name() == temporaryPrefix+"1" || !isTemporary(entry.Name())).To(BeTrue())
 		}
 	}
 }
@@ -0,0 +1,148 @@
+package adapter
+
+import (
+	"os"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/model"
+)
+
+// Problems found by Fsck
+const (
+	ProblemUnparsable       = "unparsable"
+	ProblemChecksumMismatch = "checksum-mismatch"
+	ProblemOrphanedHistory  = "orphaned-history"
+	ProblemInvalidHistory   = "invalid-history"
+)
+
+// FsckIssue describes a problem with a project found in a data directory, of a tenant other than the default tenant
+// if Tenant is set
+type FsckIssue struct {
+	Tenant   string
+	Project  string
+	Problem  string
+	Detail   string
+	Repaired bool
+}
+
+// Fsck scans all projects of a data directory and of the tenant directories within it for unparsable or
+// checksum-mismatched version files and for unreadable or orphaned history files. With repair, broken version files
+// are restored from the project's history.
+func Fsck(basePath string, repair bool) ([]FsckIssue, error) {
+	issues, err := fsckDirectory(basePath, repair)
+	if err != nil {
+		return issues, err
+	}
+
+	tenants, err := TenantDirs(basePath)
+	if err != nil {
+		return issues, err
+	}
+	for _, tenant := range tenants {
+		tenantIssues, err := fsckDirectory(TenantPath(basePath, tenant), repair)
+		for _, issue := range tenantIssues {
+			issue.Tenant = tenant
+			issues = append(issues, issue)
+		}
+		if err != nil {
+			return issues, errors.Wrapf(err, "Failed to check directory of tenant %v", tenant)
+		}
+	}
+
+	return issues, nil
+}
+
+func fsckDirectory(basePath string, repair bool) ([]FsckIssue, error) {
+	provider := &FileProvider{basePath: basePath}
+	issues := make([]FsckIssue, 0)
+
+	projects, err := provider.ListProjects()
+	if err != nil {
+		return issues, err
+	}
+
+	for _, project := range projects {
+		history, historyErr := provider.ReadHistory(project)
+		if historyErr != nil {
+			issues = append(issues, FsckIssue{Project: project, Problem: ProblemInvalidHistory, Detail: historyErr.Error()})
+		}
+
+		if _, err := provider.ReadProject(project); err != nil {
+			issue := FsckIssue{Project: project, Problem: ProblemUnparsable, Detail: err.Error()}
+			if errors.Cause(err) == errChecksumMismatch {
+				issue.Problem = ProblemChecksumMismatch
+			}
+			if repair && historyErr == nil {
+				issue.Repaired, issue.Detail = repairFromHistory(provider, project, history, issue.Detail)
+			}
+			issues = append(issues, issue)
+		}
+	}
+
+	orphans, err := orphanedHistories(basePath, projects)
+	if err != nil {
+		return issues, err
+	}
+	for _, project := range orphans {
+		issue := FsckIssue{Project: project, Problem: ProblemOrphanedHistory, Detail: "History exists without version file"}
+		if repair {
+			history, err := provider.ReadHistory(project)
+			if err != nil {
+				issue.Detail = err.Error()
+			} else {
+				issue.Repaired, issue.Detail = repairFromHistory(provider, project, history, issue.Detail)
+			}
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, nil
+}
+
+func orphanedHistories(basePath string, projects []string) ([]string, error) {
+	dirname := path.Join(basePath, historyDir)
+	if _, err := os.Stat(dirname); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	histories, err := listFiles(dirname)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to list history directory %v", dirname)
+	}
+
+	exists := make(map[string]bool, len(projects))
+	for _, project := range projects {
+		exists[project] = true
+	}
+
+	orphans := make([]string, 0)
+	for _, history := range histories {
+		if !exists[history] {
+			orphans = append(orphans, history)
+		}
+	}
+
+	return orphans, nil
+}
+
+func repairFromHistory(provider *FileProvider, project string, history []model.HistoryEntry, detail string) (bool, string) {
+	history = model.MainLine(history)
+	if len(history) == 0 {
+		return false, detail + "; no history to repair from"
+	}
+
+	last := history[len(history)-1]
+	repaired := model.Project{
+		Version: last.Version,
+		Metadata: model.Metadata{
+			Created: history[0].Timestamp,
+		}.Touch("fsck", time.Now().UTC()),
+	}
+	if err := provider.StoreProject(project, repaired); err != nil {
+		return false, detail + "; " + err.Error()
+	}
+
+	return true, detail + "; restored version " + last.Version.String() + " from history"
+}
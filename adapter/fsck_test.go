@@ -0,0 +1,75 @@
+package adapter
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"maibornwolff/vbump/model"
+)
+
+func TestFsckOnConsistentDirectory(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	provider := NewFileProvider(basePath)
+	_ = provider.StoreVersion("A", model.NewVersion(1, 0, 0))
+
+	issues, err := Fsck(basePath, false)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(issues).To(BeEmpty())
+}
+
+func TestFsckFindsAndRepairsBrokenFiles(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	provider := NewFileProvider(basePath)
+	entry := model.HistoryEntry{Timestamp: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), Action: "set", Version: model.NewVersion(1, 2, 0)}
+	_ = provider.StoreVersion("tampered", model.NewVersion(1, 2, 0))
+	_ = provider.AppendHistory("tampered", entry)
+	data, _ := os.ReadFile(path.Join(basePath, "tampered"))
+	_ = os.WriteFile(path.Join(basePath, "tampered"), []byte(strings.Replace(string(data), "1.2.0", "9.9.9", 1)), 0644)
+	_ = os.WriteFile(path.Join(basePath, "garbage"), []byte("not a version"), 0644)
+	_ = provider.AppendHistory("orphan", entry)
+
+	issues, err := Fsck(basePath, false)
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(issues).To(HaveLen(3))
+	Ω.Expect(issues[0].Project).To(Equal("garbage"))
+	Ω.Expect(issues[0].Problem).To(Equal(ProblemUnparsable))
+	Ω.Expect(issues[1].Problem).To(Equal(ProblemChecksumMismatch))
+	Ω.Expect(issues[2].Problem).To(Equal(ProblemOrphanedHistory))
+
+	issues, _ = Fsck(basePath, true)
+	Ω.Expect(issues[0].Repaired).To(BeFalse())
+	Ω.Expect(issues[1].Repaired).To(BeTrue())
+	Ω.Expect(issues[2].Repaired).To(BeTrue())
+
+	actual, err := provider.ReadVersion("tampered")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(actual).To(Equal(model.NewVersion(1, 2, 0)))
+	actual, _ = provider.ReadVersion("orphan")
+	Ω.Expect(actual).To(Equal(model.NewVersion(1, 2, 0)))
+}
+
+func TestFsckChecksTenantDirectories(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	_ = NewFileProvider(basePath).StoreVersion("A", model.NewVersion(1, 0, 0))
+	tenantPath := TenantPath(basePath, "team-a")
+	_ = os.MkdirAll(tenantPath, 0755)
+	_ = os.WriteFile(path.Join(tenantPath, "garbage"), []byte("not a version"), 0644)
+
+	issues, err := Fsck(basePath, false)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(issues).To(HaveLen(1))
+	Ω.Expect(issues[0].Tenant).To(Equal("team-a"))
+	Ω.Expect(issues[0].Project).To(Equal("garbage"))
+}
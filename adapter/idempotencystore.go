@@ -0,0 +1,119 @@
+package adapter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/model"
+)
+
+const idempotencyDir = ".idempotency"
+
+// IdempotencyStore allows to read and write the responses recorded for idempotency keys
+type IdempotencyStore interface {
+	ReadResponse(key string) (model.IdempotentResponse, bool, error)
+	StoreResponse(key string, response model.IdempotentResponse) error
+	DeleteResponsesBefore(before time.Time) (int, error)
+}
+
+// FileIdempotencyStore reads and writes recorded responses from/to files in the data directory
+type FileIdempotencyStore struct {
+	basePath string
+}
+
+// NewFileIdempotencyStore constructs a new file idempotency store
+func NewFileIdempotencyStore(basePath string) IdempotencyStore {
+	return &FileIdempotencyStore{basePath: basePath}
+}
+
+// filename hashes the key, which is chosen by clients and may contain any characters
+func (store *FileIdempotencyStore) filename(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return path.Join(store.basePath, idempotencyDir, hex.EncodeToString(sum[:]))
+}
+
+// ReadResponse reads the response recorded for the given key, reporting whether there is one
+func (store *FileIdempotencyStore) ReadResponse(key string) (model.IdempotentResponse, bool, error) {
+	var response model.IdempotentResponse
+	filename := store.filename(key)
+
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return response, false, nil
+	}
+	if err != nil {
+		return response, false, withKind(ErrUnavailable, err, "Failed to read idempotency file %v", filename)
+	}
+
+	if err := json.Unmarshal(data, &response); err != nil {
+		return response, false, errors.Wrapf(err, "Failed to decode idempotency file %v", filename)
+	}
+
+	return response, true, nil
+}
+
+// StoreResponse records the response for the given key, replacing the file atomically so concurrent readers never see
+// a partially written response
+func (store *FileIdempotencyStore) StoreResponse(key string, response model.IdempotentResponse) error {
+	dirname := path.Join(store.basePath, idempotencyDir)
+	if err := os.MkdirAll(dirname, 0755); err != nil {
+		return withKind(ErrUnavailable, err, "Failed to create idempotency directory %v", dirname)
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode idempotent response")
+	}
+
+	filename := store.filename(key)
+	if err := writeFileAtomically(filename, data, 0644); err != nil {
+		return withKind(ErrUnavailable, err, "Failed to store idempotency file %v", filename)
+	}
+
+	return nil
+}
+
+// DeleteResponsesBefore deletes all responses recorded before the given time and returns their number, along with
+// temporary files left behind before that time
+func (store *FileIdempotencyStore) DeleteResponsesBefore(before time.Time) (int, error) {
+	dirname := path.Join(store.basePath, idempotencyDir)
+	files, err := ioutil.ReadDir(dirname)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, withKind(ErrUnavailable, err, "Failed to list idempotency directory %v", dirname)
+	}
+
+	deleted := 0
+	for _, file := range files {
+		filename := path.Join(dirname, file.Name())
+		if isTemporary(file.Name()) {
+			if file.ModTime().Before(before) {
+				_ = os.Remove(filename)
+			}
+			continue
+		}
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return deleted, withKind(ErrUnavailable, err, "Failed to read idempotency file %v", filename)
+		}
+
+		var response model.IdempotentResponse
+		if err := json.Unmarshal(data, &response); err == nil && !response.Created.Before(before) {
+			continue
+		}
+		if err := os.Remove(filename); err != nil {
+			return deleted, withKind(ErrUnavailable, err, "Failed to delete idempotency file %v", filename)
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
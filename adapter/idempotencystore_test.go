@@ -0,0 +1,64 @@
+package adapter
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"maibornwolff/vbump/model"
+)
+
+func TestStoreAndReadIdempotentResponses(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	store := NewFileIdempotencyStore(basePath)
+	_, found, err := store.ReadResponse("default key/1")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(found).To(BeFalse())
+
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	response := model.IdempotentResponse{Request: "POST /patch/p1", Status: 200, ContentType: "text/plain",
+		Headers: map[string][]string{"Etag": {`"abc"`}}, Body: "1.0.1", Created: created}
+	_ = store.StoreResponse("default key/1", response)
+	_ = store.StoreResponse("default key/2", model.IdempotentResponse{Created: created.Add(time.Hour)})
+
+	actual, found, _ := NewFileIdempotencyStore(basePath).ReadResponse("default key/1")
+	Ω.Expect(found).To(BeTrue())
+	Ω.Expect(actual).To(Equal(response))
+
+	deleted, err := store.DeleteResponsesBefore(created.Add(time.Minute))
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(deleted).To(Equal(1))
+	_, found, _ = store.ReadResponse("default key/1")
+	Ω.Expect(found).To(BeFalse())
+	_, found, _ = store.ReadResponse("default key/2")
+	Ω.Expect(found).To(BeTrue())
+}
+
+func TestStoreIdempotentResponsesAtomically(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	store := NewFileIdempotencyStore(basePath)
+	Ω.Expect(store.StoreResponse("default key/1", model.IdempotentResponse{Status: 200, Created: time.Now()})).To(Succeed())
+	files, _ := ioutil.ReadDir(path.Join(basePath, idempotencyDir))
+	Ω.Expect(files).To(HaveLen(1))
+
+	leftover := path.Join(basePath, idempotencyDir, temporaryPrefix+"1")
+	_ = ioutil.WriteFile(leftover, []byte("{"), 0644)
+	deleted, err := store.DeleteResponsesBefore(time.Now().Add(-time.Hour))
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(deleted).To(Equal(0))
+	Ω.Expect(leftover).To(BeAnExistingFile())
+
+	old := time.Now().Add(-2 * time.Hour)
+	_ = os.Chtimes(leftover, old, old)
+	_, _ = store.DeleteResponsesBefore(time.Now().Add(-time.Hour))
+	Ω.Expect(leftover).NotTo(BeAnExistingFile())
+	_, found, _ := store.ReadResponse("default key/1")
+	Ω.Expect(found).To(BeTrue())
+}
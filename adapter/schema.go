@@ -0,0 +1,293 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SchemaVersion is the data directory layout written by this version of vbump
+const SchemaVersion = 3
+
+const schemaFile = ".schema"
+
+// migration upgrades a data directory from one schema version to the next
+type migration struct {
+	from        int
+	description string
+	apply       func(basePath string, dryRun bool) ([]string, error)
+}
+
+var migrations = []migration{
+	{from: 1, description: "convert plain text version files to JSON documents", apply: migratePlainTextToJSON},
+	{from: 2, description: "add checksums to version files", apply: migrateAddChecksums},
+}
+
+// ReadSchemaVersion returns the schema version of a data directory, which is 1 for directories without marker
+func ReadSchemaVersion(basePath string) (int, error) {
+	filename := path.Join(basePath, schemaFile)
+
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "Failed to read schema version from file %v", filename)
+	}
+
+	schemaVersion, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, errors.Wrapf(err, "Failed to parse schema version in file %v", filename)
+	}
+
+	return schemaVersion, nil
+}
+
+func writeSchemaVersion(basePath string, schemaVersion int) error {
+	filename := path.Join(basePath, schemaFile)
+
+	err := ioutil.WriteFile(filename, []byte(strconv.Itoa(schemaVersion)), 0644)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to write schema version to file %v", filename)
+	}
+
+	return nil
+}
+
+// CheckSchema verifies that this version of vbump can serve the data directory, marking an empty directory with
+// the current schema version. It returns the directory's schema version.
+func CheckSchema(basePath string) (int, error) {
+	schemaVersion, err := ReadSchemaVersion(basePath)
+	if err != nil {
+		return 0, err
+	}
+
+	if schemaVersion > SchemaVersion {
+		return schemaVersion, errors.Errorf("Data directory %v has schema version %v, but only versions up to %v are supported", basePath, schemaVersion, SchemaVersion)
+	}
+
+	if schemaVersion < SchemaVersion {
+		files, err := ioutil.ReadDir(basePath)
+		if err != nil {
+			return schemaVersion, errors.Wrapf(err, "Failed to read data directory %v", basePath)
+		}
+		if len(files) == 0 {
+			return SchemaVersion, writeSchemaVersion(basePath, SchemaVersion)
+		}
+	}
+
+	return schemaVersion, nil
+}
+
+// Migrate upgrades the data directory and the directories of all tenants within it in place to the current schema
+// version, returning a report of all steps. With dryRun nothing is changed. With a non-empty backupPath the data
+// directory is copied there first.
+func Migrate(basePath string, dryRun bool, backupPath string) ([]string, error) {
+	tenants, err := TenantDirs(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	directories := []string{basePath}
+	for _, tenant := range tenants {
+		directories = append(directories, TenantPath(basePath, tenant))
+	}
+
+	schemaVersions := make(map[string]int, len(directories))
+	outdated := make([]string, 0, len(directories))
+	for _, directory := range directories {
+		schemaVersion, err := ReadSchemaVersion(directory)
+		if err != nil {
+			return nil, err
+		}
+		if schemaVersion > SchemaVersion {
+			return nil, errors.Errorf("Data directory %v has schema version %v, but only versions up to %v are supported", directory, schemaVersion, SchemaVersion)
+		}
+		if schemaVersion < SchemaVersion {
+			schemaVersions[directory] = schemaVersion
+			outdated = append(outdated, directory)
+		}
+	}
+
+	report := make([]string, 0)
+	if len(outdated) == 0 {
+		return append(report, fmt.Sprintf("data directory is up to date at schema version %v", SchemaVersion)), nil
+	}
+
+	if backupPath != "" {
+		report = append(report, fmt.Sprintf("back up %v to %v", basePath, backupPath))
+		if !dryRun {
+			if err := copyDir(basePath, backupPath); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	for _, directory := range outdated {
+		if directory != basePath {
+			report = append(report, fmt.Sprintf("migrate tenant directory %v", directory))
+		}
+		stepReport, err := migrateDirectory(directory, schemaVersions[directory], dryRun)
+		report = append(report, stepReport...)
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// migrateDirectory applies all migrations from the given schema version to a single directory
+func migrateDirectory(basePath string, schemaVersion int, dryRun bool) ([]string, error) {
+	report := make([]string, 0)
+	for _, step := range migrations {
+		if step.from < schemaVersion {
+			continue
+		}
+
+		report = append(report, fmt.Sprintf("migrate schema version %v to %v: %v", step.from, step.from+1, step.description))
+		stepReport, err := step.apply(basePath, dryRun)
+		report = append(report, stepReport...)
+		if err != nil {
+			return report, errors.Wrapf(err, "Failed to migrate schema version %v to %v", step.from, step.from+1)
+		}
+
+		if !dryRun {
+			if err := writeSchemaVersion(basePath, step.from+1); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func migratePlainTextToJSON(basePath string, dryRun bool) ([]string, error) {
+	provider := &FileProvider{basePath: basePath}
+	report := make([]string, 0)
+
+	projects, err := provider.ListProjects()
+	if err != nil {
+		return report, err
+	}
+
+	for _, project := range projects {
+		filename := path.Join(basePath, project)
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return report, errors.Wrapf(err, "Failed to read version file %v", filename)
+		}
+		if !isPlainText(data) {
+			continue
+		}
+
+		report = append(report, fmt.Sprintf("  convert project %v", project))
+		if dryRun {
+			continue
+		}
+
+		storedProject, err := provider.ReadProject(project)
+		if err != nil {
+			return report, err
+		}
+		info, err := os.Stat(filename)
+		if err != nil {
+			return report, errors.Wrapf(err, "Failed to stat version file %v", filename)
+		}
+		storedProject.Metadata = storedProject.Metadata.Touch("", info.ModTime().UTC())
+		if err := provider.StoreProject(project, storedProject); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+func migrateAddChecksums(basePath string, dryRun bool) ([]string, error) {
+	provider := &FileProvider{basePath: basePath}
+	report := make([]string, 0)
+
+	projects, err := provider.ListProjects()
+	if err != nil {
+		return report, err
+	}
+
+	for _, project := range projects {
+		filename := path.Join(basePath, project)
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return report, errors.Wrapf(err, "Failed to read version file %v", filename)
+		}
+		var document projectDocument
+		if !isPlainText(data) && json.Unmarshal(data, &document) == nil && document.Checksum != "" {
+			continue
+		}
+
+		report = append(report, fmt.Sprintf("  add checksum to project %v", project))
+		if dryRun {
+			continue
+		}
+
+		storedProject, err := provider.ReadProject(project)
+		if err != nil {
+			return report, err
+		}
+		if err := provider.StoreProject(project, storedProject); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+func copyDir(source string, target string) error {
+	if _, err := os.Stat(target); err == nil {
+		return errors.Errorf("Backup target %v already exists", target)
+	}
+
+	return filepath.Walk(source, func(sourcePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Wrapf(err, "Failed to walk %v", sourcePath)
+		}
+
+		relativePath, err := filepath.Rel(source, sourcePath)
+		if err != nil {
+			return err
+		}
+		targetPath := filepath.Join(target, relativePath)
+
+		if info.IsDir() {
+			return os.MkdirAll(targetPath, info.Mode().Perm())
+		}
+
+		return copyFile(sourcePath, targetPath, info.Mode().Perm())
+	})
+}
+
+func copyFile(sourcePath string, targetPath string, mode os.FileMode) error {
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to open %v", sourcePath)
+	}
+	defer source.Close()
+
+	target, err := os.OpenFile(targetPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to create %v", targetPath)
+	}
+	defer target.Close()
+
+	if _, err := io.Copy(target, source); err != nil {
+		return errors.Wrapf(err, "Failed to copy %v to %v", sourcePath, targetPath)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package adapter
+
+import (
+	"os"
+	"path"
+	"strconv"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"maibornwolff/vbump/model"
+)
+
+func TestCheckSchemaMarksEmptyDirectory(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	schemaVersion, err := CheckSchema(basePath)
+	actual, _ := ReadSchemaVersion(basePath)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(schemaVersion).To(Equal(SchemaVersion))
+	Ω.Expect(actual).To(Equal(SchemaVersion))
+}
+
+func TestCheckSchemaRefusesNewerSchema(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	_ = os.WriteFile(path.Join(basePath, schemaFile), []byte("99"), 0644)
+
+	_, err := CheckSchema(basePath)
+
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func TestMigrateDryRunChangesNothing(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	_ = os.WriteFile(path.Join(basePath, "legacy"), []byte("1.2.3"), 0644)
+
+	report, err := Migrate(basePath, true, path.Join(t.TempDir(), "backup"))
+	data, _ := os.ReadFile(path.Join(basePath, "legacy"))
+	schemaVersion, _ := ReadSchemaVersion(basePath)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(report).To(ContainElement("  convert project legacy"))
+	Ω.Expect(string(data)).To(Equal("1.2.3"))
+	Ω.Expect(schemaVersion).To(Equal(1))
+}
+
+func TestMigrateWithBackup(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	backupPath := path.Join(t.TempDir(), "backup")
+	_ = os.WriteFile(path.Join(basePath, "legacy"), []byte("1.2.3"), 0644)
+
+	_, err := Migrate(basePath, false, backupPath)
+	backup, _ := os.ReadFile(path.Join(backupPath, "legacy"))
+	data, _ := os.ReadFile(path.Join(basePath, "legacy"))
+	schemaVersion, _ := ReadSchemaVersion(basePath)
+	actual, _ := NewFileProvider(basePath).ReadProject("legacy")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(string(backup)).To(Equal("1.2.3"))
+	Ω.Expect(isPlainText(data)).To(BeFalse())
+	Ω.Expect(schemaVersion).To(Equal(SchemaVersion))
+	Ω.Expect(actual.Version).To(Equal(model.NewVersion(1, 2, 3)))
+	Ω.Expect(actual.Metadata.Created.IsZero()).To(BeFalse())
+}
+
+func TestMigrateAddsChecksums(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	_ = os.WriteFile(path.Join(basePath, schemaFile), []byte("2"), 0644)
+	_ = os.WriteFile(path.Join(basePath, "unsummed"), []byte(`{"format": 1, "version": "1.0.0"}`), 0644)
+
+	report, err := Migrate(basePath, false, "")
+	data, _ := os.ReadFile(path.Join(basePath, "unsummed"))
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(report).To(ContainElement("  add checksum to project unsummed"))
+	Ω.Expect(string(data)).To(ContainSubstring(`"checksum": "sha256:`))
+}
+
+func TestMigrateTenantDirectories(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	tenantPath := TenantPath(basePath, "team-a")
+	_ = os.WriteFile(path.Join(basePath, schemaFile), []byte(strconv.Itoa(SchemaVersion)), 0644)
+	_ = os.MkdirAll(tenantPath, 0755)
+	_ = os.WriteFile(path.Join(tenantPath, "legacy"), []byte("1.2.3"), 0644)
+
+	report, err := Migrate(basePath, false, "")
+	schemaVersion, _ := ReadSchemaVersion(tenantPath)
+	actual, _ := NewFileProvider(tenantPath).ReadProject("legacy")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(report).To(ContainElement("migrate tenant directory " + tenantPath))
+	Ω.Expect(schemaVersion).To(Equal(SchemaVersion))
+	Ω.Expect(actual.Version).To(Equal(model.NewVersion(1, 2, 3)))
+}
@@ -0,0 +1,77 @@
+package adapter
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// OpenStorage constructs the storage provider described by a storage spec of the form <backend>:<location>.
+// A spec without backend is a directory path for the file backend.
+func OpenStorage(spec string) (StorageProvider, error) {
+	location, err := fileStorageLocation(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFileProvider(location), nil
+}
+
+// OpenTenantStore constructs the store of the tenants registered in the storage described by a storage spec
+func OpenTenantStore(spec string) (TenantStore, error) {
+	location, err := fileStorageLocation(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFileTenantStore(location), nil
+}
+
+// StorageTenants returns the tenants with projects of their own in the storage described by a storage spec
+func StorageTenants(spec string) ([]string, error) {
+	location, err := fileStorageLocation(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return TenantDirs(location)
+}
+
+// OpenTenantStorage constructs the storage provider of a tenant within the storage described by a storage spec,
+// creating the tenant's directory with the current schema version if needed
+func OpenTenantStorage(spec string, tenant string) (StorageProvider, error) {
+	location, err := fileStorageLocation(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := TenantPath(location, tenant)
+	if err := os.MkdirAll(basePath, 0755); err != nil {
+		return nil, withKind(ErrUnavailable, err, "Failed to create directory %v of tenant %v", basePath, tenant)
+	}
+	if _, err := CheckSchema(basePath); err != nil {
+		return nil, err
+	}
+
+	return NewFileProvider(basePath), nil
+}
+
+// fileStorageLocation returns the directory of a storage spec for the file backend, the only backend supported
+func fileStorageLocation(spec string) (string, error) {
+	backend, location := "file", spec
+	if index := strings.Index(spec, ":"); index > 1 {
+		backend, location = spec[:index], spec[index+1:]
+	}
+
+	switch backend {
+	case "file":
+		location = strings.TrimPrefix(location, "//")
+		if info, err := os.Stat(location); err != nil || !info.IsDir() {
+			return "", errors.Errorf("Directory %v of storage %v does not exist", location, spec)
+		}
+		return location, nil
+	}
+
+	return "", errors.Errorf("Storage backend %v of storage %v is not supported", backend, spec)
+}
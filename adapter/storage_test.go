@@ -0,0 +1,36 @@
+package adapter
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestOpenStorage(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	_, err := OpenStorage("file:" + t.TempDir())
+	Ω.Expect(err).To(BeNil())
+
+	_, err = OpenStorage(t.TempDir())
+	Ω.Expect(err).To(BeNil())
+
+	_, err = OpenStorage("postgres://localhost/vbump")
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func TestOpenTenantStorage(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	location := t.TempDir()
+	tenants, _ := StorageTenants("file:" + location)
+	Ω.Expect(tenants).To(BeEmpty())
+
+	_, err := OpenTenantStorage("file:"+location, "team-a")
+	Ω.Expect(err).To(BeNil())
+	tenants, _ = StorageTenants("file:" + location)
+	Ω.Expect(tenants).To(Equal([]string{"team-a"}))
+
+	_, err = OpenTenantStorage("postgres://localhost/vbump", "team-a")
+	Ω.Expect(err).NotTo(BeNil())
+}
@@ -8,4 +8,14 @@ import (
 type StorageProvider interface {
 	ReadVersion(project string) (model.Version, error)
 	StoreVersion(project string, version model.Version) error
+	ReadProject(project string) (model.Project, error)
+	StoreProject(project string, storedProject model.Project) error
+	DeleteProject(project string) error
+	ListProjects() ([]string, error)
+	ReadHistory(project string) ([]model.HistoryEntry, error)
+	AppendHistory(project string, entry model.HistoryEntry) error
+	StoreHistory(project string, history []model.HistoryEntry) error
+	ReadNamespace(namespace string) (model.Namespace, error)
+	StoreNamespace(namespace string, storedNamespace model.Namespace) error
+	ListNamespaces() ([]string, error)
 }
@@ -0,0 +1,97 @@
+package adapter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/model"
+)
+
+const (
+	tenantsFile = ".tenants.json"
+	tenantsDir  = ".tenants"
+)
+
+// TenantStore allows to read and write the registered tenants
+type TenantStore interface {
+	ReadTenants() ([]model.Tenant, error)
+	StoreTenants(tenants []model.Tenant) error
+}
+
+// FileTenantStore reads and writes the registered tenants from/to a file in the data directory
+type FileTenantStore struct {
+	basePath string
+}
+
+// NewFileTenantStore constructs a new file tenant store
+func NewFileTenantStore(basePath string) TenantStore {
+	return &FileTenantStore{basePath: basePath}
+}
+
+// TenantPath returns the directory below the data directory holding the projects of the given tenant
+func TenantPath(basePath string, tenant string) string {
+	return path.Join(basePath, tenantsDir, tenant)
+}
+
+// TenantDirs returns the tenants with a directory below the data directory sorted by name, whether they are
+// registered or not
+func TenantDirs(basePath string) ([]string, error) {
+	dirname := path.Join(basePath, tenantsDir)
+	files, err := ioutil.ReadDir(dirname)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, withKind(ErrUnavailable, err, "Failed to list tenant directory %v", dirname)
+	}
+
+	tenants := make([]string, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() {
+			tenants = append(tenants, file.Name())
+		}
+	}
+	sort.Strings(tenants)
+
+	return tenants, nil
+}
+
+// ReadTenants reads all registered tenants from the tenants file
+func (store *FileTenantStore) ReadTenants() ([]model.Tenant, error) {
+	filename := path.Join(store.basePath, tenantsFile)
+	tenants := make([]model.Tenant, 0)
+
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return tenants, nil
+	}
+	if err != nil {
+		return nil, withKind(ErrUnavailable, err, "Failed to read tenants file %v", filename)
+	}
+
+	if err := json.Unmarshal(data, &tenants); err != nil {
+		return nil, errors.Wrapf(err, "Failed to decode tenants file %v", filename)
+	}
+
+	return tenants, nil
+}
+
+// StoreTenants writes all registered tenants to the tenants file
+func (store *FileTenantStore) StoreTenants(tenants []model.Tenant) error {
+	filename := path.Join(store.basePath, tenantsFile)
+
+	data, err := json.MarshalIndent(tenants, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode tenants")
+	}
+
+	if err := writeFileAtomically(filename, data, 0644); err != nil {
+		return withKind(ErrUnavailable, err, "Failed to store tenants file %v", filename)
+	}
+
+	return nil
+}
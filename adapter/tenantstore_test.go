@@ -0,0 +1,30 @@
+package adapter
+
+import (
+	"path"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"maibornwolff/vbump/model"
+)
+
+func TestStoreAndReadTenants(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	store := NewFileTenantStore(t.TempDir())
+	empty, err := store.ReadTenants()
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(empty).To(BeEmpty())
+
+	tenants := []model.Tenant{{Name: "team-a", MaxProjects: 10}}
+	_ = store.StoreTenants(tenants)
+	actual, _ := store.ReadTenants()
+
+	Ω.Expect(actual).To(Equal(tenants))
+}
+
+func TestTenantPath(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(TenantPath("data", "team-a")).To(Equal(path.Join("data", ".tenants", "team-a")))
+}
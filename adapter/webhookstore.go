@@ -0,0 +1,146 @@
+package adapter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"maibornwolff/vbump/model"
+)
+
+const (
+	webhooksFile     = ".webhooks.json"
+	deliveriesDir    = ".deliveries"
+	quarantineSuffix = ".corrupt"
+)
+
+// WebhookStore allows to read and write webhook subscriptions and the queue of pending deliveries
+type WebhookStore interface {
+	ReadWebhooks() ([]model.Webhook, error)
+	StoreWebhooks(webhooks []model.Webhook) error
+	ReadDeliveries() ([]model.Delivery, error)
+	StoreDelivery(delivery model.Delivery) error
+	DeleteDelivery(id string) error
+}
+
+// FileWebhookStore reads and writes webhooks from/to a file and pending deliveries from/to files in the data directory
+type FileWebhookStore struct {
+	basePath string
+}
+
+// NewFileWebhookStore constructs a new file webhook store
+func NewFileWebhookStore(basePath string) WebhookStore {
+	return &FileWebhookStore{basePath: basePath}
+}
+
+// ReadWebhooks reads all webhooks from the webhooks file
+func (store *FileWebhookStore) ReadWebhooks() ([]model.Webhook, error) {
+	filename := path.Join(store.basePath, webhooksFile)
+	webhooks := make([]model.Webhook, 0)
+
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return webhooks, nil
+	}
+	if err != nil {
+		return nil, withKind(ErrUnavailable, err, "Failed to read webhooks file %v", filename)
+	}
+
+	if err := json.Unmarshal(data, &webhooks); err != nil {
+		return nil, errors.Wrapf(err, "Failed to decode webhooks file %v", filename)
+	}
+
+	return webhooks, nil
+}
+
+// StoreWebhooks writes all webhooks to the webhooks file
+func (store *FileWebhookStore) StoreWebhooks(webhooks []model.Webhook) error {
+	filename := path.Join(store.basePath, webhooksFile)
+
+	data, err := json.MarshalIndent(webhooks, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode webhooks")
+	}
+
+	if err := writeFileAtomically(filename, data, 0600); err != nil {
+		return withKind(ErrUnavailable, err, "Failed to store webhooks file %v", filename)
+	}
+
+	return nil
+}
+
+// ReadDeliveries reads all pending deliveries in the order they were queued. Undecodable delivery files are
+// quarantined by renaming them to <id>.json.corrupt, so they neither block the queue nor get lost.
+func (store *FileWebhookStore) ReadDeliveries() ([]model.Delivery, error) {
+	dirname := path.Join(store.basePath, deliveriesDir)
+	deliveries := make([]model.Delivery, 0)
+
+	files, err := ioutil.ReadDir(dirname)
+	if os.IsNotExist(err) {
+		return deliveries, nil
+	}
+	if err != nil {
+		return nil, withKind(ErrUnavailable, err, "Failed to list deliveries directory %v", dirname)
+	}
+
+	for _, file := range files {
+		if file.IsDir() || isTemporary(file.Name()) || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		filename := path.Join(dirname, file.Name())
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, withKind(ErrUnavailable, err, "Failed to read delivery file %v", filename)
+		}
+
+		var delivery model.Delivery
+		if err := json.Unmarshal(data, &delivery); err != nil {
+			log.Warn().Err(err).Str("file", filename).Msg("Quarantined undecodable delivery file")
+			if err := os.Rename(filename, filename+quarantineSuffix); err != nil {
+				return nil, withKind(ErrUnavailable, err, "Failed to quarantine delivery file %v", filename)
+			}
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].Queued.Before(deliveries[j].Queued)
+	})
+
+	return deliveries, nil
+}
+
+// StoreDelivery adds a delivery to the queue or updates it
+func (store *FileWebhookStore) StoreDelivery(delivery model.Delivery) error {
+	dirname := path.Join(store.basePath, deliveriesDir)
+	if err := os.MkdirAll(dirname, 0700); err != nil {
+		return withKind(ErrUnavailable, err, "Failed to create deliveries directory %v", dirname)
+	}
+
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode delivery")
+	}
+
+	filename := path.Join(dirname, delivery.ID+".json")
+	if err := writeFileAtomically(filename, data, 0600); err != nil {
+		return withKind(ErrUnavailable, err, "Failed to store delivery file %v", filename)
+	}
+
+	return nil
+}
+
+// DeleteDelivery removes a delivery from the queue
+func (store *FileWebhookStore) DeleteDelivery(id string) error {
+	filename := path.Join(store.basePath, deliveriesDir, id+".json")
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return withKind(ErrUnavailable, err, "Failed to delete delivery file %v", filename)
+	}
+
+	return nil
+}
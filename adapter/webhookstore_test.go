@@ -0,0 +1,70 @@
+package adapter
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"maibornwolff/vbump/model"
+)
+
+func TestStoreAndReadWebhooks(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	store := NewFileWebhookStore(t.TempDir())
+	empty, err := store.ReadWebhooks()
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(empty).To(BeEmpty())
+
+	webhooks := []model.Webhook{{ID: "1", URL: "https://example.com/hook", Secret: "s3cret", Project: "team"}}
+	Ω.Expect(store.StoreWebhooks(webhooks)).To(Succeed())
+	actual, _ := store.ReadWebhooks()
+
+	Ω.Expect(actual).To(Equal(webhooks))
+}
+
+func TestDeliveryQueue(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	store := NewFileWebhookStore(basePath)
+	now := time.Now().UTC().Truncate(time.Second)
+	later := model.Delivery{ID: "later", Queued: now.Add(time.Minute), Attempts: 2}
+	sooner := model.Delivery{ID: "sooner", Queued: now}
+
+	Ω.Expect(store.StoreDelivery(later)).To(Succeed())
+	Ω.Expect(store.StoreDelivery(sooner)).To(Succeed())
+
+	deliveries, err := NewFileWebhookStore(basePath).ReadDeliveries()
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(deliveries).To(HaveLen(2))
+	Ω.Expect(deliveries[0].ID).To(Equal("sooner"))
+	Ω.Expect(deliveries[1].Attempts).To(Equal(2))
+
+	Ω.Expect(store.DeleteDelivery("sooner")).To(Succeed())
+	Ω.Expect(store.DeleteDelivery("unknown")).To(Succeed())
+	deliveries, _ = store.ReadDeliveries()
+	Ω.Expect(deliveries).To(HaveLen(1))
+
+	projects, _ := NewFileProvider(basePath).ListProjects()
+	Ω.Expect(projects).To(BeEmpty())
+}
+
+func TestQuarantineUndecodableDeliveries(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	store := NewFileWebhookStore(basePath)
+	Ω.Expect(store.StoreDelivery(model.Delivery{ID: "valid", Webhook: "1"})).To(Succeed())
+	dirname := path.Join(basePath, deliveriesDir)
+	_ = ioutil.WriteFile(path.Join(dirname, "torn.json"), []byte(`{"id": "to`), 0600)
+	_ = ioutil.WriteFile(path.Join(dirname, temporaryPrefix+"1"), []byte(`{"id": "temporary"}`), 0600)
+
+	deliveries, err := store.ReadDeliveries()
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(deliveries).To(Equal([]model.Delivery{{ID: "valid", Webhook: "1"}}))
+	Ω.Expect(path.Join(dirname, "torn.json"+quarantineSuffix)).To(BeAnExistingFile())
+	Ω.Expect(path.Join(dirname, "torn.json")).NotTo(BeAnExistingFile())
+}
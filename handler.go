@@ -1,28 +1,83 @@
 package main
 
 import (
+	"fmt"
+	"net"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"maibornwolff/vbump/model"
 	"maibornwolff/vbump/service"
 
 	"github.com/gin-gonic/gin"
-	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog/log"
 )
 
+// modifierHeader names the request header identifying who changes a project
+const modifierHeader = "X-Vbump-User"
+
+// skippedProjectsHeader names the response header listing projects left out of a list because they could not be read
+const skippedProjectsHeader = "X-Vbump-Skipped-Projects"
+
+// byRefSegment precedes the source reference in the last segment of paths looking up the version bumped for it
+const byRefSegment = "by-ref"
+
+const (
+	tenantKey         = "tenant"
+	versionManagerKey = "versionManager"
+)
+
+// TenantResolver returns the tenant a request is bound to, an empty tenant meaning the default tenant
+type TenantResolver func(context *gin.Context) string
+
 // Handler for handling http routes
 type Handler struct {
-	versionManager *service.VersionManager
+	tenants           *service.Tenants
+	resolveTenant     TenantResolver
+	trustedProxies    []*net.IPNet
+	adminToken        string
+	idempotency       *idempotency
+	webhooks          *service.Webhooks
+	events            *service.EventLog
+	eventWriteTimeout time.Duration
+	eventStreamsEnded chan struct{}
+	endEventStreams   sync.Once
 }
 
-// NewHandler constructs a new handler
+// NewHandler constructs a new handler serving the default tenant only
 func NewHandler(versionManager *service.VersionManager) *Handler {
 	return &Handler{
-		versionManager: versionManager,
+		tenants:       service.NewSingleTenant(versionManager),
+		resolveTenant: func(*gin.Context) string { return "" },
 	}
 }
 
+// NewTenantHandler constructs a new handler serving every request for the tenant it is bound to. Only requests
+// passing one of the trusted proxies, which authenticate them and bind them to tenants, are served.
+func NewTenantHandler(tenants *service.Tenants, resolveTenant TenantResolver, trustedProxies []*net.IPNet) *Handler {
+	return &Handler{
+		tenants:        tenants,
+		resolveTenant:  resolveTenant,
+		trustedProxies: append(make([]*net.IPNet, 0, len(trustedProxies)), trustedProxies...),
+	}
+}
+
+func (handler *Handler) versionManagerOf(context *gin.Context) *service.VersionManager {
+	return context.MustGet(versionManagerKey).(*service.VersionManager)
+}
+
+func (handler *Handler) modifyingVersionManagerOf(context *gin.Context) *service.VersionManager {
+	return handler.versionManagerOf(context).WithModifier(context.GetHeader(modifierHeader)).WithPrecondition(preconditionOf(context))
+}
+
+func tenantOf(context *gin.Context) string {
+	return context.GetString(tenantKey)
+}
+
 // LoggerMiddleware logs the last error
 func (handler *Handler) LoggerMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -34,121 +89,384 @@ func (handler *Handler) LoggerMiddleware() gin.HandlerFunc {
 	}
 }
 
+// TenantMiddleware binds the request to the version manager of its tenant
+func (handler *Handler) TenantMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		tenant, err := handler.requestTenant(context)
+		if err != nil {
+			abortWithStatus(context, http.StatusForbidden, err)
+			return
+		}
+
+		versionManager, err := handler.tenants.Manager(tenant)
+		if err != nil {
+			abortWithError(context, err)
+			return
+		}
+
+		context.Set(tenantKey, tenant)
+		context.Set(versionManagerKey, versionManager)
+		context.Next()
+	}
+}
+
 // GetRouter configures all routes
 func (handler *Handler) GetRouter() *gin.Engine {
 	r := gin.New()
 	r.Use(handler.LoggerMiddleware())
+	r.Use(handler.IdempotencyMiddleware())
+	r.UseRawPath = true
 	gin.SetMode(gin.ReleaseMode)
 
-	r.POST("/major/:project", handler.OnMajor)
-	r.POST("/minor/:project", handler.OnMinor)
-	r.POST("/patch/:project", handler.OnPatch)
-	r.POST("/transient/minor/:version", handler.OnTransientMinor)
-	r.POST("/transient/patch/:version", handler.OnTransientPatch)
-	r.POST("/version/:project/:version", handler.OnSetVersion)
-	r.GET("/version/:project", handler.OnGetVersion)
+	t := r.Group("/", handler.TenantMiddleware())
+	t.POST("/major/*project", handler.OnMajor)
+	t.POST("/minor/*project", handler.OnMinor)
+	t.POST("/patch/*project", handler.OnPatch)
+	t.GET("/preview/:part/*project", handler.OnPreview)
+	t.POST("/transient/major/:version", handler.OnTransientMajor)
+	t.POST("/transient/minor/:version", handler.OnTransientMinor)
+	t.POST("/transient/patch/:version", handler.OnTransientPatch)
+	t.POST("/batch", handler.OnBatch)
+	t.POST("/version/*projectVersion", handler.OnSetVersion)
+	t.GET("/version/*project", handler.OnGetVersion)
+	t.GET("/versions", handler.OnGetVersions)
+	t.GET("/projects", handler.OnListProjects)
+	t.GET("/projects/*namespace", handler.OnListProjects)
+	t.GET("/project-settings/*project", handler.OnGetSettings)
+	t.PUT("/project-settings/*project", handler.OnSetSettings)
+	t.GET("/namespace-settings/*namespace", handler.OnGetNamespaceSettings)
+	t.GET("/dependencies/*project", handler.OnGetDependencies)
+	t.PUT("/dependencies/*project", handler.OnSetDependencies)
+	t.GET("/lines/*project", handler.OnGetLines)
+	t.DELETE("/branches/*project", handler.OnDeleteBranch)
+	t.GET("/tags/*project", handler.OnGetTags)
+	t.PUT("/tags/:tag/*projectVersion", handler.OnSetTag)
+	t.DELETE("/tags/:tag/*project", handler.OnDeleteTag)
+	t.GET("/groups", handler.OnListGroups)
+	t.GET("/groups/*group", handler.OnGetGroup)
+	t.PUT("/groups/*group", handler.OnSetGroup)
+	t.PUT("/namespace-settings/*namespace", handler.OnSetNamespaceSettings)
+	t.GET("/webhooks", handler.OnListWebhooks)
+	t.POST("/webhooks", handler.OnAddWebhook)
+	t.DELETE("/webhooks/:id", handler.OnDeleteWebhook)
+	t.GET("/events", handler.OnEvents)
+	t.GET("/admin/export", handler.AdminMiddleware(), handler.OnExport)
+	t.POST("/admin/import", handler.AdminMiddleware(), handler.OnImport)
+	a := r.Group("/admin", handler.AdminMiddleware())
+	a.GET("/tenants", handler.OnListTenants)
+	a.PUT("/tenants/:tenant", handler.OnPutTenant)
+	a.DELETE("/tenants/:tenant", handler.OnDeleteTenant)
+	handler.registerAPIRoutes(r)
 	r.GET("/", handler.OnHealth)
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	return r
 }
 
+// projectParam returns the project name of the request, which may contain namespaces separated by slashes
+func projectParam(context *gin.Context) string {
+	return model.NormalizeName(context.Param("project"))
+}
+
+// splitProjectVersion splits a path like team/service/1.0 into the project and the version in its last segment
+func splitProjectVersion(projectVersion string) (string, string) {
+	projectVersion = model.NormalizeName(projectVersion)
+	index := strings.LastIndex(projectVersion, model.NamespaceSeparator)
+	if index < 0 {
+		return "", projectVersion
+	}
+
+	return projectVersion[:index], projectVersion[index+1:]
+}
+
 // OnHealth is a handler for a health check
 func (handler *Handler) OnHealth(context *gin.Context) {
-	context.String(http.StatusOK, "hello from vbump!")
+	respond(context, http.StatusOK, "hello from vbump!", gin.H{"status": "ok", "message": "hello from vbump!"})
 }
 
 // OnMajor is a handler for bumping the major part for a given project
 func (handler *Handler) OnMajor(context *gin.Context) {
-	project := context.Param("project")
-	version, err := handler.versionManager.BumpMajor(project)
-	if err != nil {
-		_ = context.AbortWithError(http.StatusInternalServerError, err)
-		return
-	}
-
-	numberOfBumps.With(prometheus.Labels{"project": project, "element": "major"}).Inc()
-	log.Info().Str("version", version.String()).Str("project", project).Msg("Bumped major version")
-	context.String(http.StatusOK, "%s", version.String())
+	handler.onBump(context, model.PartMajor, bumpOptionsOf(context))
 }
 
 // OnMinor is a handler for bumping the minor part for a given project
 func (handler *Handler) OnMinor(context *gin.Context) {
-	project := context.Param("project")
-	version, err := handler.versionManager.BumpMinor(project)
+	handler.onBump(context, model.PartMinor, bumpOptionsOf(context))
+}
+
+// OnPatch is a handler for bumping the patch part for a given project
+func (handler *Handler) OnPatch(context *gin.Context) {
+	handler.onBump(context, model.PartPatch, bumpOptionsOf(context))
+}
+
+// OnPreview is a handler for previewing the bump of a given part for a given project without changing it
+func (handler *Handler) OnPreview(context *gin.Context) {
+	handler.onPreview(context, context.Param("part"), bumpOptionsOf(context))
+}
+
+func (handler *Handler) onBump(context *gin.Context, part string, options bumpOptions) {
+	if context.Query("dryRun") == "true" {
+		handler.onPreview(context, part, options)
+		return
+	}
+	if options.branch != "" || options.line != "" {
+		handler.onStreamBump(context, part, options, false)
+		return
+	}
+
+	project := projectParam(context)
+	bump, err := handler.modifyingVersionManagerOf(context).BumpRef(project, part, options.ref)
 	if err != nil {
-		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		abortWithError(context, err)
 		return
 	}
 
-	numberOfBumps.With(prometheus.Labels{"project": project, "element": "minor"}).Inc()
-	log.Info().Str("version", version.String()).Str("project", project).Msg("Bumped minor version")
-	context.String(http.StatusOK, "%s", version.String())
+	if bump.Reused {
+		log.Info().Str("version", bump.Version.String()).Str("project", project).Str("ref", options.ref).Msg("Reused version bumped for source reference")
+		respond(context, http.StatusOK, bump.Version.String(), bump)
+		return
+	}
+
+	log.Info().Str("version", bump.Version.String()).Str("project", project).Msgf("Bumped %s version", part)
+	countBump(context, bump)
+	respond(context, http.StatusOK, bump.Version.String(), bump)
 }
 
-// OnPatch is a handler for bumping the patch part for a given project
-func (handler *Handler) OnPatch(context *gin.Context) {
-	project := context.Param("project")
-	version, err := handler.versionManager.BumpPatch(project)
+func (handler *Handler) onPreview(context *gin.Context, part string, options bumpOptions) {
+	if options.branch != "" || options.line != "" {
+		handler.onStreamBump(context, part, options, true)
+		return
+	}
+
+	project := projectParam(context)
+	bump, err := handler.modifyingVersionManagerOf(context).PreviewBump(project, part, options.ref)
 	if err != nil {
-		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		abortWithError(context, err)
 		return
 	}
 
-	numberOfBumps.With(prometheus.Labels{"project": project, "element": "patch"}).Inc()
-	log.Info().Str("version", version.String()).Str("project", project).Msg("Bumped patch version")
-	context.String(http.StatusOK, "%s", version.String())
+	log.Info().Str("version", bump.Version.String()).Str("project", project).Msgf("Previewed %s version", part)
+	respond(context, http.StatusOK, bump.Version.String(), bump)
 }
 
 // OnSetVersion is a handler for setting the version for a given project
 func (handler *Handler) OnSetVersion(context *gin.Context) {
-	project := context.Param("project")
-	version := context.Param("version")
-	_, err := handler.versionManager.SetVersion(project, version)
+	project, version := splitProjectVersion(context.Param("projectVersion"))
+	_, err := handler.modifyingVersionManagerOf(context).SetVersion(project, version)
 	if err != nil {
-		_ = context.AbortWithError(http.StatusBadRequest, err)
+		abortWithError(context, err)
 		return
 	}
-
 	log.Info().Str("version", version).Str("project", project).Msg("Set version explicitly")
-	context.String(http.StatusOK, "%s", version)
+	respond(context, http.StatusOK, version, versionResponse{Project: project, Version: version})
 }
 
-// OnGetVersion is a handler for getting the version for a given project
+// OnGetVersion is a handler for getting the version for a given project, optionally at a given point in time, of a
+// branch, within a release line, of a distribution tag or bumped for a source reference given as query parameter or
+// as /by-ref/<ref> after the project
 func (handler *Handler) OnGetVersion(context *gin.Context) {
-	project := context.Param("project")
-	version, err := handler.versionManager.GetVersion(project)
+	project := projectParam(context)
+	if ref := context.Query("ref"); ref != "" {
+		handler.onGetVersionByRef(context, project, ref)
+		return
+	}
+	if prefix, ref := splitProjectVersion(project); prefix != "" {
+		if byRefProject, segment := splitProjectVersion(prefix); segment == byRefSegment && byRefProject != "" {
+			handler.onGetVersionByRef(context, byRefProject, ref)
+			return
+		}
+	}
+	if _, ok := context.GetQuery("at"); ok {
+		handler.onGetVersionAt(context, project)
+		return
+	}
+	if branch := context.Query("branch"); branch != "" {
+		handler.onGetBranchVersion(context, project, branch)
+		return
+	}
+	if line := context.Query("line"); line != "" {
+		handler.onGetLineVersion(context, project, line)
+		return
+	}
+	if tag := context.Query("tag"); tag != "" {
+		handler.onGetTag(context, project, tag)
+		return
+	}
+
+	storedProject, err := handler.versionManagerOf(context).GetProject(project)
 	if err != nil {
-		_ = context.AbortWithError(http.StatusNotFound, err)
+		abortWithError(context, err)
 		return
 	}
 
+	context.Header("ETag", etag(storedProject))
+	if !(model.Precondition{IfNoneMatch: preconditionOf(context).IfNoneMatch}).Holds(storedProject, true) {
+		context.AbortWithStatus(http.StatusNotModified)
+		return
+	}
+
+	version := storedProject.Version
 	log.Info().Str("project", project).Msg("Got version")
-	context.String(http.StatusOK, "%s", version.String())
+	respond(context, http.StatusOK, version.String(), versionResponse{Project: project, Version: version.String()})
+}
+
+// OnGetVersionByRef is a handler for getting the version bumped for a source reference of a given project
+func (handler *Handler) OnGetVersionByRef(context *gin.Context) {
+	handler.onGetVersionByRef(context, projectParam(context), context.Param("ref"))
+}
+
+func (handler *Handler) onGetVersionByRef(context *gin.Context, project string, ref string) {
+	version, err := handler.versionManagerOf(context).GetVersionByRef(project, ref)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("project", project).Str("ref", ref).Msg("Got version for source reference")
+	respond(context, http.StatusOK, version.String(), versionResponse{Project: project, Version: version.String(), Ref: ref})
+}
+
+func (handler *Handler) onGetVersionAt(context *gin.Context, project string) {
+	at, err := time.Parse(time.RFC3339, context.Query("at"))
+	if err != nil {
+		abortWithStatus(context, http.StatusBadRequest, err)
+		return
+	}
+
+	version, err := handler.versionManagerOf(context).GetVersionAt(project, at)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("project", project).Time("at", at).Msg("Got version at point in time")
+	respond(context, http.StatusOK, version.String(), versionResponse{Project: project, Version: version.String(), At: at.UTC().Format(time.RFC3339)})
+}
+
+// OnGetVersions is a handler for getting the versions of all projects, optionally at a given point in time
+func (handler *Handler) OnGetVersions(context *gin.Context) {
+	var versions map[string]model.Version
+	var err error
+
+	var tags map[string]map[string]model.Version
+	if atString, ok := context.GetQuery("at"); ok {
+		at, parseErr := time.Parse(time.RFC3339, atString)
+		if parseErr != nil {
+			abortWithStatus(context, http.StatusBadRequest, parseErr)
+			return
+		}
+		versions, err = handler.versionManagerOf(context).GetVersionsAt(at)
+	} else {
+		var projects map[string]model.Project
+		var skipped []string
+		projects, skipped, err = handler.versionManagerOf(context).GetProjects("")
+		versions, tags = versionsAndTags(projects)
+		reportSkipped(context, skipped)
+	}
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Int("projects", len(versions)).Msg("Got versions")
+	respond(context, http.StatusOK, formatVersions(versions), versionList(versions, tags))
+}
+
+func formatVersions(versions map[string]model.Version) string {
+	var builder strings.Builder
+	for _, project := range sortedProjects(versions) {
+		fmt.Fprintf(&builder, "%s %s\n", project, versions[project].String())
+	}
+
+	return builder.String()
+}
+
+// versionList lists the versions of projects with their distribution tags
+func versionList(versions map[string]model.Version, tags map[string]map[string]model.Version) []versionResponse {
+	list := make([]versionResponse, 0, len(versions))
+	for _, project := range sortedProjects(versions) {
+		response := versionResponse{Project: project, Version: versions[project].String()}
+		if len(tags[project]) > 0 {
+			response.Tags = tagMap(tags[project])
+		}
+		list = append(list, response)
+	}
+
+	return list
+}
+
+// reportSkipped names the projects left out of a list in a response header
+func reportSkipped(context *gin.Context, skipped []string) {
+	if len(skipped) > 0 {
+		context.Header(skippedProjectsHeader, strings.Join(skipped, ","))
+	}
+}
+
+// versionsAndTags returns the versions and the distribution tags of the given projects
+func versionsAndTags(projects map[string]model.Project) (map[string]model.Version, map[string]map[string]model.Version) {
+	versions := make(map[string]model.Version, len(projects))
+	tags := make(map[string]map[string]model.Version)
+	for project, storedProject := range projects {
+		versions[project] = storedProject.Version
+		if len(storedProject.Metadata.Tags) > 0 {
+			tags[project] = storedProject.Metadata.Tags
+		}
+	}
+
+	return versions, tags
+}
+
+func sortedProjects(versions map[string]model.Version) []string {
+	projects := make([]string, 0, len(versions))
+	for project := range versions {
+		projects = append(projects, project)
+	}
+	sort.Strings(projects)
+
+	return projects
+}
+
+// OnTransientMajor is a handler for a transient major bump
+func (handler *Handler) OnTransientMajor(context *gin.Context) {
+	version := context.Param("version")
+	bumpedVersion, err := handler.versionManagerOf(context).BumpTransientMajor(version)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("version", bumpedVersion.String()).Msg("Bumped major version transiently")
+	respond(context, http.StatusOK, bumpedVersion.String(), transientBump(model.PartMajor, version, bumpedVersion))
 }
 
 // OnTransientPatch is a handler for a transient patch bump
 func (handler *Handler) OnTransientPatch(context *gin.Context) {
 	version := context.Param("version")
-	bumpedVersion, err := handler.versionManager.BumpTransientPatch(version)
+	bumpedVersion, err := handler.versionManagerOf(context).BumpTransientPatch(version)
 	if err != nil {
-		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		abortWithError(context, err)
 		return
 	}
 
 	log.Info().Str("version", bumpedVersion.String()).Msg("Bumped patch version transiently")
-	context.String(http.StatusOK, "%s", bumpedVersion.String())
+	respond(context, http.StatusOK, bumpedVersion.String(), transientBump(model.PartPatch, version, bumpedVersion))
 }
 
 // OnTransientMinor is a handler for a transient minor bump
 func (handler *Handler) OnTransientMinor(context *gin.Context) {
 	version := context.Param("version")
-	bumpedVersion, err := handler.versionManager.BumpTransientMinor(version)
+	bumpedVersion, err := handler.versionManagerOf(context).BumpTransientMinor(version)
 	if err != nil {
-		_ = context.AbortWithError(http.StatusInternalServerError, err)
+		abortWithError(context, err)
 		return
 	}
 
 	log.Info().Str("version", bumpedVersion.String()).Msg("Bumped minor version transiently")
-	context.String(http.StatusOK, "%s", bumpedVersion.String())
+	respond(context, http.StatusOK, bumpedVersion.String(), transientBump(model.PartMinor, version, bumpedVersion))
+}
+
+// transientBump describes a transient bump of an already validated version
+func transientBump(part string, version string, bumpedVersion model.Version) model.Bump {
+	previousVersion, _ := model.FromVersionString(version)
+	return model.Bump{Part: part, PreviousVersion: previousVersion, Version: bumpedVersion}
 }
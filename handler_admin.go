@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"maibornwolff/vbump/model"
+	"maibornwolff/vbump/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// EnableAdmin serves the admin routes to requests authenticated with the given bearer token
+func (handler *Handler) EnableAdmin(token string) {
+	handler.adminToken = token
+}
+
+// AdminMiddleware rejects requests without the admin token and all requests while admin routes are not enabled
+func (handler *Handler) AdminMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if handler.adminToken == "" {
+			abortWithStatus(context, http.StatusForbidden, errors.New("Admin routes are not enabled"))
+			return
+		}
+
+		authorization := context.GetHeader("Authorization")
+		token := strings.TrimPrefix(authorization, "Bearer ")
+		if token == authorization || subtle.ConstantTimeCompare([]byte(token), []byte(handler.adminToken)) != 1 {
+			context.Header("WWW-Authenticate", `Bearer realm="vbump admin"`)
+			abortWithStatus(context, http.StatusUnauthorized, errors.New("Admin routes require the admin token"))
+			return
+		}
+
+		context.Next()
+	}
+}
+
+// OnExport is a handler for exporting all projects with their metadata and history as JSON snapshot
+func (handler *Handler) OnExport(context *gin.Context) {
+	snapshot, err := handler.versionManagerOf(context).Export()
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Int("projects", len(snapshot.Projects)).Msg("Exported projects")
+	context.Header("Content-Disposition", "attachment; filename=vbump-export.json")
+	context.JSON(http.StatusOK, snapshot)
+}
+
+// OnImport is a handler for importing a JSON snapshot, resolving conflicts with the strategy given as query parameter
+func (handler *Handler) OnImport(context *gin.Context) {
+	strategy, err := service.ParseImportStrategy(context.DefaultQuery("strategy", string(service.ImportSkip)))
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	var snapshot model.Snapshot
+	if err := context.ShouldBindJSON(&snapshot); err != nil {
+		abortWithStatus(context, http.StatusBadRequest, err)
+		return
+	}
+
+	outcomes, err := handler.versionManagerOf(context).Import(snapshot, strategy)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Int("projects", len(outcomes)).Str("strategy", string(strategy)).Msg("Imported projects")
+	respond(context, http.StatusOK, formatImportOutcomes(outcomes), outcomes)
+}
+
+func formatImportOutcomes(outcomes map[string]service.ImportOutcome) string {
+	projects := make([]string, 0, len(outcomes))
+	for project := range outcomes {
+		projects = append(projects, project)
+	}
+	sort.Strings(projects)
+
+	var builder strings.Builder
+	for _, project := range projects {
+		fmt.Fprintf(&builder, "%s %s\n", project, outcomes[project])
+	}
+
+	return builder.String()
+}
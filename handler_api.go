@@ -0,0 +1,156 @@
+package main
+
+import (
+	"net/http"
+
+	"maibornwolff/vbump/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// apiPrefix is the base path of the versioned REST API
+const apiPrefix = "/api/v1"
+
+// jsonOnlyKey marks requests which are always answered with JSON
+const jsonOnlyKey = "jsonOnly"
+
+// apiRoute describes a route of the versioned REST API, used to register it and to document it in the OpenAPI spec
+type apiRoute struct {
+	method   string
+	path     string
+	summary  string
+	query    []string
+	request  string
+	response string
+	handle   gin.HandlerFunc
+}
+
+// bumpRequest is the JSON body of requests bumping a version
+type bumpRequest struct {
+	Part    string `json:"part" binding:"required"`
+	Version string `json:"version,omitempty"`
+	Ref     string `json:"ref,omitempty"`
+	Branch  string `json:"branch,omitempty"`
+	Line    string `json:"line,omitempty"`
+}
+
+// setVersionRequest is the JSON body of requests setting a project's version
+type setVersionRequest struct {
+	Version string `json:"version" binding:"required"`
+}
+
+func (handler *Handler) apiRoutes() []apiRoute {
+	return []apiRoute{
+		{"GET", "/projects", "List the versions of all projects", []string{"namespace"}, "", "[]ProjectVersion", handler.OnAPIListProjects},
+		{"GET", "/projects/:project", "Get the version of a project, at a point in time, of a branch, within a release line or of a tag", []string{"at", "branch", "line", "tag"}, "", "ProjectVersion", handler.OnGetVersion},
+		{"DELETE", "/projects/:project/branches", "Remove the version stream of a branch of a project", []string{"branch"}, "", "ProjectVersion", handler.OnDeleteBranch},
+		{"GET", "/projects/:project/lines", "Get the latest version of every release line of a project", nil, "", "[]Line", handler.OnGetLines},
+		{"GET", "/projects/:project/tags", "Get the distribution tags of a project", nil, "", "Tags", handler.OnGetTags},
+		{"GET", "/projects/:project/tags/:tag", "Get the version a distribution tag of a project points to", nil, "", "ProjectVersion", handler.OnGetTag},
+		{"PUT", "/projects/:project/tags/:tag", "Point a distribution tag of a project to a version the project had", nil, "SetVersionRequest", "ProjectVersion", handler.OnAPISetTag},
+		{"DELETE", "/projects/:project/tags/:tag", "Remove a distribution tag of a project", nil, "", "Tags", handler.OnDeleteTag},
+		{"GET", "/projects/:project/refs/:ref", "Get the version bumped for a source reference of a project", nil, "", "ProjectVersion", handler.OnGetVersionByRef},
+		{"PUT", "/projects/:project/version", "Set the version of a project", nil, "SetVersionRequest", "ProjectVersion", handler.OnAPISetVersion},
+		{"POST", "/projects/:project/bumps", "Bump a part of the version of a project, only previewing it with dryRun=true", []string{"dryRun"}, "BumpRequest", "Bump", handler.OnAPIBump},
+		{"GET", "/projects/:project/settings", "Get the settings of a project including inherited ones", nil, "", "Settings", handler.OnGetSettings},
+		{"PUT", "/projects/:project/settings", "Replace the own settings of a project", nil, "Settings", "Settings", handler.OnSetSettings},
+		{"GET", "/projects/:project/dependencies", "Get the projects a project depends on", nil, "", "ProjectNames", handler.OnGetDependencies},
+		{"PUT", "/projects/:project/dependencies", "Replace the projects a project depends on, which its bumps cascade from", nil, "ProjectNames", "ProjectNames", handler.OnSetDependencies},
+		{"GET", "/groups", "List all groups of projects sharing one version", nil, "", "[]Group", handler.OnListGroups},
+		{"GET", "/groups/:group", "Get the members and the shared version of a group", nil, "", "Group", handler.OnGetGroup},
+		{"PUT", "/groups/:group", "Replace the members of a group, new members adopting the highest version of all members", nil, "ProjectNames", "Group", handler.OnSetGroup},
+		{"GET", "/namespaces/:namespace/settings", "Get the settings of a namespace including inherited ones", nil, "", "Settings", handler.OnGetNamespaceSettings},
+		{"PUT", "/namespaces/:namespace/settings", "Replace the own settings of a namespace", nil, "Settings", "Settings", handler.OnSetNamespaceSettings},
+		{"GET", "/webhooks", "List the webhooks of the tenant without their secrets", nil, "", "[]Webhook", handler.OnListWebhooks},
+		{"POST", "/webhooks", "Subscribe a webhook to the bumps and set versions of a project, a namespace or the tenant", nil, "Webhook", "Webhook", handler.OnAddWebhook},
+		{"DELETE", "/webhooks/:id", "Unsubscribe a webhook", nil, "", "[]Webhook", handler.OnDeleteWebhook},
+		{"POST", "/batches", "Apply bumps and set versions of several projects all or nothing", nil, "BatchRequest", "BatchResponse", handler.OnBatch},
+		{"POST", "/bumps", "Bump a part of a given version without changing any project", nil, "BumpRequest", "Bump", handler.OnAPITransientBump},
+	}
+}
+
+// registerAPIRoutes registers the versioned REST API and its OpenAPI document
+func (handler *Handler) registerAPIRoutes(router *gin.Engine) {
+	api := router.Group(apiPrefix, handler.JSONOnlyMiddleware(), handler.TenantMiddleware())
+	for _, route := range handler.apiRoutes() {
+		api.Handle(route.method, route.path, route.handle)
+	}
+	router.GET(apiPrefix+"/openapi.json", handler.OnOpenAPI)
+}
+
+// JSONOnlyMiddleware answers every request with JSON regardless of its Accept header
+func (handler *Handler) JSONOnlyMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		context.Set(jsonOnlyKey, true)
+		context.Next()
+	}
+}
+
+// OnAPIListProjects is a handler for listing the versions of all projects, optionally within a namespace
+func (handler *Handler) OnAPIListProjects(context *gin.Context) {
+	namespace := model.NormalizeName(context.Query("namespace"))
+	projects, skipped, err := handler.versionManagerOf(context).GetProjects(namespace)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+	versions, tags := versionsAndTags(projects)
+	reportSkipped(context, skipped)
+
+	log.Info().Str("namespace", namespace).Int("projects", len(versions)).Msg("Got versions")
+	context.JSON(http.StatusOK, versionList(versions, tags))
+}
+
+// OnAPISetVersion is a handler for setting the version of a project given in the JSON request body
+func (handler *Handler) OnAPISetVersion(context *gin.Context) {
+	project := projectParam(context)
+	var request setVersionRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		abortWithStatus(context, http.StatusBadRequest, err)
+		return
+	}
+
+	bump, err := handler.modifyingVersionManagerOf(context).SetVersionBump(project, request.Version)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("version", bump.Version.String()).Str("project", project).Msg("Set version explicitly")
+	context.JSON(http.StatusOK, versionResponse{Project: project, Version: bump.Version.String()})
+}
+
+// OnAPIBump is a handler for bumping the part of a project's version given in the JSON request body
+func (handler *Handler) OnAPIBump(context *gin.Context) {
+	var request bumpRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		abortWithStatus(context, http.StatusBadRequest, err)
+		return
+	}
+
+	handler.onBump(context, request.Part, bumpOptions{ref: request.Ref, branch: request.Branch, line: request.Line})
+}
+
+// OnAPITransientBump is a handler for bumping the part of the version given in the JSON request body
+func (handler *Handler) OnAPITransientBump(context *gin.Context) {
+	var request bumpRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		abortWithStatus(context, http.StatusBadRequest, err)
+		return
+	}
+
+	bumpedVersion, err := handler.versionManagerOf(context).BumpTransient(request.Version, request.Part)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("version", bumpedVersion.String()).Msgf("Bumped %s version transiently", request.Part)
+	context.JSON(http.StatusOK, transientBump(request.Part, request.Version, bumpedVersion))
+}
+
+// OnOpenAPI is a handler serving the OpenAPI document of the versioned REST API
+func (handler *Handler) OnOpenAPI(context *gin.Context) {
+	context.JSON(http.StatusOK, openAPIDocument(handler.apiRoutes()))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/service"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestOpenAPIDocumentsRouter(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	router := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir()))).GetRouter()
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/openapi.json", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+
+	var document struct {
+		OpenAPI string                                `json:"openapi"`
+		Servers []struct{ URL string }                `json:"servers"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	Ω.Expect(json.Unmarshal(res.Body.Bytes(), &document)).To(Succeed())
+	Ω.Expect(document.OpenAPI).To(HavePrefix("3."))
+	Ω.Expect(document.Servers[0].URL).To(Equal(apiPrefix))
+
+	documented := make([]string, 0)
+	for path, operations := range document.Paths {
+		for method := range operations {
+			documented = append(documented, strings.ToUpper(method)+" "+apiPrefix+path)
+		}
+	}
+	routed := make([]string, 0)
+	for _, route := range router.Routes() {
+		if strings.HasPrefix(route.Path, apiPrefix+"/") && route.Path != apiPrefix+"/openapi.json" {
+			routed = append(routed, route.Method+" "+openAPIPath(route.Path))
+		}
+	}
+	sort.Strings(documented)
+	sort.Strings(routed)
+
+	Ω.Expect(routed).NotTo(BeEmpty())
+	Ω.Expect(documented).To(Equal(routed))
+}
+
+func TestOpenAPIReferencesDefinedSchemas(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	handler := NewHandler(nil)
+	for _, route := range handler.apiRoutes() {
+		for _, schema := range []string{route.request, route.response} {
+			if schema != "" {
+				Ω.Expect(openAPISchemas).To(HaveKey(strings.TrimPrefix(schema, "[]")), route.path)
+			}
+		}
+	}
+}
+
+func TestVersionedAPI(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	router := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir()))).GetRouter()
+
+	res := serveRequest(router, "PUT", "/api/v1/projects/team%2Fp1/version", `{"version": "1.0.0"}`)
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"project": "team/p1", "version": "1.0.0"}`))
+
+	res = serveRequest(router, "POST", "/api/v1/projects/team%2Fp1/bumps", `{"part": "minor"}`)
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"project": "team/p1", "part": "minor", "previousVersion": "1.0.0", "version": "1.1.0"}`))
+
+	res = serveRequest(router, "GET", "/api/v1/projects/team%2Fp1", "")
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"project": "team/p1", "version": "1.1.0"}`))
+
+	res = serveRequest(router, "GET", "/api/v1/projects?namespace=team", "")
+	Ω.Expect(res.Body.String()).To(MatchJSON(`[{"project": "team/p1", "version": "1.1.0"}]`))
+
+	res = serveRequest(router, "GET", "/api/v1/projects?namespace=other", "")
+	Ω.Expect(res.Body.String()).To(MatchJSON(`[]`))
+
+	res = serveRequest(router, "POST", "/api/v1/bumps", `{"part": "patch", "version": "1.2"}`)
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"part": "patch", "previousVersion": "1.2", "version": "1.2.1"}`))
+
+	res = serveRequest(router, "POST", "/api/v1/projects/team%2Fp1/bumps", `{"part": "build"}`)
+	Ω.Expect(res.Code).To(Equal(400))
+	Ω.Expect(res.Body.String()).To(ContainSubstring(`"code":"bad_request"`))
+
+	res = serveRequest(router, "POST", "/api/v1/projects/unknown/bumps", `{}`)
+	Ω.Expect(res.Code).To(Equal(400))
+
+	res = serveRequest(router, "GET", "/api/v1/projects/unknown", "")
+	Ω.Expect(res.Code).To(Equal(404))
+	Ω.Expect(res.Body.String()).To(ContainSubstring(`"code":"not_found"`))
+}
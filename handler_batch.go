@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"maibornwolff/vbump/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+// batchRequest is the JSON body of requests changing several projects at once
+type batchRequest struct {
+	Items []model.BatchItem `json:"items" binding:"required"`
+}
+
+// batchResponse is the JSON body answering a batch with the bumps of all its items
+type batchResponse struct {
+	Bumps []model.Bump `json:"bumps"`
+}
+
+// OnBatch is a handler for applying the bumps and set versions of the JSON request body all or nothing. Preconditions
+// are stated per item as ifMatch, since the If-Match and If-None-Match headers cannot name the project they apply to.
+func (handler *Handler) OnBatch(context *gin.Context) {
+	var request batchRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		abortWithStatus(context, http.StatusBadRequest, err)
+		return
+	}
+	if context.GetHeader("If-Match") != "" || context.GetHeader("If-None-Match") != "" {
+		abortWithStatus(context, http.StatusBadRequest, errors.New("Batches take preconditions per item as ifMatch instead of If-Match and If-None-Match headers"))
+		return
+	}
+
+	versionManager := handler.versionManagerOf(context).WithModifier(context.GetHeader(modifierHeader))
+	bumps, err := versionManager.ApplyBatch(request.Items)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	for _, bump := range bumps {
+		if bump.Part != model.OperationSet {
+			countBump(context, bump)
+		}
+	}
+
+	log.Info().Int("items", len(bumps)).Msg("Applied batch")
+	respond(context, http.StatusOK, formatBumps(bumps), batchResponse{Bumps: bumps})
+}
+
+// countBump counts a bump of a project along with the members of its group and the bumps cascaded to dependent
+// projects, logging the cascaded ones
+func countBump(context *gin.Context, bump model.Bump) {
+	for _, project := range append([]string{bump.Project}, bump.Linked...) {
+		numberOfBumps.With(prometheus.Labels{"tenant": tenantOf(context), "project": project, "element": bump.Part}).Inc()
+	}
+	for _, cascaded := range bump.Cascaded {
+		log.Info().Str("version", cascaded.Version.String()).Str("project", cascaded.Project).Strs("cascadedFrom", cascaded.CascadedFrom).Msgf("Cascaded %s version", cascaded.Part)
+		countBump(context, cascaded)
+	}
+}
+
+func formatBumps(bumps []model.Bump) string {
+	var builder strings.Builder
+	for _, bump := range bumps {
+		fmt.Fprintf(&builder, "%s %s\n", bump.Project, bump.Version.String())
+	}
+
+	return builder.String()
+}
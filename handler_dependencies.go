@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// OnGetDependencies is a handler for getting the projects a project depends on
+func (handler *Handler) OnGetDependencies(context *gin.Context) {
+	project := projectParam(context)
+	dependencies, err := handler.versionManagerOf(context).GetDependencies(project)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("project", project).Int("dependencies", len(dependencies)).Msg("Got dependencies")
+	respond(context, http.StatusOK, joinLines(dependencies), dependencies)
+}
+
+// OnSetDependencies is a handler for replacing the projects a project depends on with the JSON list in the request body
+func (handler *Handler) OnSetDependencies(context *gin.Context) {
+	project := projectParam(context)
+	var dependencies []string
+	if err := context.ShouldBindJSON(&dependencies); err != nil {
+		abortWithStatus(context, http.StatusBadRequest, err)
+		return
+	}
+
+	versionManager := handler.modifyingVersionManagerOf(context)
+	if err := versionManager.SetDependencies(project, dependencies); err != nil {
+		abortWithError(context, err)
+		return
+	}
+	dependencies, err := versionManager.GetDependencies(project)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("project", project).Int("dependencies", len(dependencies)).Msg("Set dependencies")
+	respond(context, http.StatusOK, joinLines(dependencies), dependencies)
+}
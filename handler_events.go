@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"maibornwolff/vbump/model"
+	"maibornwolff/vbump/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// lastEventIDHeader names the request header of reconnecting event stream clients holding the last event they received
+const lastEventIDHeader = "Last-Event-ID"
+
+// eventStreamRetry is the time event stream clients wait before reconnecting
+const eventStreamRetry = time.Second
+
+// resetEvent tells event stream clients that events they missed are no longer retained, so they need to reload the
+// versions they track
+const resetEvent = "reset"
+
+// EnableEvents makes the handler number the events of all bumps and set versions in the given event log and stream
+// them to clients. Streams are not bound to the server's write timeout; instead every write to a stream has to
+// complete within the given timeout, if any.
+func (handler *Handler) EnableEvents(eventLog *service.EventLog, writeTimeout time.Duration) {
+	handler.events = eventLog
+	handler.eventWriteTimeout = writeTimeout
+	handler.eventStreamsEnded = make(chan struct{})
+	handler.tenants.LogEvents(eventLog)
+}
+
+// EndEventStreams ends all event streams when the server shuts down, clients reconnecting with the last event they
+// received. Streams opened afterwards end once they sent the events the client missed.
+func (handler *Handler) EndEventStreams() {
+	if handler.eventStreamsEnded != nil {
+		handler.endEventStreams.Do(func() { close(handler.eventStreamsEnded) })
+	}
+}
+
+// OnEvents is a handler for streaming the bumps and set versions of the tenant as server-sent events, optionally only
+// those of a project or of the projects within a namespace. Clients reconnecting with a Last-Event-ID header or
+// lastEventId query parameter first receive the retained events they missed, preceded by a reset event if some of
+// them are no longer retained.
+func (handler *Handler) OnEvents(context *gin.Context) {
+	if handler.events == nil {
+		abortWithError(context, errors.Wrap(service.ErrNotFound, "Event streams are not enabled"))
+		return
+	}
+
+	filter := model.EventFilter{
+		Tenant:    tenantOf(context),
+		Project:   model.NormalizeName(context.Query("project")),
+		Namespace: model.NormalizeName(context.Query("namespace")),
+	}
+	lastEventID := context.GetHeader(lastEventIDHeader)
+	if lastEventID == "" {
+		lastEventID = context.Query("lastEventId")
+	}
+
+	subscription, err := handler.events.Subscribe(filter.Tenant, lastEventID)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+	defer subscription.Cancel()
+
+	header := context.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	context.Status(http.StatusOK)
+	controller := http.NewResponseController(context.Writer)
+	extendDeadline := func() {
+		deadline := time.Time{}
+		if handler.eventWriteTimeout > 0 {
+			deadline = time.Now().Add(handler.eventWriteTimeout)
+		}
+		if err := controller.SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			log.Warn().Err(err).Msg("Failed to set write deadline of event stream")
+		}
+	}
+
+	extendDeadline()
+	log.Debug().Str("project", filter.Project).Str("namespace", filter.Namespace).Str("lastEventId", lastEventID).Int("missed", len(subscription.Missed)).Bool("reset", subscription.Reset).Msg("Streaming events")
+	fmt.Fprintf(context.Writer, "retry: %d\n\n", eventStreamRetry.Milliseconds())
+	if subscription.Reset {
+		fmt.Fprintf(context.Writer, "event: %s\ndata: {}\n\n", resetEvent)
+	}
+	for _, event := range subscription.Missed {
+		if filter.Matches(event) {
+			writeEvent(context.Writer, event)
+		}
+	}
+	context.Writer.Flush()
+
+	for {
+		select {
+		case <-context.Request.Context().Done():
+			return
+		case <-handler.eventStreamsEnded:
+			return
+		case event, open := <-subscription.Events:
+			if !open {
+				// the client fell behind and resumes after reconnecting
+				return
+			}
+			if filter.Matches(event) {
+				extendDeadline()
+				writeEvent(context.Writer, event)
+				context.Writer.Flush()
+			}
+		}
+	}
+}
+
+// writeEvent writes an event in the server-sent events format
+func writeEvent(writer io.Writer, event model.Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Error().Err(err).Str("event", event.ID).Msg("Failed to encode event")
+		return
+	}
+
+	fmt.Fprintf(writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
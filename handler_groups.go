@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"maibornwolff/vbump/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// OnListGroups is a handler for listing all groups with their members and shared version
+func (handler *Handler) OnListGroups(context *gin.Context) {
+	groups, err := handler.versionManagerOf(context).ListGroups()
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Int("groups", len(groups)).Msg("Listed groups")
+	respond(context, http.StatusOK, formatGroups(groups...), groups)
+}
+
+// OnGetGroup is a handler for getting the members and the shared version of a group
+func (handler *Handler) OnGetGroup(context *gin.Context) {
+	name := model.NormalizeName(context.Param("group"))
+	group, err := handler.versionManagerOf(context).GetGroup(name)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	context.Header("ETag", groupETag(group))
+	log.Info().Str("group", name).Msg("Got group")
+	respond(context, http.StatusOK, formatGroups(group), group)
+}
+
+// OnSetGroup is a handler for replacing the members of a group with the JSON list in the request body, answering with
+// the group's new ETag
+func (handler *Handler) OnSetGroup(context *gin.Context) {
+	name := model.NormalizeName(context.Param("group"))
+	var members []string
+	if err := context.ShouldBindJSON(&members); err != nil {
+		abortWithStatus(context, http.StatusBadRequest, err)
+		return
+	}
+
+	group, err := handler.modifyingVersionManagerOf(context).SetGroup(name, members)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	context.Header("ETag", groupETag(group))
+	log.Info().Str("group", name).Strs("members", group.Members).Msg("Set group")
+	respond(context, http.StatusOK, formatGroups(group), group)
+}
+
+// formatGroups formats groups as one "group version member..." line per group
+func formatGroups(groups ...model.Group) string {
+	var builder strings.Builder
+	for _, group := range groups {
+		fmt.Fprintf(&builder, "%s %s %s\n", group.Name, group.Version.String(), strings.Join(group.Members, " "))
+	}
+
+	return builder.String()
+}
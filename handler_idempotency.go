@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// idempotencyKeyHeader names the request header identifying retries of the same mutating request
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader marks responses replayed for a retried request
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// idempotency records the responses to mutating requests sent with an idempotency key
+type idempotency struct {
+	store    adapter.IdempotencyStore
+	window   time.Duration
+	now      func() time.Time
+	mutex    sync.Mutex
+	inFlight map[string]bool
+}
+
+// recordingWriter passes a response through while recording its body
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (writer *recordingWriter) Write(data []byte) (int, error) {
+	writer.body.Write(data)
+	return writer.ResponseWriter.Write(data)
+}
+
+func (writer *recordingWriter) WriteString(data string) (int, error) {
+	writer.body.WriteString(data)
+	return writer.ResponseWriter.WriteString(data)
+}
+
+// EnableIdempotency makes the handler replay the recorded response to retries of mutating requests with the same
+// idempotency key within the given window
+func (handler *Handler) EnableIdempotency(store adapter.IdempotencyStore, window time.Duration) {
+	handler.idempotency = &idempotency{
+		store:    store,
+		window:   window,
+		now:      time.Now,
+		inFlight: make(map[string]bool),
+	}
+}
+
+// PruneIdempotentResponses deletes the responses recorded longer than the idempotency window ago at the given interval
+// until stopped
+func (handler *Handler) PruneIdempotentResponses(stop <-chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := handler.idempotency.prune()
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to delete expired idempotent responses")
+			continue
+		}
+		log.Debug().Int("expired", deleted).Msg("Deleted expired idempotent responses")
+	}
+}
+
+// prune deletes the responses recorded longer than the window ago, returning their number
+func (idempotency *idempotency) prune() (int, error) {
+	return idempotency.store.DeleteResponsesBefore(idempotency.now().Add(-idempotency.window))
+}
+
+// IdempotencyMiddleware replays the recorded response to a mutating request whose idempotency key was already used
+// by the same request of the same tenant and records the response otherwise
+func (handler *Handler) IdempotencyMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		key := context.GetHeader(idempotencyKeyHeader)
+		method := context.Request.Method
+		if handler.idempotency == nil || key == "" || method == http.MethodGet || method == http.MethodHead {
+			context.Next()
+			return
+		}
+
+		tenant, err := handler.requestTenant(context)
+		if err != nil {
+			abortWithStatus(context, http.StatusForbidden, err)
+			return
+		}
+		handler.idempotency.handle(context, tenant+" "+key)
+	}
+}
+
+func (idempotency *idempotency) handle(context *gin.Context, key string) {
+	request, err := fingerprint(context)
+	if err != nil {
+		abortWithStatus(context, http.StatusBadRequest, err)
+		return
+	}
+
+	if !idempotency.begin(key) {
+		abortWithStatus(context, http.StatusConflict, errors.Errorf("A request with idempotency key %v is still in progress", key))
+		return
+	}
+	defer idempotency.end(key)
+
+	recorded, found, err := idempotency.store.ReadResponse(key)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+	if found && !recorded.Expired(idempotency.window, idempotency.now()) {
+		if recorded.Request != request {
+			abortWithStatus(context, http.StatusUnprocessableEntity, errors.Errorf("Idempotency key %v was used for another request", key))
+			return
+		}
+
+		log.Info().Str("idempotencyKey", key).Msg("Replayed response")
+		for name, values := range recorded.Headers {
+			for _, value := range values {
+				context.Writer.Header().Add(name, value)
+			}
+		}
+		context.Header(idempotentReplayedHeader, "true")
+		context.Data(recorded.Status, recorded.ContentType, []byte(recorded.Body))
+		context.Abort()
+		return
+	}
+
+	writer := &recordingWriter{ResponseWriter: context.Writer}
+	context.Writer = writer
+	context.Next()
+
+	// only successes are recorded, so a retry after a failure or a rejected precondition is processed again
+	if writer.Status() < http.StatusOK || writer.Status() >= http.StatusMultipleChoices {
+		return
+	}
+	err = idempotency.store.StoreResponse(key, model.IdempotentResponse{
+		Request:     request,
+		Status:      writer.Status(),
+		ContentType: writer.Header().Get("Content-Type"),
+		Headers:     replayedHeaders(writer.Header()),
+		Body:        writer.body.String(),
+		Created:     idempotency.now().UTC(),
+	})
+	if err != nil {
+		log.Error().Str("idempotencyKey", key).Err(err).Msg("Failed to record response")
+	}
+}
+
+// replayedHeaders returns the headers of a response to replay along with its body, like ETag or Location
+func replayedHeaders(header http.Header) map[string][]string {
+	headers := make(map[string][]string, len(header))
+	for name, values := range header {
+		switch name {
+		case "Content-Type", "Content-Length", "Date":
+			continue
+		}
+		headers[name] = append([]string(nil), values...)
+	}
+
+	return headers
+}
+
+func (idempotency *idempotency) begin(key string) bool {
+	idempotency.mutex.Lock()
+	defer idempotency.mutex.Unlock()
+
+	if idempotency.inFlight[key] {
+		return false
+	}
+	idempotency.inFlight[key] = true
+	return true
+}
+
+func (idempotency *idempotency) end(key string) {
+	idempotency.mutex.Lock()
+	defer idempotency.mutex.Unlock()
+
+	delete(idempotency.inFlight, key)
+}
+
+// fingerprint identifies a request by its method, URI and body, keeping the body readable for the handlers
+func fingerprint(context *gin.Context) (string, error) {
+	var body []byte
+	if context.Request.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(context.Request.Body)
+		if err != nil {
+			return "", errors.Wrap(err, "Failed to read request body")
+		}
+		context.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	sum := sha256.Sum256(body)
+	return context.Request.Method + " " + context.Request.URL.RequestURI() + " " + hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"maibornwolff/vbump/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// OnListProjects is a handler for listing all projects within a namespace
+func (handler *Handler) OnListProjects(context *gin.Context) {
+	namespace := model.NormalizeName(context.Param("namespace"))
+	storedProjects, skipped, err := handler.versionManagerOf(context).GetProjects(namespace)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+	versions, allTags := versionsAndTags(storedProjects)
+	reportSkipped(context, skipped)
+	projects := sortedProjects(versions)
+	tags := make(map[string]map[string]string, len(allTags))
+	for project, projectTags := range allTags {
+		tags[project] = tagMap(projectTags)
+	}
+
+	response := gin.H{"namespace": namespace, "projects": projects, "tags": tags}
+	if len(skipped) > 0 {
+		response["skipped"] = skipped
+	}
+
+	log.Info().Str("namespace", namespace).Int("projects", len(projects)).Msg("Listed projects")
+	respond(context, http.StatusOK, joinLines(projects), response)
+}
+
+// OnGetSettings is a handler for getting the settings of a project including those inherited from its namespaces
+func (handler *Handler) OnGetSettings(context *gin.Context) {
+	project := projectParam(context)
+	settings, err := handler.versionManagerOf(context).GetSettings(project)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("project", project).Msg("Got settings")
+	context.JSON(http.StatusOK, settings)
+}
+
+// OnSetSettings is a handler for replacing the own settings of a project with the JSON object in the request body
+func (handler *Handler) OnSetSettings(context *gin.Context) {
+	project := projectParam(context)
+	var settings map[string]string
+	if err := context.ShouldBindJSON(&settings); err != nil {
+		abortWithStatus(context, http.StatusBadRequest, err)
+		return
+	}
+
+	err := handler.modifyingVersionManagerOf(context).SetSettings(project, settings)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("project", project).Msg("Set settings")
+	context.JSON(http.StatusOK, settings)
+}
+
+// OnGetNamespaceSettings is a handler for getting the settings of a namespace including those inherited from its parents
+func (handler *Handler) OnGetNamespaceSettings(context *gin.Context) {
+	namespace := model.NormalizeName(context.Param("namespace"))
+	settings, err := handler.versionManagerOf(context).GetNamespaceSettings(namespace)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+	storedNamespace, err := handler.versionManagerOf(context).GetNamespace(namespace)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	context.Header("ETag", namespaceETag(storedNamespace))
+	log.Info().Str("namespace", namespace).Msg("Got namespace settings")
+	context.JSON(http.StatusOK, settings)
+}
+
+// OnSetNamespaceSettings is a handler for replacing the own settings of a namespace with the JSON object in the request body
+func (handler *Handler) OnSetNamespaceSettings(context *gin.Context) {
+	namespace := model.NormalizeName(context.Param("namespace"))
+	var settings map[string]string
+	if err := context.ShouldBindJSON(&settings); err != nil {
+		abortWithStatus(context, http.StatusBadRequest, err)
+		return
+	}
+
+	err := handler.modifyingVersionManagerOf(context).SetNamespaceSettings(namespace, settings)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("namespace", namespace).Msg("Set namespace settings")
+	context.JSON(http.StatusOK, settings)
+}
+
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
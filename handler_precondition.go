@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+
+	"maibornwolff/vbump/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag returns the entity tag of a project's current state
+func etag(project model.Project) string {
+	return `"` + project.Revision() + `"`
+}
+
+// namespaceETag returns the entity tag of a namespace's own settings
+func namespaceETag(namespace model.Namespace) string {
+	return `"` + namespace.Revision() + `"`
+}
+
+// groupETag returns the entity tag of a group's members and shared version
+func groupETag(group model.Group) string {
+	return `"` + group.Revision() + `"`
+}
+
+// preconditionOf returns the precondition a request states with its If-Match and If-None-Match headers. If-Match
+// compares entity tags strongly, so weak entity tags never match, while If-None-Match compares them weakly.
+func preconditionOf(context *gin.Context) model.Precondition {
+	return model.Precondition{
+		IfMatch:     parseETags(context.GetHeader("If-Match"), false),
+		IfNoneMatch: parseETags(context.GetHeader("If-None-Match"), true),
+	}
+}
+
+// parseETags returns the revisions of a comma separated list of entity tags or the wildcard *. Weak entity tags are
+// only reduced to their revision when weak comparison is allowed and kept as they are otherwise, so they never match
+// a revision.
+func parseETags(header string, weak bool) []string {
+	revisions := make([]string, 0)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag != "" && !strings.HasPrefix(tag, "W/") {
+			tag = strings.Trim(tag, `"`)
+		}
+		if tag != "" {
+			revisions = append(revisions, tag)
+		}
+	}
+
+	return revisions
+}
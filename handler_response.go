@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"maibornwolff/vbump/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// errorResponse is the JSON body of failed requests
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// versionResponse is the JSON body of requests returning a project's version
+type versionResponse struct {
+	Project string            `json:"project"`
+	Version string            `json:"version"`
+	At      string            `json:"at,omitempty"`
+	Ref     string            `json:"ref,omitempty"`
+	Branch  string            `json:"branch,omitempty"`
+	Line    string            `json:"line,omitempty"`
+	Tag     string            `json:"tag,omitempty"`
+	Tags    map[string]string `json:"tags,omitempty"`
+}
+
+// wantsJSON checks if the route only serves JSON or the client asked for JSON by ?format=json or its Accept header,
+// plain text being the default
+func wantsJSON(context *gin.Context) bool {
+	if context.GetBool(jsonOnlyKey) {
+		return true
+	}
+
+	switch strings.ToLower(context.Query("format")) {
+	case "json":
+		return true
+	case "text":
+		return false
+	}
+
+	return context.NegotiateFormat(gin.MIMEPlain, gin.MIMEJSON) == gin.MIMEJSON
+}
+
+// respond answers with the plain text or, if negotiated, the JSON representation of a result
+func respond(context *gin.Context, status int, text string, body interface{}) {
+	if wantsJSON(context) {
+		context.JSON(status, body)
+		return
+	}
+
+	context.String(status, "%s", text)
+}
+
+// abortWithError aborts the request with the status corresponding to the kind of the error
+func abortWithError(context *gin.Context, err error) {
+	abortWithStatus(context, statusOf(err), err)
+}
+
+// abortWithStatus aborts the request with the given status, answering with a structured error body if JSON was
+// negotiated and with an empty body otherwise
+func abortWithStatus(context *gin.Context, status int, err error) {
+	_ = context.Error(err)
+
+	if wantsJSON(context) {
+		context.AbortWithStatusJSON(status, errorResponse{Error: errorBody{Code: errorCode(status), Message: err.Error()}})
+		return
+	}
+
+	context.AbortWithStatus(status)
+}
+
+// statusOf maps the kinds of errors returned by the version manager to HTTP status codes
+func statusOf(err error) int {
+	switch errors.Cause(err) {
+	case service.ErrInvalidInput:
+		return http.StatusBadRequest
+	case service.ErrQuotaExceeded:
+		return http.StatusForbidden
+	case service.ErrNotFound, service.ErrUnknownTenant:
+		return http.StatusNotFound
+	case service.ErrConflict:
+		return http.StatusConflict
+	case service.ErrPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case service.ErrUnavailable:
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusInternalServerError
+}
+
+func errorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusPreconditionFailed:
+		return "precondition_failed"
+	case http.StatusUnprocessableEntity:
+		return "unprocessable"
+	case http.StatusServiceUnavailable:
+		return "unavailable"
+	}
+
+	return "internal_error"
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"maibornwolff/vbump/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// bumpOptions select the source reference a bump is made for or the branch or release line it bumps instead of the
+// project's version
+type bumpOptions struct {
+	ref    string
+	branch string
+	line   string
+}
+
+// lineResponse is the JSON body describing the latest version of a release line
+type lineResponse struct {
+	Line    string `json:"line"`
+	Version string `json:"version"`
+}
+
+func bumpOptionsOf(context *gin.Context) bumpOptions {
+	return bumpOptions{ref: context.Query("ref"), branch: context.Query("branch"), line: context.Query("line")}
+}
+
+// onStreamBump bumps the version stream of a branch or the version within a release line of a project
+func (handler *Handler) onStreamBump(context *gin.Context, part string, options bumpOptions, dryRun bool) {
+	given := 0
+	for _, option := range []string{options.ref, options.branch, options.line} {
+		if option != "" {
+			given++
+		}
+	}
+	if given > 1 {
+		abortWithStatus(context, http.StatusBadRequest, errors.New("Only one of ref, branch and line can be given"))
+		return
+	}
+
+	project := projectParam(context)
+	versionManager := handler.modifyingVersionManagerOf(context)
+	var bump model.Bump
+	var err error
+	switch {
+	case options.branch != "" && dryRun:
+		bump, err = versionManager.PreviewBranchBump(project, part, options.branch)
+	case options.branch != "":
+		bump, err = versionManager.BumpBranch(project, part, options.branch)
+	case dryRun:
+		bump, err = versionManager.PreviewLineBump(project, part, options.line)
+	default:
+		bump, err = versionManager.BumpLine(project, part, options.line)
+	}
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("version", bump.Version.String()).Str("project", project).Str("branch", options.branch).Str("line", options.line).
+		Bool("dryRun", dryRun).Msgf("Bumped %s version of stream", part)
+	if !dryRun {
+		countBump(context, bump)
+	}
+	respond(context, http.StatusOK, bump.Version.String(), bump)
+}
+
+func (handler *Handler) onGetBranchVersion(context *gin.Context, project string, branch string) {
+	version, err := handler.versionManagerOf(context).GetBranchVersion(project, branch)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("project", project).Str("branch", branch).Msg("Got version of branch")
+	respond(context, http.StatusOK, version.String(), versionResponse{Project: project, Version: version.String(), Branch: branch})
+}
+
+// OnDeleteBranch is a handler for removing the version stream of the branch given as query parameter, answering with
+// its latest version
+func (handler *Handler) OnDeleteBranch(context *gin.Context) {
+	project := projectParam(context)
+	branch := context.Query("branch")
+	if branch == "" {
+		abortWithStatus(context, http.StatusBadRequest, errors.New("The branch must be given"))
+		return
+	}
+
+	version, err := handler.modifyingVersionManagerOf(context).DeleteBranch(project, branch)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("project", project).Str("branch", branch).Msg("Deleted branch")
+	respond(context, http.StatusOK, version.String(), versionResponse{Project: project, Version: version.String(), Branch: branch})
+}
+
+func (handler *Handler) onGetLineVersion(context *gin.Context, project string, line string) {
+	version, err := handler.versionManagerOf(context).GetLineVersion(project, line)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("project", project).Str("line", line).Msg("Got version of release line")
+	respond(context, http.StatusOK, version.String(), versionResponse{Project: project, Version: version.String(), Line: line})
+}
+
+// OnGetLines is a handler for getting the latest version of every release line of a project
+func (handler *Handler) OnGetLines(context *gin.Context) {
+	project := projectParam(context)
+	lines, err := handler.versionManagerOf(context).GetLines(project)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	names := make([]string, 0, len(lines))
+	for line := range lines {
+		names = append(names, line)
+	}
+	sort.Strings(names)
+	list := make([]lineResponse, 0, len(lines))
+	var text strings.Builder
+	for _, line := range names {
+		list = append(list, lineResponse{Line: line, Version: lines[line].String()})
+		fmt.Fprintf(&text, "%s %s\n", line, lines[line].String())
+	}
+
+	log.Info().Str("project", project).Int("lines", len(lines)).Msg("Got release lines")
+	respond(context, http.StatusOK, text.String(), list)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"maibornwolff/vbump/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// OnGetTags is a handler for getting the distribution tags of a project
+func (handler *Handler) OnGetTags(context *gin.Context) {
+	project := projectParam(context)
+	tags, err := handler.versionManagerOf(context).GetTags(project)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("project", project).Int("tags", len(tags)).Msg("Got tags")
+	respond(context, http.StatusOK, formatTags(tags), tagMap(tags))
+}
+
+// OnGetTag is a handler for getting the version a distribution tag of a project points to
+func (handler *Handler) OnGetTag(context *gin.Context) {
+	handler.onGetTag(context, projectParam(context), context.Param("tag"))
+}
+
+func (handler *Handler) onGetTag(context *gin.Context, project string, tag string) {
+	version, err := handler.versionManagerOf(context).GetTag(project, tag)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("project", project).Str("tag", tag).Msg("Got version of tag")
+	respond(context, http.StatusOK, version.String(), versionResponse{Project: project, Version: version.String(), Tag: tag})
+}
+
+// OnSetTag is a handler for pointing a distribution tag to the version in the last segment of the path
+func (handler *Handler) OnSetTag(context *gin.Context) {
+	project, version := splitProjectVersion(context.Param("projectVersion"))
+	handler.onSetTag(context, project, context.Param("tag"), version)
+}
+
+// OnAPISetTag is a handler for pointing a distribution tag to the version given in the JSON request body
+func (handler *Handler) OnAPISetTag(context *gin.Context) {
+	var request setVersionRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		abortWithStatus(context, http.StatusBadRequest, err)
+		return
+	}
+
+	handler.onSetTag(context, projectParam(context), context.Param("tag"), request.Version)
+}
+
+func (handler *Handler) onSetTag(context *gin.Context, project string, tag string, versionString string) {
+	version, err := handler.modifyingVersionManagerOf(context).SetTag(project, tag, versionString)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("project", project).Str("tag", tag).Str("version", version.String()).Msg("Set tag")
+	respond(context, http.StatusOK, version.String(), versionResponse{Project: project, Version: version.String(), Tag: tag})
+}
+
+// OnDeleteTag is a handler for removing a distribution tag of a project, answering with the remaining tags
+func (handler *Handler) OnDeleteTag(context *gin.Context) {
+	project := projectParam(context)
+	tag := context.Param("tag")
+	versionManager := handler.modifyingVersionManagerOf(context)
+	if err := versionManager.DeleteTag(project, tag); err != nil {
+		abortWithError(context, err)
+		return
+	}
+	tags, err := versionManager.GetTags(project)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("project", project).Str("tag", tag).Msg("Deleted tag")
+	respond(context, http.StatusOK, formatTags(tags), tagMap(tags))
+}
+
+// formatTags formats tags as one "tag version" line per tag
+func formatTags(tags map[string]model.Version) string {
+	names := make([]string, 0, len(tags))
+	for tag := range tags {
+		names = append(names, tag)
+	}
+	sort.Strings(names)
+
+	var builder strings.Builder
+	for _, tag := range names {
+		fmt.Fprintf(&builder, "%s %s\n", tag, tags[tag].String())
+	}
+
+	return builder.String()
+}
+
+// tagMap converts tags to their JSON form
+func tagMap(tags map[string]model.Version) map[string]string {
+	converted := make(map[string]string, len(tags))
+	for tag, version := range tags {
+		converted[tag] = version.String()
+	}
+
+	return converted
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"maibornwolff/vbump/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// tenantHeader names the request header binding a request to a tenant, e.g. set by an authenticating proxy
+const tenantHeader = "X-Vbump-Tenant"
+
+// TenantFromHeader binds requests to the tenant named in the tenant header
+func TenantFromHeader(context *gin.Context) string {
+	return context.GetHeader(tenantHeader)
+}
+
+// TenantFromHost binds requests to the tenant named by the first label of the requested host,
+// e.g. team-a for team-a.vbump.example.com. Requests for IP addresses, localhost or other single label hosts are
+// bound to the default tenant.
+func TenantFromHost(context *gin.Context) string {
+	host := context.Request.Host
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	host = strings.Trim(host, "[]")
+
+	labels := strings.SplitN(host, ".", 2)
+	if net.ParseIP(host) != nil || len(labels) < 2 || labels[1] == "" {
+		return ""
+	}
+
+	return labels[0]
+}
+
+// ParseTrustedProxies parses the addresses or CIDR networks of trusted proxies
+func ParseTrustedProxies(specs []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(specs))
+	for _, spec := range specs {
+		if !strings.Contains(spec, "/") {
+			ip := net.ParseIP(spec)
+			if ip == nil {
+				return nil, errors.Errorf("%v is neither an IP address nor a CIDR network", spec)
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(8*len(ip), 8*len(ip))})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(spec)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%v is neither an IP address nor a CIDR network", spec)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+// requestTenant returns the tenant a request is bound to. Handlers serving several tenants only trust the binding of
+// requests passing a trusted proxy.
+func (handler *Handler) requestTenant(context *gin.Context) (string, error) {
+	if handler.trustedProxies != nil && !handler.isTrustedProxy(context.Request.RemoteAddr) {
+		return "", errors.Errorf("Requests from %v are not passing a trusted proxy", context.Request.RemoteAddr)
+	}
+
+	tenant := handler.resolveTenant(context)
+	if tenant == "" {
+		tenant = model.DefaultTenant
+	}
+
+	return tenant, nil
+}
+
+// isTrustedProxy reports if the given remote address is one of the trusted proxies
+func (handler *Handler) isTrustedProxy(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range handler.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OnListTenants is a handler for listing all tenants with their quotas
+func (handler *Handler) OnListTenants(context *gin.Context) {
+	tenants, err := handler.tenants.List()
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Int("tenants", len(tenants)).Msg("Listed tenants")
+	context.JSON(http.StatusOK, tenants)
+}
+
+// OnPutTenant is a handler for registering a tenant or updating its quota given as JSON in the request body
+func (handler *Handler) OnPutTenant(context *gin.Context) {
+	var tenant model.Tenant
+	if err := context.ShouldBindJSON(&tenant); err != nil {
+		abortWithStatus(context, http.StatusBadRequest, err)
+		return
+	}
+	tenant.Name = context.Param("tenant")
+
+	err := handler.tenants.Put(tenant)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("tenant", tenant.Name).Int("maxProjects", tenant.MaxProjects).Msg("Put tenant")
+	context.JSON(http.StatusOK, tenant)
+}
+
+// OnDeleteTenant is a handler for unregistering a tenant, keeping its projects in storage
+func (handler *Handler) OnDeleteTenant(context *gin.Context) {
+	tenant := context.Param("tenant")
+	err := handler.tenants.Delete(tenant)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("tenant", tenant).Msg("Deleted tenant")
+	context.Status(http.StatusNoContent)
+}
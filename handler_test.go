@@ -1,16 +1,44 @@
 package main
 
 import (
+	"bufio"
+	gocontext "context"
+	"encoding/json"
+	"io"
 	"maibornwolff/vbump/service"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"maibornwolff/vbump/adapter"
 	"maibornwolff/vbump/model"
 )
 
+// serveRequest sends a request with the given body and headers, given as pairs of name and value, to the handler; headers
+// without value are left out
+func serveRequest(handler http.Handler, method string, path string, body string, headers ...string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		if headers[i+1] != "" {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+	}
+	handler.ServeHTTP(res, req)
+
+	return res
+}
+
 func TestBumbMajor(t *testing.T) {
 	Ω := NewGomegaWithT(t)
 
@@ -76,9 +104,9 @@ func TestNumberOfVbumpMetric(t *testing.T) {
 	router.ServeHTTP(res, majorp1)
 	router.ServeHTTP(res, metrics)
 
-	Ω.Expect(res.Body.String()).To(ContainSubstring("vbump_bumps_total{element=\"patch\",project=\"prom1\"} 1"))
-	Ω.Expect(res.Body.String()).To(ContainSubstring("vbump_bumps_total{element=\"minor\",project=\"prom1\"} 1"))
-	Ω.Expect(res.Body.String()).To(ContainSubstring("vbump_bumps_total{element=\"major\",project=\"prom1\"} 1"))
+	Ω.Expect(res.Body.String()).To(ContainSubstring("vbump_bumps_total{element=\"patch\",project=\"prom1\",tenant=\"default\"} 1"))
+	Ω.Expect(res.Body.String()).To(ContainSubstring("vbump_bumps_total{element=\"minor\",project=\"prom1\",tenant=\"default\"} 1"))
+	Ω.Expect(res.Body.String()).To(ContainSubstring("vbump_bumps_total{element=\"major\",project=\"prom1\",tenant=\"default\"} 1"))
 
 	// Test for prom2
 	patchp2, _ := http.NewRequest("POST", "/patch/prom2", nil)
@@ -90,9 +118,9 @@ func TestNumberOfVbumpMetric(t *testing.T) {
 	router.ServeHTTP(res, majorp2)
 	router.ServeHTTP(res, metrics)
 
-	Ω.Expect(res.Body.String()).To(ContainSubstring("vbump_bumps_total{element=\"patch\",project=\"prom2\"} 1"))
-	Ω.Expect(res.Body.String()).To(ContainSubstring("vbump_bumps_total{element=\"minor\",project=\"prom2\"} 1"))
-	Ω.Expect(res.Body.String()).To(ContainSubstring("vbump_bumps_total{element=\"major\",project=\"prom2\"} 1"))
+	Ω.Expect(res.Body.String()).To(ContainSubstring("vbump_bumps_total{element=\"patch\",project=\"prom2\",tenant=\"default\"} 1"))
+	Ω.Expect(res.Body.String()).To(ContainSubstring("vbump_bumps_total{element=\"minor\",project=\"prom2\",tenant=\"default\"} 1"))
+	Ω.Expect(res.Body.String()).To(ContainSubstring("vbump_bumps_total{element=\"major\",project=\"prom2\",tenant=\"default\"} 1"))
 }
 
 func TestBumbTransientPatch(t *testing.T) {
@@ -182,7 +210,7 @@ func TestBumpMajorWithInvalidPath(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/major/p1", nil)
 	router.ServeHTTP(res, req)
 
-	Ω.Expect(res.Code).To(Equal(500))
+	Ω.Expect(res.Code).To(Equal(503))
 	Ω.Expect(res.Body.String()).To(Equal(""))
 }
 
@@ -198,7 +226,7 @@ func TestBumpMinorWithInvalidPath(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/minor/p1", nil)
 	router.ServeHTTP(res, req)
 
-	Ω.Expect(res.Code).To(Equal(500))
+	Ω.Expect(res.Code).To(Equal(503))
 	Ω.Expect(res.Body.String()).To(Equal(""))
 }
 
@@ -214,7 +242,7 @@ func TestBumpPatchWithInvalidPath(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/patch/p1", nil)
 	router.ServeHTTP(res, req)
 
-	Ω.Expect(res.Code).To(Equal(500))
+	Ω.Expect(res.Code).To(Equal(503))
 	Ω.Expect(res.Body.String()).To(Equal(""))
 }
 
@@ -230,3 +258,762 @@ func TestGetHealth(t *testing.T) {
 
 	Ω.Expect(res.Body.String()).To(Equal("hello from vbump!"))
 }
+
+func TestGetVersionAtWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	fileProvider := adapter.NewMock(model.NewVersion(1, 0, 0), "p1")
+	versionManager := service.NewVersionManager(fileProvider)
+	handler := NewHandler(versionManager)
+	router := handler.GetRouter()
+
+	bump, _ := http.NewRequest("POST", "/patch/p1", nil)
+	router.ServeHTTP(httptest.NewRecorder(), bump)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/version/p1?at=2000-01-01T00:00:00Z", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(404))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/version/p1?at="+time.Now().Add(time.Hour).UTC().Format(time.RFC3339), nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("1.0.1"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/version/p1?at=yesterday", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(400))
+}
+
+func TestGetVersionsWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	fileProvider := adapter.NewMock(model.NewVersion(1, 0, 0), "p1")
+	versionManager := service.NewVersionManager(fileProvider)
+	handler := NewHandler(versionManager)
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/versions", nil)
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Body.String()).To(Equal("p1 1.0.0\n"))
+}
+
+func TestGetVersionsSkipsBrokenProjectsWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	versionManager := service.NewVersionManager(adapter.NewFileProvider(basePath))
+	_, _ = versionManager.SetVersion("team/p1", "1.0.0")
+	_ = os.WriteFile(path.Join(basePath, "team", "broken"), []byte("not a version"), 0644)
+	router := NewHandler(versionManager).GetRouter()
+
+	for _, url := range []string{"/versions", "/projects/team", "/api/v1/projects"} {
+		res := serveRequest(router, "GET", url, "")
+
+		Ω.Expect(res.Code).To(Equal(200), url)
+		Ω.Expect(res.Body.String()).To(ContainSubstring("team/p1"), url)
+		Ω.Expect(res.Header().Get(skippedProjectsHeader)).To(Equal("team/broken"), url)
+	}
+}
+
+func TestExportAndImportWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	sourceHandler := NewHandler(service.NewVersionManager(adapter.NewMock(model.NewVersion(1, 0, 0), "p1")))
+	sourceHandler.EnableAdmin("s3cret")
+	source := sourceHandler.GetRouter()
+	targetHandler := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir())))
+	targetHandler.EnableAdmin("s3cret")
+	target := targetHandler.GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/export", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	source.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+
+	res2 := httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/admin/import?strategy=overwrite", res.Body)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	target.ServeHTTP(res2, req)
+	Ω.Expect(res2.Body.String()).To(Equal("p1 created\n"))
+
+	res3 := httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/version/p1", nil)
+	target.ServeHTTP(res3, req)
+	Ω.Expect(res3.Body.String()).To(Equal("1.0.0"))
+}
+
+func TestImportWithInvalidStrategy(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	handler := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir())))
+	handler.EnableAdmin("s3cret")
+	router := handler.GetRouter()
+	res := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/admin/import?strategy=replace", strings.NewReader("{}"))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	router.ServeHTTP(res, req)
+
+	Ω.Expect(res.Code).To(Equal(400))
+}
+
+func TestAdminRoutesRequireToken(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	handler := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir())))
+	router := handler.GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/export", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(403))
+
+	handler.EnableAdmin("s3cret")
+	for _, authorization := range []string{"", "s3cret", "Bearer wrong"} {
+		res = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/admin/export", nil)
+		req.Header.Set("Authorization", authorization)
+		router.ServeHTTP(res, req)
+		Ω.Expect(res.Code).To(Equal(401))
+	}
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/admin/export", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+}
+
+func TestErrorPathsWithInjectedFaults(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	fileProvider := adapter.NewFileProvider(t.TempDir())
+	_ = fileProvider.StoreVersion("p1", model.NewVersion(1, 0, 0))
+	faultProvider := adapter.NewFaultProvider(fileProvider, map[string]adapter.Fault{adapter.AllOperations: {ErrorRate: 1}})
+	router := NewHandler(service.NewVersionManager(faultProvider)).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patch/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(503))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/version/p1", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(503))
+}
+
+func TestNamespacedProjectsWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	router := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir()))).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/version/team/service/1.0.0", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("1.0.0"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/minor/team%2Fservice", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("1.1.0"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/version/team/service", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("1.1.0"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/namespace-settings/team", strings.NewReader(`{"owner": "team-a"}`))
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(200))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/project-settings/team/service", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"owner": "team-a"}`))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/projects/team", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("team/service\n"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/projects", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("team/service\n"))
+}
+
+func TestTenantsWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	tenants := service.NewTenants(service.NewVersionManager(adapter.NewFileProvider(basePath)), adapter.NewFileTenantStore(basePath), func(tenant string) (*service.VersionManager, error) {
+		return service.NewVersionManager(adapter.NewFileProvider(t.TempDir())), nil
+	})
+	proxies, _ := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	handler := NewTenantHandler(tenants, TenantFromHeader, proxies)
+	handler.EnableAdmin("s3cret")
+	router := handler.GetRouter()
+	fromProxy := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		req.RemoteAddr = "10.0.0.1:41234"
+		router.ServeHTTP(res, req)
+	})
+
+	Ω.Expect(serveRequest(fromProxy, "POST", "/version/p1/1.0", "", "X-Vbump-Tenant", "team-a").Code).To(Equal(404))
+	Ω.Expect(serveRequest(fromProxy, "PUT", "/admin/tenants/team-a", `{"maxProjects": 1}`, "Authorization", "Bearer s3cret").Code).To(Equal(200))
+	Ω.Expect(serveRequest(fromProxy, "POST", "/version/p1/1.0", "", "X-Vbump-Tenant", "team-a").Body.String()).To(Equal("1.0"))
+	Ω.Expect(serveRequest(fromProxy, "POST", "/version/p2/1.0", "", "X-Vbump-Tenant", "team-a").Code).To(Equal(403))
+	Ω.Expect(serveRequest(fromProxy, "GET", "/version/p1", "").Code).To(Equal(404))
+	Ω.Expect(serveRequest(fromProxy, "GET", "/admin/tenants", "", "Authorization", "Bearer s3cret").Body.String()).To(MatchJSON(`[{"name": "default", "maxProjects": 0}, {"name": "team-a", "maxProjects": 1}]`))
+
+	// requests bypassing the trusted proxy cannot choose their tenant
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/version/p1", nil)
+	req.RemoteAddr = "192.0.2.1:41234"
+	req.Header.Set("X-Vbump-Tenant", "team-a")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(403))
+}
+
+func TestTenantFromHost(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	hosts := map[string]string{
+		"team-a.vbump.example.com:8080": "team-a",
+		"team-a.localhost":              "team-a",
+		"localhost:8080":                "",
+		"vbump":                         "",
+		"127.0.0.1:8080":                "",
+		"[::1]:8080":                    "",
+		"10.0.0.1":                      "",
+	}
+	for host, tenant := range hosts {
+		context, _ := gin.CreateTestContext(httptest.NewRecorder())
+		context.Request, _ = http.NewRequest("GET", "/version/p1", nil)
+		context.Request.Host = host
+
+		Ω.Expect(TenantFromHost(context)).To(Equal(tenant), host)
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	networks, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.7", "::1"})
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(networks).To(HaveLen(3))
+	Ω.Expect(networks[1].Contains(net.ParseIP("192.0.2.7"))).To(BeTrue())
+	Ω.Expect(networks[1].Contains(net.ParseIP("192.0.2.8"))).To(BeFalse())
+
+	_, err = ParseTrustedProxies([]string{"proxy.example.com"})
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func TestContentNegotiationWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	router := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir()))).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/version/team/p1/1.0.0", nil)
+	req.Header.Set("Accept", "application/json")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Header().Get("Content-Type")).To(HavePrefix("application/json"))
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"project": "team/p1", "version": "1.0.0"}`))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/minor/team/p1?format=json", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"project": "team/p1", "part": "minor", "previousVersion": "1.0.0", "version": "1.1.0"}`))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/versions", nil)
+	req.Header.Set("Accept", "application/json")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(MatchJSON(`[{"project": "team/p1", "version": "1.1.0"}]`))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/version/team/p1?format=text", nil)
+	req.Header.Set("Accept", "application/json")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("1.1.0"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/transient/patch/1.2", nil)
+	req.Header.Set("Accept", "text/plain, application/json;q=0.5")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(Equal("1.2.1"))
+}
+
+func TestJSONErrorWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	router := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir()))).GetRouter()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/version/unknown", nil)
+	req.Header.Set("Accept", "application/json")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(404))
+	Ω.Expect(res.Body.String()).To(ContainSubstring(`"code":"not_found"`))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/version/unknown", nil)
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(404))
+	Ω.Expect(res.Body.String()).To(BeEmpty())
+}
+
+func TestErrorStatusCodesWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	router := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir()))).GetRouter()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/version/team/p1/1.0.0", nil))
+
+	for _, request := range []struct {
+		method string
+		path   string
+		status int
+	}{
+		{"POST", "/patch/unknown", 404},
+		{"GET", "/version/unknown", 404},
+		{"GET", "/version/team", 404},
+		{"GET", "/version/team/p1?at=2000-01-01T00:00:00Z", 404},
+		{"POST", "/transient/patch/1.x", 400},
+		{"POST", "/version/p2/1.x", 400},
+		{"POST", "/version/team/1.0", 409},
+		{"GET", "/version/team/p1?at=yesterday", 400},
+	} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest(request.method, request.path, nil)
+		router.ServeHTTP(res, req)
+		Ω.Expect(res.Code).To(Equal(request.status), request.method+" "+request.path)
+	}
+}
+
+func TestIdempotencyKeyWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	newRouter := func() *gin.Engine {
+		handler := NewHandler(service.NewVersionManager(adapter.NewFileProvider(basePath)))
+		handler.EnableIdempotency(adapter.NewFileIdempotencyStore(basePath), time.Hour)
+		return handler.GetRouter()
+	}
+	router := newRouter()
+	serveRequest(router, "POST", "/version/p1/1.0.0", "")
+
+	res := serveRequest(router, "POST", "/patch/p1", "", idempotencyKeyHeader, "build-1")
+	Ω.Expect(res.Body.String()).To(Equal("1.0.1"))
+	Ω.Expect(res.Header().Get(idempotentReplayedHeader)).To(BeEmpty())
+
+	res = serveRequest(newRouter(), "POST", "/patch/p1", "", idempotencyKeyHeader, "build-1")
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Body.String()).To(Equal("1.0.1"))
+	Ω.Expect(res.Header().Get(idempotentReplayedHeader)).To(Equal("true"))
+
+	res = serveRequest(router, "POST", "/minor/p1", "", idempotencyKeyHeader, "build-1")
+	Ω.Expect(res.Code).To(Equal(422))
+
+	res = serveRequest(router, "POST", "/patch/p1", "", idempotencyKeyHeader, "build-2")
+	Ω.Expect(res.Body.String()).To(Equal("1.0.2"))
+
+	res = serveRequest(router, "POST", "/patch/p1", "")
+	Ω.Expect(res.Body.String()).To(Equal("1.0.3"))
+}
+
+func TestIdempotencyKeyExpires(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	handler := NewHandler(service.NewVersionManager(adapter.NewFileProvider(basePath)))
+	handler.EnableIdempotency(adapter.NewFileIdempotencyStore(basePath), time.Hour)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	handler.idempotency.now = func() time.Time { return now }
+	router := handler.GetRouter()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/version/p1/1.0.0", nil))
+
+	for _, expected := range []string{"1.0.1", "1.0.1", "1.0.2"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/patch/p1", nil)
+		req.Header.Set(idempotencyKeyHeader, "build-1")
+		router.ServeHTTP(res, req)
+		Ω.Expect(res.Body.String()).To(Equal(expected))
+		now = now.Add(40 * time.Minute)
+	}
+}
+
+func TestIdempotencyKeyRecordsSuccessesOnly(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	handler := NewHandler(service.NewVersionManager(adapter.NewFileProvider(basePath)))
+	handler.EnableIdempotency(adapter.NewFileIdempotencyStore(basePath), time.Hour)
+	router := handler.GetRouter()
+
+	Ω.Expect(serveRequest(router, "POST", "/patch/p1", "", idempotencyKeyHeader, "build-1").Code).To(Equal(404))
+	serveRequest(router, "POST", "/version/p1/1.0.0", "")
+	Ω.Expect(serveRequest(router, "POST", "/patch/p1", "", idempotencyKeyHeader, "build-1").Body.String()).To(Equal("1.0.1"))
+
+	first := serveRequest(router, "PUT", "/groups/g", `["p1"]`, idempotencyKeyHeader, "group-1")
+	Ω.Expect(first.Header().Get("ETag")).NotTo(BeEmpty())
+	replayed := serveRequest(router, "PUT", "/groups/g", `["p1"]`, idempotencyKeyHeader, "group-1")
+	Ω.Expect(replayed.Header().Get(idempotentReplayedHeader)).To(Equal("true"))
+	Ω.Expect(replayed.Header().Get("ETag")).To(Equal(first.Header().Get("ETag")))
+	Ω.Expect(replayed.Header().Get("Content-Type")).To(Equal(first.Header().Get("Content-Type")))
+
+	handler.idempotency.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	deleted, err := handler.idempotency.prune()
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(deleted).To(Equal(2))
+}
+
+func TestBumpWithRefWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	router := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir()))).GetRouter()
+	serveRequest(router, "POST", "/version/team/p1/1.0.0", "")
+
+	Ω.Expect(serveRequest(router, "POST", "/patch/team/p1?ref=abc", "").Body.String()).To(Equal("1.0.1"))
+	Ω.Expect(serveRequest(router, "POST", "/patch/team/p1?ref=abc", "").Body.String()).To(Equal("1.0.1"))
+	Ω.Expect(serveRequest(router, "POST", "/api/v1/projects/team%2Fp1/bumps", `{"part": "minor", "ref": "def"}`).Body.String()).To(ContainSubstring(`"version":"1.1.0"`))
+	Ω.Expect(serveRequest(router, "POST", "/api/v1/projects/team%2Fp1/bumps", `{"part": "minor", "ref": "abc"}`).Body.String()).To(ContainSubstring(`"reused":true`))
+
+	Ω.Expect(serveRequest(router, "GET", "/version/team/p1", "").Body.String()).To(Equal("1.1.0"))
+	Ω.Expect(serveRequest(router, "GET", "/version/team/p1?ref=abc", "").Body.String()).To(Equal("1.0.1"))
+	Ω.Expect(serveRequest(router, "GET", "/version/team/p1/by-ref/abc", "").Body.String()).To(Equal("1.0.1"))
+	Ω.Expect(serveRequest(router, "GET", "/version/team/p1/by-ref/xyz", "").Code).To(Equal(404))
+	Ω.Expect(serveRequest(router, "GET", "/api/v1/projects/team%2Fp1/refs/def", "").Body.String()).To(MatchJSON(`{"project": "team/p1", "version": "1.1.0", "ref": "def"}`))
+	Ω.Expect(serveRequest(router, "GET", "/version/team/p1?ref=xyz", "").Code).To(Equal(404))
+
+	// only a by-ref segment before the last one looks up a reference
+	serveRequest(router, "POST", "/version/team/by-ref/p2/app/2.0.0", "")
+	Ω.Expect(serveRequest(router, "GET", "/version/team/by-ref/p2/app", "").Body.String()).To(Equal("2.0.0"))
+}
+
+func TestConditionalRequestsWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	router := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir()))).GetRouter()
+
+	Ω.Expect(serveRequest(router, "POST", "/version/p1/1.0.0", "", "If-None-Match", "*").Code).To(Equal(200))
+	Ω.Expect(serveRequest(router, "POST", "/version/p1/1.0.0", "", "If-None-Match", "*").Code).To(Equal(412))
+
+	res := serveRequest(router, "GET", "/version/p1", "")
+	etag := res.Header().Get("ETag")
+	Ω.Expect(etag).To(MatchRegexp(`^"[0-9a-f]+"$`))
+	Ω.Expect(serveRequest(router, "GET", "/version/p1", "", "If-None-Match", etag).Code).To(Equal(304))
+
+	Ω.Expect(serveRequest(router, "POST", "/patch/p1", "", "If-Match", etag).Body.String()).To(Equal("1.0.1"))
+	Ω.Expect(serveRequest(router, "POST", "/version/p1/2.0.0", "", "If-Match", etag).Code).To(Equal(412))
+
+	res = serveRequest(router, "GET", "/version/p1", "", "If-None-Match", etag)
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Body.String()).To(Equal("1.0.1"))
+	Ω.Expect(serveRequest(router, "POST", "/minor/p1", "", "If-Match", "W/"+res.Header().Get("ETag")).Code).To(Equal(412))
+	Ω.Expect(serveRequest(router, "POST", "/minor/p1", "", "If-Match", `W/"other", `+res.Header().Get("ETag")).Body.String()).To(Equal("1.1.0"))
+	Ω.Expect(serveRequest(router, "POST", "/minor/p1", "", "If-Match", "*").Body.String()).To(Equal("1.2.0"))
+	Ω.Expect(serveRequest(router, "POST", "/version/p2/1.0.0", "", "If-Match", "*").Code).To(Equal(412))
+}
+
+func TestNamespaceSettingsWithPreconditionWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	router := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir()))).GetRouter()
+
+	etag := serveRequest(router, "GET", "/namespace-settings/team", "").Header().Get("ETag")
+	Ω.Expect(etag).To(MatchRegexp(`^"[0-9a-f]+"$`))
+	Ω.Expect(serveRequest(router, "PUT", "/namespace-settings/team", `{"owner": "team-a"}`, "If-Match", etag).Code).To(Equal(200))
+	Ω.Expect(serveRequest(router, "PUT", "/namespace-settings/team", `{"owner": "team-b"}`, "If-Match", etag).Code).To(Equal(412))
+	Ω.Expect(serveRequest(router, "GET", "/namespace-settings/team", "").Header().Get("ETag")).NotTo(Equal(etag))
+}
+
+func TestPreviewWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	router := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir()))).GetRouter()
+	serveRequest(router, "POST", "/version/team/p1/1.2.3", "")
+
+	Ω.Expect(serveRequest(router, "POST", "/major/team/p1?dryRun=true", "").Body.String()).To(Equal("2.0.0"))
+	Ω.Expect(serveRequest(router, "GET", "/preview/minor/team/p1", "").Body.String()).To(Equal("1.3.0"))
+	Ω.Expect(serveRequest(router, "GET", "/preview/prerelease/team/p1", "").Body.String()).To(Equal("1.2.4-rc.0"))
+	Ω.Expect(serveRequest(router, "GET", "/preview/build/team/p1", "").Code).To(Equal(400))
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/projects/team%2Fp1/bumps?dryRun=true", strings.NewReader(`{"part": "patch"}`))
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"project": "team/p1", "part": "patch", "previousVersion": "1.2.3", "version": "1.2.4", "dryRun": true}`))
+	Ω.Expect(serveRequest(router, "GET", "/version/team/p1", "").Body.String()).To(Equal("1.2.3"))
+
+	Ω.Expect(serveRequest(router, "POST", "/transient/major/1.2", "").Body.String()).To(Equal("2.0"))
+}
+
+func TestBatchWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	router := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir()))).GetRouter()
+	serveRequest(router, "POST", "/version/svc-a/1.0.0", "")
+	serveRequest(router, "POST", "/version/svc-b/2.0.0", "")
+
+	res := serveRequest(router, "POST", "/batch", `{"items": [{"project": "svc-a", "operation": "minor"}, {"project": "svc-b", "operation": "patch"}]}`)
+	Ω.Expect(res.Body.String()).To(Equal("svc-a 1.1.0\nsvc-b 2.0.1\n"))
+
+	res = serveRequest(router, "POST", "/api/v1/batches", `{"items": [{"project": "svc-a", "operation": "patch"}, {"project": "unknown", "operation": "patch"}]}`)
+	Ω.Expect(res.Code).To(Equal(404))
+	Ω.Expect(serveRequest(router, "POST", "/api/v1/batches", `{"items": [{"project": "svc-a", "operation": "set", "version": "3.0.0"}]}`).Body.String()).
+		To(MatchJSON(`{"bumps": [{"project": "svc-a", "part": "set", "previousVersion": "1.1.0", "version": "3.0.0"}]}`))
+
+	// preconditions are stated per item
+	project := httptest.NewRecorder()
+	getReq, _ := http.NewRequest("GET", "/api/v1/projects/svc-b", nil)
+	router.ServeHTTP(project, getReq)
+	Ω.Expect(serveRequest(router, "POST", "/batch", `{"items": [{"project": "svc-a", "operation": "patch"}, {"project": "svc-b", "operation": "patch", "ifMatch": "\"stale\""}]}`).Code).To(Equal(412))
+	Ω.Expect(serveRequest(router, "POST", "/batch", `{"items": [{"project": "svc-b", "operation": "patch", "ifMatch": `+strconv.Quote(project.Header().Get("ETag"))+`}]}`).Body.String()).To(Equal("svc-b 2.0.2\n"))
+
+	res = httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/batch", strings.NewReader(`{"items": [{"project": "svc-a", "operation": "patch"}]}`))
+	req.Header.Set("If-Match", "*")
+	router.ServeHTTP(res, req)
+	Ω.Expect(res.Code).To(Equal(400))
+
+	// bumps count the members of groups like single bumps do
+	serveRequest(router, "POST", "/version/svc-c/3.0.0", "")
+	groupReq, _ := http.NewRequest("PUT", "/groups/bc", strings.NewReader(`["svc-b", "svc-c"]`))
+	router.ServeHTTP(httptest.NewRecorder(), groupReq)
+	before := testutil.ToFloat64(numberOfBumps.With(prometheus.Labels{"tenant": model.DefaultTenant, "project": "svc-c", "element": "minor"}))
+	serveRequest(router, "POST", "/batch", `{"items": [{"project": "svc-b", "operation": "minor"}]}`)
+	Ω.Expect(testutil.ToFloat64(numberOfBumps.With(prometheus.Labels{"tenant": model.DefaultTenant, "project": "svc-c", "element": "minor"}))).To(Equal(before + 1))
+}
+
+func TestDependenciesWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	router := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir()))).GetRouter()
+	serveRequest(router, "POST", "/version/team/lib/1.0.0", "")
+	serveRequest(router, "POST", "/version/app/2.0.0", "")
+
+	Ω.Expect(serveRequest(router, "PUT", "/dependencies/app", `["team/lib"]`).Body.String()).To(Equal("team/lib\n"))
+	Ω.Expect(serveRequest(router, "PUT", "/api/v1/projects/team%2Flib/dependencies", `["app"]`).Code).To(Equal(409))
+	Ω.Expect(serveRequest(router, "GET", "/api/v1/projects/app/dependencies", "").Body.String()).To(MatchJSON(`["team/lib"]`))
+
+	res := serveRequest(router, "POST", "/api/v1/projects/team%2Flib/bumps", `{"part": "minor"}`)
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"project": "team/lib", "part": "minor", "previousVersion": "1.0.0", "version": "1.1.0",
+		"cascaded": [{"project": "app", "part": "patch", "previousVersion": "2.0.0", "version": "2.0.1", "cascadedFrom": ["team/lib"]}]}`))
+	Ω.Expect(serveRequest(router, "GET", "/version/app", "").Body.String()).To(Equal("2.0.1"))
+}
+
+func TestGroupsWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	router := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir()))).GetRouter()
+	serveRequest(router, "POST", "/version/sdk-java/1.0.0", "")
+	serveRequest(router, "POST", "/version/sdk-go/1.1.0", "")
+
+	Ω.Expect(serveRequest(router, "PUT", "/groups/sdk", `["sdk-java", "sdk-go"]`).Body.String()).To(Equal("sdk 1.1.0 sdk-go sdk-java\n"))
+	Ω.Expect(serveRequest(router, "POST", "/patch/sdk-java", "").Body.String()).To(Equal("1.1.1"))
+	Ω.Expect(serveRequest(router, "GET", "/version/sdk-go", "").Body.String()).To(Equal("1.1.1"))
+	Ω.Expect(serveRequest(router, "GET", "/api/v1/groups/sdk", "").Body.String()).
+		To(MatchJSON(`{"name": "sdk", "members": ["sdk-go", "sdk-java"], "version": "1.1.1"}`))
+	Ω.Expect(serveRequest(router, "GET", "/api/v1/groups", "").Body.String()).
+		To(MatchJSON(`[{"name": "sdk", "members": ["sdk-go", "sdk-java"], "version": "1.1.1"}]`))
+	Ω.Expect(serveRequest(router, "GET", "/groups/unknown", "").Code).To(Equal(404))
+}
+
+func TestSetGroupWithPreconditionWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	router := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir()))).GetRouter()
+	serveRequest(router, "POST", "/version/a/1.0.0", "")
+	serveRequest(router, "POST", "/version/b/1.0.0", "")
+	serveRequest(router, "POST", "/version/c/1.0.0", "")
+	Ω.Expect(serveRequest(router, "PUT", "/groups/ab", `["a"]`, "If-Match", "*").Code).To(Equal(412))
+	serveRequest(router, "PUT", "/groups/ab", `["a"]`)
+	etag := serveRequest(router, "GET", "/groups/ab", "").Header().Get("ETag")
+	Ω.Expect(etag).NotTo(BeEmpty())
+
+	res := serveRequest(router, "PUT", "/groups/ab", `["a", "b"]`, "If-Match", etag)
+	Ω.Expect(res.Code).To(Equal(200))
+	Ω.Expect(res.Header().Get("ETag")).NotTo(Equal(etag))
+	Ω.Expect(serveRequest(router, "PUT", "/groups/ab", `["a", "b", "c"]`, "If-Match", etag).Code).To(Equal(412))
+}
+
+func TestBranchStreamsWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	router := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir()))).GetRouter()
+	serveRequest(router, "POST", "/version/app/1.3.2", "")
+
+	Ω.Expect(serveRequest(router, "POST", "/minor/app?branch=feature/x", "").Body.String()).To(Equal("1.4.0-feature-x.0"))
+	Ω.Expect(serveRequest(router, "POST", "/minor/app?branch=feature/x", "").Body.String()).To(Equal("1.4.0-feature-x.1"))
+	Ω.Expect(serveRequest(router, "POST", "/minor/app?branch=feature/x&dryRun=true", "").Body.String()).To(Equal("1.4.0-feature-x.2"))
+	Ω.Expect(serveRequest(router, "GET", "/version/app?branch=feature/x", "").Body.String()).To(Equal("1.4.0-feature-x.1"))
+	Ω.Expect(serveRequest(router, "GET", "/version/app", "").Body.String()).To(Equal("1.3.2"))
+	Ω.Expect(serveRequest(router, "GET", "/version/app?branch=other", "").Code).To(Equal(404))
+	Ω.Expect(serveRequest(router, "POST", "/patch/app?branch=feature/x&ref=abc", "").Code).To(Equal(400))
+	Ω.Expect(serveRequest(router, "POST", "/patch/app?branch=//", "").Code).To(Equal(400))
+
+	serveRequest(router, "POST", "/minor/app", "")
+	res := serveRequest(router, "POST", "/api/v1/projects/app/bumps", `{"part": "minor", "branch": "feature/x"}`)
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"project": "app", "part": "minor", "branch": "feature/x",
+		"previousVersion": "1.4.0-feature-x.1", "version": "1.5.0-feature-x.0"}`))
+
+	Ω.Expect(serveRequest(router, "DELETE", "/branches/app?branch=feature/x", "").Body.String()).To(Equal("1.5.0-feature-x.0"))
+	Ω.Expect(serveRequest(router, "GET", "/version/app?branch=feature/x", "").Code).To(Equal(404))
+	Ω.Expect(serveRequest(router, "DELETE", "/api/v1/projects/app/branches?branch=feature/x", "").Code).To(Equal(404))
+	Ω.Expect(serveRequest(router, "DELETE", "/branches/app", "").Code).To(Equal(400))
+}
+
+func TestReleaseLinesWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	router := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir()))).GetRouter()
+	serveRequest(router, "POST", "/version/app/1.8.4", "")
+	serveRequest(router, "POST", "/major/app", "")
+
+	Ω.Expect(serveRequest(router, "POST", "/patch/app?line=1&dryRun=true", "").Body.String()).To(Equal("1.8.5"))
+	Ω.Expect(serveRequest(router, "POST", "/patch/app?line=1", "").Body.String()).To(Equal("1.8.5"))
+	Ω.Expect(serveRequest(router, "GET", "/version/app?line=1", "").Body.String()).To(Equal("1.8.5"))
+	Ω.Expect(serveRequest(router, "GET", "/version/app", "").Body.String()).To(Equal("2.0.0"))
+	Ω.Expect(serveRequest(router, "GET", "/lines/app", "").Body.String()).To(Equal("1 1.8.5\n2 2.0.0\n"))
+	Ω.Expect(serveRequest(router, "POST", "/major/app?line=1", "").Code).To(Equal(400))
+	Ω.Expect(serveRequest(router, "POST", "/patch/app?line=1&branch=x", "").Code).To(Equal(400))
+
+	res := serveRequest(router, "POST", "/api/v1/projects/app/bumps", `{"part": "minor", "line": "1"}`)
+	Ω.Expect(res.Body.String()).To(MatchJSON(`{"project": "app", "part": "minor", "line": "1", "previousVersion": "1.8.5", "version": "1.9.0"}`))
+	Ω.Expect(serveRequest(router, "GET", "/api/v1/projects/app/lines", "").Body.String()).
+		To(MatchJSON(`[{"line": "1", "version": "1.9.0"}, {"line": "2", "version": "2.0.0"}]`))
+}
+
+func TestTagsWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	router := NewHandler(service.NewVersionManager(adapter.NewFileProvider(t.TempDir()))).GetRouter()
+	serveRequest(router, "POST", "/version/team/app/1.0.0", "")
+	serveRequest(router, "POST", "/minor/team/app", "")
+
+	Ω.Expect(serveRequest(router, "PUT", "/tags/stable/team/app/1.0.0", "").Body.String()).To(Equal("1.0.0"))
+	Ω.Expect(serveRequest(router, "PUT", "/api/v1/projects/team%2Fapp/tags/next", `{"version": "1.1.0"}`).Body.String()).
+		To(MatchJSON(`{"project": "team/app", "version": "1.1.0", "tag": "next"}`))
+	Ω.Expect(serveRequest(router, "GET", "/version/team/app?tag=stable", "").Body.String()).To(Equal("1.0.0"))
+	Ω.Expect(serveRequest(router, "GET", "/tags/team/app", "").Body.String()).To(Equal("next 1.1.0\nstable 1.0.0\n"))
+	Ω.Expect(serveRequest(router, "GET", "/api/v1/projects", "").Body.String()).
+		To(MatchJSON(`[{"project": "team/app", "version": "1.1.0", "tags": {"next": "1.1.0", "stable": "1.0.0"}}]`))
+	Ω.Expect(serveRequest(router, "GET", "/projects?format=json", "").Body.String()).
+		To(MatchJSON(`{"namespace": "", "projects": ["team/app"], "tags": {"team/app": {"next": "1.1.0", "stable": "1.0.0"}}}`))
+	Ω.Expect(serveRequest(router, "PUT", "/tags/stable/team/app/9.9.9", "").Code).To(Equal(404))
+
+	Ω.Expect(serveRequest(router, "DELETE", "/api/v1/projects/team%2Fapp/tags/next", "").Body.String()).To(MatchJSON(`{"stable": "1.0.0"}`))
+	Ω.Expect(serveRequest(router, "DELETE", "/tags/next/team/app", "").Code).To(Equal(404))
+	Ω.Expect(serveRequest(router, "GET", "/api/v1/projects/team%2Fapp/tags/next", "").Code).To(Equal(404))
+}
+
+func TestWebhooksWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	handler := NewHandler(service.NewVersionManager(adapter.NewFileProvider(basePath)))
+	router := handler.GetRouter()
+	Ω.Expect(serveRequest(router, "GET", "/webhooks", "").Code).To(Equal(404))
+
+	store := adapter.NewFileWebhookStore(basePath)
+	webhooks := service.NewWebhooks(store, 3)
+	handler.EnableWebhooks(webhooks)
+	added := serveRequest(router, "POST", "/api/v1/webhooks", `{"url": "https://example.com/hook", "secret": "s3cret", "project": "team"}`)
+	Ω.Expect(added.Code).To(Equal(200))
+	var webhook model.Webhook
+	_ = json.Unmarshal(added.Body.Bytes(), &webhook)
+	Ω.Expect(webhook.Secret).To(BeEmpty())
+	Ω.Expect(serveRequest(router, "GET", "/webhooks", "").Body.String()).To(Equal(webhook.ID + " https://example.com/hook team\n"))
+	Ω.Expect(serveRequest(router, "POST", "/webhooks", `{"url": "example.com", "secret": "s3cret"}`).Code).To(Equal(400))
+	Ω.Expect(serveRequest(router, "POST", "/webhooks", `{"url": "https://example.com/hook"}`).Code).To(Equal(400))
+
+	serveRequest(router, "POST", "/version/team/app/1.0.0", "")
+	serveRequest(router, "POST", "/minor/team/app", "")
+	serveRequest(router, "POST", "/minor/other", "")
+	deliveries, _ := store.ReadDeliveries()
+	Ω.Expect(deliveries).To(HaveLen(2))
+	events := []string{deliveries[0].Event.Type, deliveries[1].Event.Type}
+	Ω.Expect(events).To(Equal([]string{model.EventSet, model.EventBump}))
+
+	Ω.Expect(serveRequest(router, "DELETE", "/api/v1/webhooks/"+webhook.ID, "").Body.String()).To(MatchJSON(`[]`))
+	Ω.Expect(serveRequest(router, "DELETE", "/webhooks/"+webhook.ID, "").Code).To(Equal(404))
+	_, _ = webhooks.Deliver()
+	deliveries, _ = store.ReadDeliveries()
+	Ω.Expect(deliveries).To(BeEmpty())
+}
+
+func TestEventsWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	handler := NewHandler(service.NewVersionManager(adapter.NewFileProvider(basePath)))
+	router := handler.GetRouter()
+	Ω.Expect(serveRequest(router, "GET", "/events", "").Code).To(Equal(404))
+
+	eventLog, _ := service.NewEventLog(adapter.NewFileEventStore(basePath), 100)
+	handler.EnableEvents(eventLog, time.Second)
+	// streams end once they sent the events the client missed
+	handler.EndEventStreams()
+	serveRequest(router, "POST", "/version/team/app/1.0.0", "")
+	serveRequest(router, "POST", "/version/other/1.0.0", "")
+	serveRequest(router, "POST", "/minor/team/app", "")
+
+	res := serveRequest(router, "GET", "/events?namespace=team", "")
+	Ω.Expect(res.Header().Get("Content-Type")).To(Equal("text/event-stream"))
+	Ω.Expect(res.Body.String()).To(ContainSubstring("id: 1\nevent: set\ndata: {"))
+	Ω.Expect(res.Body.String()).To(ContainSubstring("id: 3\nevent: bump\ndata: {"))
+	Ω.Expect(res.Body.String()).NotTo(ContainSubstring("id: 2\n"))
+
+	res = serveRequest(router, "GET", "/events?project=team/app", "", lastEventIDHeader, "1")
+	Ω.Expect(res.Body.String()).NotTo(ContainSubstring("id: 1\n"))
+	Ω.Expect(res.Body.String()).To(ContainSubstring(`"previousVersion":"1.0.0","version":"1.1.0"`))
+	Ω.Expect(serveRequest(router, "GET", "/events", "", lastEventIDHeader, "latest").Code).To(Equal(400))
+	Ω.Expect(serveRequest(router, "GET", "/events", "", lastEventIDHeader, "1").Body.String()).NotTo(ContainSubstring("event: reset\n"))
+	Ω.Expect(serveRequest(router, "GET", "/events", "", lastEventIDHeader, "42").Body.String()).To(HavePrefix("retry: 1000\n\nevent: reset\ndata: {}\n\n"))
+}
+
+func TestLiveEventsWithHandler(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	handler := NewHandler(service.NewVersionManager(adapter.NewFileProvider(basePath)))
+	eventLog, _ := service.NewEventLog(adapter.NewFileEventStore(basePath), 100)
+	handler.EnableEvents(eventLog, 200*time.Millisecond)
+	server := httptest.NewUnstartedServer(handler.GetRouter())
+	server.Config.WriteTimeout = 200 * time.Millisecond
+	server.Config.RegisterOnShutdown(handler.EndEventStreams)
+	server.Start()
+	defer server.Close()
+
+	// fail instead of hanging if the stream stalls
+	client := &http.Client{Timeout: 5 * time.Second}
+	stream, err := client.Get(server.URL + "/events?project=app")
+	Ω.Expect(err).To(BeNil())
+	defer stream.Body.Close()
+	reader := bufio.NewReader(stream.Body)
+	retry, _ := reader.ReadString('\n')
+	Ω.Expect(retry).To(Equal("retry: 1000\n"))
+
+	// the stream outlives the server's write timeout
+	time.Sleep(400 * time.Millisecond)
+	_, _ = http.Post(server.URL+"/version/other/1.0.0", "", nil)
+	_, _ = http.Post(server.URL+"/version/app/2.0.0", "", nil)
+	_, _ = reader.ReadString('\n')
+	id, _ := reader.ReadString('\n')
+	event, _ := reader.ReadString('\n')
+	data, _ := reader.ReadString('\n')
+	Ω.Expect(id).To(Equal("id: 2\n"))
+	Ω.Expect(event).To(Equal("event: set\n"))
+	Ω.Expect(data).To(ContainSubstring(`"project":"app"`))
+
+	// shutting down ends the stream
+	go func() { _ = server.Config.Shutdown(gocontext.Background()) }()
+	_, _ = reader.ReadString('\n')
+	_, err = reader.ReadString('\n')
+	Ω.Expect(err).To(Equal(io.EOF))
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"maibornwolff/vbump/model"
+	"maibornwolff/vbump/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// EnableWebhooks makes the handler manage webhook subscriptions and publish the events of all bumps and set versions
+// to them
+func (handler *Handler) EnableWebhooks(webhooks *service.Webhooks) {
+	handler.webhooks = webhooks
+	handler.tenants.PublishTo(webhooks)
+}
+
+// OnListWebhooks is a handler for listing the webhooks of the tenant without their secrets
+func (handler *Handler) OnListWebhooks(context *gin.Context) {
+	if handler.webhooks == nil {
+		abortWithError(context, errors.Wrap(service.ErrNotFound, "Webhooks are not enabled"))
+		return
+	}
+
+	webhooks, err := handler.webhooks.List(tenantOf(context))
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Int("webhooks", len(webhooks)).Msg("Listed webhooks")
+	respond(context, http.StatusOK, formatWebhooks(webhooks...), webhooks)
+}
+
+// OnAddWebhook is a handler for subscribing the webhook in the JSON request body to the events of the tenant
+func (handler *Handler) OnAddWebhook(context *gin.Context) {
+	if handler.webhooks == nil {
+		abortWithError(context, errors.Wrap(service.ErrNotFound, "Webhooks are not enabled"))
+		return
+	}
+
+	var webhook model.Webhook
+	if err := context.ShouldBindJSON(&webhook); err != nil {
+		abortWithStatus(context, http.StatusBadRequest, err)
+		return
+	}
+	webhook.Tenant = tenantOf(context)
+
+	added, err := handler.webhooks.Add(webhook)
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+	added.Secret = ""
+
+	log.Info().Str("webhook", added.ID).Str("project", added.Project).Msg("Added webhook")
+	respond(context, http.StatusOK, formatWebhooks(added), added)
+}
+
+// OnDeleteWebhook is a handler for unsubscribing a webhook of the tenant, answering with the remaining webhooks
+func (handler *Handler) OnDeleteWebhook(context *gin.Context) {
+	if handler.webhooks == nil {
+		abortWithError(context, errors.Wrap(service.ErrNotFound, "Webhooks are not enabled"))
+		return
+	}
+
+	id := context.Param("id")
+	if err := handler.webhooks.Delete(tenantOf(context), id); err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	webhooks, err := handler.webhooks.List(tenantOf(context))
+	if err != nil {
+		abortWithError(context, err)
+		return
+	}
+
+	log.Info().Str("webhook", id).Msg("Deleted webhook")
+	respond(context, http.StatusOK, formatWebhooks(webhooks...), webhooks)
+}
+
+// formatWebhooks formats webhooks as one "id url project" line per webhook
+func formatWebhooks(webhooks ...model.Webhook) string {
+	lines := make([]string, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		lines = append(lines, strings.TrimSpace(webhook.ID+" "+webhook.URL+" "+webhook.Project))
+	}
+
+	return joinLines(lines)
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -11,42 +14,316 @@ import (
 	"maibornwolff/vbump/service"
 )
 
+// writeTimeout limits the time to answer a request and to write an event to a stream
+const writeTimeout = 10 * time.Second
+
 var (
 	numberOfBumps = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "vbump_bumps_total",
-			Help: "Number of bumps tracked by vbump, labelled with project name and semVer element",
+			Help: "Number of bumps tracked by vbump, labelled with tenant, project name and semVer element",
 		},
-		[]string{"project", "element"},
+		[]string{"tenant", "project", "element"},
 	)
 )
 
+var (
+	listenAddr = kingpin.Flag("listen", "Address to listen on.").Short('l').Default(":8080").String()
+	dataDir    = kingpin.Flag("datadir", "Directory path for storing version files (must exist).").Short('d').Required().String()
+
+	serveCommand         = kingpin.Command("serve", "Serve the version API (default).").Default()
+	injectFaults         = serveCommand.Flag("inject-fault", "Inject storage faults for resilience testing as <operation>:latency=<duration>,error=<rate>,notfound=<rate>,partial=<rate> (repeatable, operation * for all).").Strings()
+	tenantFrom           = serveCommand.Flag("tenant-from", "Bind requests to tenants by the X-Vbump-Tenant header, by the first label of the host or not at all.").Default("none").Enum("none", "header", "host")
+	trustedProxies       = serveCommand.Flag("trusted-proxy", "Address or CIDR network of a proxy authenticating requests and binding them to tenants (repeatable, required with --tenant-from).").Strings()
+	adminToken           = serveCommand.Flag("admin-token", "Bearer token required by the /admin routes (default: /admin routes are disabled).").Envar("VBUMP_ADMIN_TOKEN").String()
+	cacheTTL             = serveCommand.Flag("cache-ttl", "Time to cache projects read from storage, e.g. 30s (default: no caching).").Default("0s").Duration()
+	idempotencyWindow    = serveCommand.Flag("idempotency-window", "Time to replay the response to retries of mutating requests with the same Idempotency-Key header (0 to disable).").Default("24h").Duration()
+	webhookMaxAttempts   = serveCommand.Flag("webhook-max-attempts", "Attempts to deliver an event to a webhook before giving up, retrying with exponential backoff (0 to disable webhooks).").Default("10").Int()
+	webhookAllowInternal = serveCommand.Flag("webhook-allow-internal", "Allow webhooks to point to localhost and loopback, private or link-local addresses.").Bool()
+	eventLogSize         = serveCommand.Flag("event-log-size", "Number of events retained per tenant for clients resuming the event stream with Last-Event-ID (0 to disable event streams).").Default("1000").Int()
+
+	migrateCommand  = kingpin.Command("migrate", "Upgrade the data directory in place to the current schema version.")
+	migrateDryRun   = migrateCommand.Flag("dry-run", "Only report the migration steps without changing anything.").Bool()
+	migrateBackup   = migrateCommand.Flag("backup", "Directory to back up the data directory to before migrating (default: <datadir>.backup-<timestamp>).").String()
+	migrateNoBackup = migrateCommand.Flag("no-backup", "Skip backing up the data directory.").Bool()
+
+	fsckCommand = kingpin.Command("fsck", "Check the data directory for broken version and history files.")
+	fsckRepair  = fsckCommand.Flag("repair", "Restore broken version files from the project's history.").Bool()
+
+	storageCommand         = kingpin.Command("storage", "Manage version storages.")
+	storageCopyCommand     = storageCommand.Command("copy", "Copy all projects with metadata and history from one storage to another.")
+	storageCopyFrom        = storageCopyCommand.Flag("from", "Source storage as <backend>:<location> (default: file:<datadir>).").String()
+	storageCopyTo          = storageCopyCommand.Flag("to", "Target storage as <backend>:<location>, e.g. file:/backup.").Required().String()
+	storageCopyIncremental = storageCopyCommand.Flag("incremental", "Only copy projects which changed since the last copy.").Bool()
+)
+
 func init() {
 	prometheus.MustRegister(numberOfBumps)
 }
 
 func main() {
-	listenAddr := kingpin.Flag("listen", "Address to listen on.").Short('l').Default(":8080").String()
-	dataDir := kingpin.Flag("datadir", "Directory path for storing version files (must exist).").Short('d').Required().String()
-	kingpin.Parse()
+	switch kingpin.Parse() {
+	case migrateCommand.FullCommand():
+		migrate()
+	case fsckCommand.FullCommand():
+		fsck()
+	case storageCopyCommand.FullCommand():
+		copyStorage()
+	case serveCommand.FullCommand():
+		serve()
+	}
+}
 
+func serve() {
 	log.Info().Msg("Server is starting...")
 
-	fileProvider := adapter.NewFileProvider(*dataDir)
-	versionManager := service.NewVersionManager(fileProvider)
+	schemaVersion, err := adapter.CheckSchema(*dataDir)
+	if err != nil {
+		log.Fatal().Str("dataDir", *dataDir).Err(err).Msg("Unsupported data directory")
+	}
+	if schemaVersion < adapter.SchemaVersion {
+		log.Warn().Str("dataDir", *dataDir).Int("schemaVersion", schemaVersion).Int("currentSchemaVersion", adapter.SchemaVersion).Msg("Data directory has an outdated schema, run 'vbump migrate' to upgrade it")
+	}
+
+	versionManager := service.NewVersionManager(newStorageProvider(*dataDir))
 	handler := NewHandler(versionManager)
+	if *tenantFrom != "none" {
+		tenants := service.NewTenants(versionManager, adapter.NewFileTenantStore(*dataDir), newTenantVersionManager)
+		resolveTenant := TenantFromHeader
+		if *tenantFrom == "host" {
+			resolveTenant = TenantFromHost
+		}
+		networks, err := ParseTrustedProxies(*trustedProxies)
+		if err != nil {
+			log.Fatal().Strs("trustedProxies", *trustedProxies).Err(err).Msg("Invalid trusted proxy")
+		}
+		if len(networks) == 0 {
+			log.Fatal().Str("tenantFrom", *tenantFrom).Msg("Binding requests to tenants requires --trusted-proxy")
+		}
+		handler = NewTenantHandler(tenants, resolveTenant, networks)
+		log.Info().Str("tenantFrom", *tenantFrom).Strs("trustedProxies", *trustedProxies).Msg("Binding requests to tenants")
+	}
+	if *adminToken != "" {
+		handler.EnableAdmin(*adminToken)
+		log.Info().Msg("Serving admin routes to requests with the admin token")
+	}
+	if *idempotencyWindow > 0 {
+		idempotencyStore := adapter.NewFileIdempotencyStore(*dataDir)
+		deleted, err := idempotencyStore.DeleteResponsesBefore(time.Now().Add(-*idempotencyWindow))
+		if err != nil {
+			log.Fatal().Str("dataDir", *dataDir).Err(err).Msg("Failed to delete expired idempotent responses")
+		}
+		handler.EnableIdempotency(idempotencyStore, *idempotencyWindow)
+		go handler.PruneIdempotentResponses(nil, idempotencyPruneInterval(*idempotencyWindow))
+		log.Info().Dur("idempotencyWindow", *idempotencyWindow).Int("expired", deleted).Msg("Replaying responses for idempotency keys")
+	}
+	if *webhookMaxAttempts > 0 {
+		webhooks := service.NewWebhooks(adapter.NewFileWebhookStore(*dataDir), *webhookMaxAttempts)
+		if *webhookAllowInternal {
+			webhooks.AllowInternalHosts()
+		}
+		handler.EnableWebhooks(webhooks)
+		go webhooks.Run(nil, time.Second)
+		log.Info().Int("webhookMaxAttempts", *webhookMaxAttempts).Bool("webhookAllowInternal", *webhookAllowInternal).Msg("Delivering events to webhooks")
+	}
+	if *eventLogSize > 0 {
+		eventLog, err := service.NewEventLog(adapter.NewFileEventStore(*dataDir), *eventLogSize)
+		if err != nil {
+			log.Fatal().Str("dataDir", *dataDir).Err(err).Msg("Failed to read event log")
+		}
+		handler.EnableEvents(eventLog, writeTimeout)
+		log.Info().Int("eventLogSize", *eventLogSize).Msg("Streaming events")
+	}
 	router := handler.GetRouter()
 
 	server := &http.Server{
 		Addr:         *listenAddr,
 		Handler:      router,
 		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		WriteTimeout: writeTimeout,
 		IdleTimeout:  15 * time.Second,
 	}
+	server.RegisterOnShutdown(handler.EndEventStreams)
 
 	log.Info().Str("listenAddr", *listenAddr).Msg("Server is ready to handle requests")
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatal().Str("listenAddr", *listenAddr).Err(err).Msg("Failed to listen")
 	}
 }
+
+// idempotencyPruneInterval returns how often expired idempotent responses are deleted, at least hourly
+func idempotencyPruneInterval(window time.Duration) time.Duration {
+	if window < time.Hour {
+		return window
+	}
+
+	return time.Hour
+}
+
+func newStorageProvider(basePath string) adapter.StorageProvider {
+	storageProvider := adapter.NewFileProvider(basePath)
+	if len(*injectFaults) > 0 {
+		faults, err := adapter.ParseFaults(*injectFaults)
+		if err != nil {
+			log.Fatal().Strs("injectFaults", *injectFaults).Err(err).Msg("Invalid fault spec")
+		}
+		storageProvider = adapter.NewFaultProvider(storageProvider, faults)
+		log.Warn().Strs("injectFaults", *injectFaults).Str("basePath", basePath).Msg("Injecting storage faults, do not use in production")
+	}
+	if *cacheTTL > 0 {
+		storageProvider = adapter.NewCachingProvider(storageProvider, *cacheTTL)
+		log.Info().Dur("cacheTTL", *cacheTTL).Str("basePath", basePath).Msg("Caching projects read from storage")
+	}
+
+	return storageProvider
+}
+
+func newTenantVersionManager(tenant string) (*service.VersionManager, error) {
+	basePath := adapter.TenantPath(*dataDir, tenant)
+	if err := os.MkdirAll(basePath, 0755); err != nil {
+		return nil, err
+	}
+	if _, err := adapter.CheckSchema(basePath); err != nil {
+		return nil, err
+	}
+
+	return service.NewVersionManager(newStorageProvider(basePath)), nil
+}
+
+func migrate() {
+	backupPath := *migrateBackup
+	if backupPath == "" && !*migrateNoBackup {
+		backupPath = fmt.Sprintf("%s.backup-%s", filepath.Clean(*dataDir), time.Now().UTC().Format("20060102T150405Z"))
+	}
+	if *migrateNoBackup {
+		backupPath = ""
+	}
+
+	report, err := adapter.Migrate(*dataDir, *migrateDryRun, backupPath)
+	for _, line := range report {
+		fmt.Println(line)
+	}
+	if err != nil {
+		log.Fatal().Str("dataDir", *dataDir).Err(err).Msg("Failed to migrate data directory")
+	}
+
+	if *migrateDryRun {
+		log.Info().Str("dataDir", *dataDir).Msg("Dry run finished, nothing was changed")
+		return
+	}
+	log.Info().Str("dataDir", *dataDir).Int("schemaVersion", adapter.SchemaVersion).Msg("Data directory migrated")
+}
+
+func fsck() {
+	issues, err := adapter.Fsck(*dataDir, *fsckRepair)
+	repaired, unresolved := 0, 0
+	for _, issue := range issues {
+		status := "found"
+		if issue.Repaired {
+			status = "repaired"
+			repaired++
+		} else {
+			unresolved++
+		}
+		fmt.Printf("%s %s %s: %s\n", status, issue.Problem, tenantProject(issue.Tenant, issue.Project), issue.Detail)
+	}
+	if err != nil {
+		log.Fatal().Str("dataDir", *dataDir).Err(err).Msg("Failed to check data directory")
+	}
+
+	if unresolved > 0 {
+		log.Error().Str("dataDir", *dataDir).Int("issues", len(issues)).Int("repaired", repaired).Int("unresolved", unresolved).Msg("Data directory has unresolved issues")
+		os.Exit(1)
+	}
+	log.Info().Str("dataDir", *dataDir).Int("issues", len(issues)).Int("repaired", repaired).Msg("Data directory is consistent")
+}
+
+func copyStorage() {
+	from := *storageCopyFrom
+	if from == "" {
+		from = "file:" + *dataDir
+	}
+
+	source, err := adapter.OpenStorage(from)
+	if err != nil {
+		log.Fatal().Str("from", from).Err(err).Msg("Failed to open source storage")
+	}
+	target, err := adapter.OpenStorage(*storageCopyTo)
+	if err != nil {
+		log.Fatal().Str("to", *storageCopyTo).Err(err).Msg("Failed to open target storage")
+	}
+
+	report, err := service.CopyStorage(source, target, *storageCopyIncremental)
+	printCopyReport("", report)
+	if err != nil {
+		log.Fatal().Str("from", from).Str("to", *storageCopyTo).Err(err).Msg("Failed to copy storage")
+	}
+	copied, unchanged := len(report.Copied), len(report.Unchanged)
+
+	tenants, err := adapter.StorageTenants(from)
+	if err != nil {
+		log.Fatal().Str("from", from).Err(err).Msg("Failed to list tenants of source storage")
+	}
+	for _, tenant := range tenants {
+		tenantSource, err := adapter.OpenTenantStorage(from, tenant)
+		if err != nil {
+			log.Fatal().Str("from", from).Str("tenant", tenant).Err(err).Msg("Failed to open source storage of tenant")
+		}
+		tenantTarget, err := adapter.OpenTenantStorage(*storageCopyTo, tenant)
+		if err != nil {
+			log.Fatal().Str("to", *storageCopyTo).Str("tenant", tenant).Err(err).Msg("Failed to open target storage of tenant")
+		}
+
+		report, err := service.CopyStorage(tenantSource, tenantTarget, *storageCopyIncremental)
+		printCopyReport(tenant, report)
+		if err != nil {
+			log.Fatal().Str("from", from).Str("to", *storageCopyTo).Str("tenant", tenant).Err(err).Msg("Failed to copy storage of tenant")
+		}
+		copied, unchanged = copied+len(report.Copied), unchanged+len(report.Unchanged)
+	}
+	if err := copyTenantRegistry(from, *storageCopyTo); err != nil {
+		log.Fatal().Str("from", from).Str("to", *storageCopyTo).Err(err).Msg("Failed to copy registered tenants")
+	}
+
+	log.Info().Str("from", from).Str("to", *storageCopyTo).Int("tenants", len(tenants)).Int("copied", copied).Int("unchanged", unchanged).Msg("Storage copied and verified")
+}
+
+func printCopyReport(tenant string, report service.CopyReport) {
+	for _, project := range report.Copied {
+		fmt.Printf("copied %s\n", tenantProject(tenant, project))
+	}
+	for _, project := range report.Unchanged {
+		fmt.Printf("unchanged %s\n", tenantProject(tenant, project))
+	}
+	for _, namespace := range report.Namespaces {
+		fmt.Printf("settings %s\n", tenantProject(tenant, namespace+"/"))
+	}
+}
+
+// copyTenantRegistry copies the registered tenants with their quotas, if any, from one storage to another
+func copyTenantRegistry(from string, to string) error {
+	source, err := adapter.OpenTenantStore(from)
+	if err != nil {
+		return err
+	}
+	registered, err := source.ReadTenants()
+	if err != nil || len(registered) == 0 {
+		return err
+	}
+
+	target, err := adapter.OpenTenantStore(to)
+	if err != nil {
+		return err
+	}
+
+	return target.StoreTenants(registered)
+}
+
+// tenantProject qualifies a project of a tenant other than the default tenant with the tenant for reports
+func tenantProject(tenant string, project string) string {
+	if tenant == "" {
+		return project
+	}
+
+	return tenant + ":" + project
+}
@@ -0,0 +1,13 @@
+package model
+
+// OperationSet sets a project's version in a batch instead of bumping a part of it
+const OperationSet = "set"
+
+// BatchItem is the change of a single project within a batch, either bumping a part or setting a version, optionally
+// only if the project matches the entity tag IfMatch like with the If-Match header
+type BatchItem struct {
+	Project   string `json:"project"`
+	Operation string `json:"operation"`
+	Version   string `json:"version,omitempty"`
+	IfMatch   string `json:"ifMatch,omitempty"`
+}
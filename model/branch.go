@@ -0,0 +1,44 @@
+package model
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	invalidIdentifierCharacters = regexp.MustCompile(`[^0-9A-Za-z-]+`)
+	numericIdentifier           = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// BranchIdentifier sanitizes a branch name to a pre-release identifier by replacing all characters not allowed in
+// pre-releases with dashes, like feature/x to feature-x. Numeric names like 007 are prefixed with branch-, so they
+// never have leading zeros. Different branches may share an identifier, like feature/x and feature-x.
+func BranchIdentifier(branch string) (string, error) {
+	identifier := strings.Trim(invalidIdentifierCharacters.ReplaceAllString(branch, "-"), "-")
+	if identifier == "" {
+		return "", errors.Errorf("%v is not a valid branch", branch)
+	}
+	if numericIdentifier.MatchString(identifier) {
+		identifier = "branch-" + identifier
+	}
+
+	return identifier, nil
+}
+
+// BranchVersion returns the next version of a branch stream, which is a pre-release of the main line's next version
+// identified by the branch and numbered by a counter. The counter continues the stream's previous version if it is a
+// pre-release of the same version and starts at 0 otherwise, e.g. when the main line moved on.
+func BranchVersion(next Version, identifier string, previous Version) Version {
+	next = next.WithPrerelease("")
+	counter := 0
+	if previous.WithPrerelease("").Compare(next) == 0 && strings.HasPrefix(previous.Prerelease(), identifier+separator) {
+		if number, err := strconv.Atoi(strings.TrimPrefix(previous.Prerelease(), identifier+separator)); err == nil {
+			counter = number + 1
+		}
+	}
+
+	return next.WithPrerelease(identifier + separator + strconv.Itoa(counter))
+}
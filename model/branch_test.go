@@ -0,0 +1,43 @@
+package model
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestBranchIdentifier(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	identifier, err := BranchIdentifier("feature-x")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(identifier).To(Equal("feature-x"))
+
+	identifier, _ = BranchIdentifier("feature/x")
+	Ω.Expect(identifier).To(Equal("feature-x"))
+
+	identifier, _ = BranchIdentifier("/bugfix/JIRA-12_login.page/")
+	Ω.Expect(identifier).To(Equal("bugfix-JIRA-12-login-page"))
+
+	identifier, _ = BranchIdentifier("007")
+	Ω.Expect(identifier).To(Equal("branch-007"))
+	Ω.Expect(ValidateVersionString(NewVersion(1, 0, 0).WithPrerelease(identifier + ".0").String())).To(BeTrue())
+
+	_, err = BranchIdentifier("//")
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func TestBranchVersion(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	next := NewVersion(1, 4, 0)
+	first := BranchVersion(next, "feature-x", Version{})
+	Ω.Expect(first.String()).To(Equal("1.4.0-feature-x.0"))
+
+	second := BranchVersion(next, "feature-x", first)
+	Ω.Expect(second.String()).To(Equal("1.4.0-feature-x.1"))
+
+	moved := BranchVersion(NewVersion(1, 5, 0), "feature-x", second)
+	Ω.Expect(moved.String()).To(Equal("1.5.0-feature-x.0"))
+	Ω.Expect(ValidateVersionString(moved.String())).To(BeTrue())
+}
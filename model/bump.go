@@ -0,0 +1,49 @@
+package model
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Version parts which can be bumped
+const (
+	PartMajor      = "major"
+	PartMinor      = "minor"
+	PartPatch      = "patch"
+	PartPrerelease = "prerelease"
+)
+
+// Bump describes a version bump of a project. Bumping a source reference again reuses the version bumped before.
+// Bumping a project bumps the other members of its group to the same version and cascades to the projects depending
+// on them, each cascaded bump naming the dependencies it cascaded from.
+// Bumps of a branch only change the branch's pre-release stream, bumps within a release line only the line unless it is
+// the highest one.
+type Bump struct {
+	Project         string   `json:"project,omitempty"`
+	Part            string   `json:"part"`
+	PreviousVersion Version  `json:"previousVersion"`
+	Version         Version  `json:"version"`
+	Ref             string   `json:"ref,omitempty"`
+	Branch          string   `json:"branch,omitempty"`
+	Line            string   `json:"line,omitempty"`
+	Reused          bool     `json:"reused,omitempty"`
+	DryRun          bool     `json:"dryRun,omitempty"`
+	Linked          []string `json:"linked,omitempty"`
+	CascadedFrom    []string `json:"cascadedFrom,omitempty"`
+	Cascaded        []Bump   `json:"cascaded,omitempty"`
+}
+
+// BumpPart bumps the given part of a version
+func BumpPart(version Version, part string) (Version, error) {
+	switch part {
+	case PartMajor:
+		return version.BumpMajor(), nil
+	case PartMinor:
+		return version.BumpMinor(), nil
+	case PartPatch:
+		return version.BumpPatch(), nil
+	case PartPrerelease:
+		return version.BumpPrerelease(), nil
+	}
+
+	return version, errors.Errorf("%v is not a version part", part)
+}
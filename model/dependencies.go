@@ -0,0 +1,142 @@
+package model
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CascadeNone disables cascading a bump of a dependency to its dependents
+const CascadeNone = "none"
+
+// cascadeSettingPrefix prefixes the settings which configure the part a project bumps when one of its dependencies
+// bumps a given part, e.g. cascade.minor=patch
+const cascadeSettingPrefix = "cascade."
+
+// defaultCascades are the parts projects bump when a dependency bumps a part unless their settings say otherwise
+var defaultCascades = map[string]string{
+	PartMajor:      PartPatch,
+	PartMinor:      PartPatch,
+	PartPatch:      PartPatch,
+	PartPrerelease: CascadeNone,
+}
+
+// partStrength orders the parts of a version by significance
+var partStrength = map[string]int{
+	PartPrerelease: 1,
+	PartPatch:      2,
+	PartMinor:      3,
+	PartMajor:      4,
+}
+
+// CascadePart returns the part a project with the given settings bumps when one of its dependencies bumps the given
+// part, or an empty part if the bump does not cascade
+func CascadePart(settings map[string]string, dependencyPart string) string {
+	part, found := settings[cascadeSettingPrefix+dependencyPart]
+	if !found {
+		part = defaultCascades[dependencyPart]
+	}
+	if _, known := partStrength[part]; !known {
+		return ""
+	}
+
+	return part
+}
+
+// StrongerPart returns the more significant of two parts, an empty part being the least significant
+func StrongerPart(part string, other string) string {
+	if partStrength[other] > partStrength[part] {
+		return other
+	}
+
+	return part
+}
+
+// DependencyGraph maps projects to the projects they depend on
+type DependencyGraph map[string][]string
+
+// dependents returns the projects depending directly on each project
+func (graph DependencyGraph) dependents() map[string][]string {
+	dependents := make(map[string][]string)
+	for project, dependencies := range graph {
+		for _, dependency := range dependencies {
+			dependents[dependency] = append(dependents[dependency], project)
+		}
+	}
+	for _, projects := range dependents {
+		sort.Strings(projects)
+	}
+
+	return dependents
+}
+
+// DependentsInOrder returns all projects depending directly or transitively on the given projects, ordered so that
+// every project comes after the projects it depends on. It fails if the dependencies contain a cycle.
+func (graph DependencyGraph) DependentsInOrder(projects ...string) ([]string, error) {
+	dependents := graph.dependents()
+
+	starts := make(map[string]bool, len(projects))
+	reachable := make(map[string]bool, len(projects))
+	queue := make([]string, 0, len(projects))
+	for _, project := range projects {
+		starts[project] = true
+		reachable[project] = true
+		queue = append(queue, project)
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[current] {
+			if !reachable[dependent] {
+				reachable[dependent] = true
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	inDegree := make(map[string]int, len(reachable))
+	for current := range reachable {
+		for _, dependency := range graph[current] {
+			if reachable[dependency] {
+				inDegree[current]++
+			}
+		}
+	}
+
+	ordered := make([]string, 0, len(reachable))
+	ready := make([]string, 0, len(projects))
+	for _, project := range projects {
+		if inDegree[project] == 0 {
+			ready = append(ready, project)
+		}
+	}
+	sorted := 0
+	for len(ready) > 0 {
+		current := ready[0]
+		ready = ready[1:]
+		sorted++
+		if !starts[current] {
+			ordered = append(ordered, current)
+		}
+		for _, dependent := range dependents[current] {
+			inDegree[dependent]--
+			if inDegree[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if sorted < len(reachable) {
+		cyclic := make([]string, 0)
+		for current := range reachable {
+			if inDegree[current] > 0 {
+				cyclic = append(cyclic, current)
+			}
+		}
+		sort.Strings(cyclic)
+		return nil, errors.Errorf("Dependencies of projects %v form a cycle", strings.Join(cyclic, ", "))
+	}
+
+	return ordered, nil
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDependentsInOrder(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	graph := DependencyGraph{
+		"app":    {"lib", "client"},
+		"client": {"lib"},
+		"tool":   {"other"},
+	}
+
+	dependents, err := graph.DependentsInOrder("lib")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(dependents).To(Equal([]string{"client", "app"}))
+}
+
+func TestDependentsInOrderDetectsCycles(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	graph := DependencyGraph{
+		"a": {"b"},
+		"b": {"c"},
+		"c": {"a"},
+	}
+
+	_, err := graph.DependentsInOrder("a")
+
+	Ω.Expect(err).To(MatchError(ContainSubstring("a, b, c")))
+}
+
+func TestCascadePart(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(CascadePart(nil, PartMinor)).To(Equal(PartPatch))
+	Ω.Expect(CascadePart(nil, PartPrerelease)).To(Equal(""))
+	Ω.Expect(CascadePart(map[string]string{"cascade.major": PartMajor}, PartMajor)).To(Equal(PartMajor))
+	Ω.Expect(CascadePart(map[string]string{"cascade.patch": CascadeNone}, PartPatch)).To(Equal(""))
+	Ω.Expect(StrongerPart(PartPatch, PartMinor)).To(Equal(PartMinor))
+	Ω.Expect(StrongerPart(PartMinor, "")).To(Equal(PartMinor))
+}
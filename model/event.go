@@ -0,0 +1,77 @@
+package model
+
+import (
+	"time"
+)
+
+// Event types of version changes
+const (
+	EventBump = "bump"
+	EventSet  = "set"
+)
+
+// Event describes a change of a project's version, published to webhooks and event streams
+type Event struct {
+	ID              string    `json:"id"`
+	Type            string    `json:"type"`
+	Tenant          string    `json:"tenant"`
+	Project         string    `json:"project"`
+	Part            string    `json:"part,omitempty"`
+	PreviousVersion Version   `json:"previousVersion"`
+	Version         Version   `json:"version"`
+	Ref             string    `json:"ref,omitempty"`
+	Branch          string    `json:"branch,omitempty"`
+	Line            string    `json:"line,omitempty"`
+	CascadedFrom    []string  `json:"cascadedFrom,omitempty"`
+	Timestamp       time.Time `json:"timestamp"`
+}
+
+// EventsOf returns the events of a bump or set version of a project, one for the project itself and one for every
+// project linked with it or cascaded to
+func EventsOf(tenant string, bump Bump, at time.Time) []Event {
+	event := Event{
+		Type:            EventBump,
+		Tenant:          tenant,
+		Project:         bump.Project,
+		Part:            bump.Part,
+		PreviousVersion: bump.PreviousVersion,
+		Version:         bump.Version,
+		Ref:             bump.Ref,
+		Branch:          bump.Branch,
+		Line:            bump.Line,
+		CascadedFrom:    bump.CascadedFrom,
+		Timestamp:       at,
+	}
+	if bump.Part == OperationSet {
+		event.Type = EventSet
+		event.Part = ""
+	}
+
+	events := []Event{event}
+	for _, project := range bump.Linked {
+		linked := event
+		linked.Project = project
+		events = append(events, linked)
+	}
+	for _, cascaded := range bump.Cascaded {
+		events = append(events, EventsOf(tenant, cascaded, at)...)
+	}
+
+	return events
+}
+
+// EventFilter selects the events of a tenant, optionally only those of a project or of the projects within a namespace
+type EventFilter struct {
+	Tenant    string
+	Project   string
+	Namespace string
+}
+
+// Matches checks if the filter selects the given event
+func (filter EventFilter) Matches(event Event) bool {
+	if event.Tenant != filter.Tenant {
+		return false
+	}
+
+	return (filter.Project == "" || event.Project == filter.Project) && InNamespace(event.Project, filter.Namespace)
+}
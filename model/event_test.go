@@ -0,0 +1,20 @@
+package model
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestEventFilter(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	event := Event{Tenant: DefaultTenant, Project: "team/service"}
+
+	Ω.Expect(EventFilter{Tenant: DefaultTenant}.Matches(event)).To(BeTrue())
+	Ω.Expect(EventFilter{Tenant: DefaultTenant, Project: "team/service"}.Matches(event)).To(BeTrue())
+	Ω.Expect(EventFilter{Tenant: DefaultTenant, Namespace: "team"}.Matches(event)).To(BeTrue())
+	Ω.Expect(EventFilter{Tenant: DefaultTenant, Project: "team"}.Matches(event)).To(BeFalse())
+	Ω.Expect(EventFilter{Tenant: DefaultTenant, Namespace: "team/service"}.Matches(event)).To(BeFalse())
+	Ω.Expect(EventFilter{Tenant: "team-a"}.Matches(event)).To(BeFalse())
+}
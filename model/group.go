@@ -0,0 +1,8 @@
+package model
+
+// Group links projects sharing exactly one version, bumping or setting any member changing all of them
+type Group struct {
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+	Version Version  `json:"version"`
+}
@@ -0,0 +1,168 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// HistoryEntry records a version change of a project at a point in time
+type HistoryEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Action    string    `json:"action"`
+	Version   Version   `json:"version"`
+	Ref       string    `json:"ref,omitempty"`
+}
+
+// Prefixes of the history actions of bumps of branch streams and of release lines, which do not change the project's
+// version
+const (
+	branchActionPrefix = "branch-"
+	lineActionPrefix   = "line-"
+)
+
+// BranchAction is the history action of bumping the given part of a branch stream
+func BranchAction(part string) string {
+	return branchActionPrefix + part
+}
+
+// LineAction is the history action of bumping the given part within a release line without changing the project's
+// version
+func LineAction(part string) string {
+	return lineActionPrefix + part
+}
+
+// OfBranch checks if the entry records a bump of a branch stream rather than a version of the project
+func (entry HistoryEntry) OfBranch() bool {
+	return strings.HasPrefix(entry.Action, branchActionPrefix)
+}
+
+// OfLine checks if the entry records a bump within a release line which did not change the project's version
+func (entry HistoryEntry) OfLine() bool {
+	return strings.HasPrefix(entry.Action, lineActionPrefix)
+}
+
+// MainLine returns the entries of a history recording versions of the project itself
+func MainLine(history []HistoryEntry) []HistoryEntry {
+	mainLine := make([]HistoryEntry, 0, len(history))
+	for _, entry := range history {
+		if !entry.OfBranch() && !entry.OfLine() {
+			mainLine = append(mainLine, entry)
+		}
+	}
+
+	return mainLine
+}
+
+var refPattern = regexp.MustCompile(`^[^\s]{1,256}$`)
+
+// ValidateRef checks that a source reference like a commit SHA or build ID is a single word of at most 256 characters
+func ValidateRef(ref string) error {
+	if !refPattern.MatchString(ref) {
+		return errors.Errorf("%v is not a valid source reference", ref)
+	}
+
+	return nil
+}
+
+// String returns the history entry's single line representation, ending with the source reference if there is one
+func (entry HistoryEntry) String() string {
+	line := fmt.Sprintf("%s %s %s", entry.Timestamp.UTC().Format(time.RFC3339Nano), entry.Action, entry.Version.String())
+	if entry.Ref != "" {
+		line += " " + entry.Ref
+	}
+
+	return line
+}
+
+// FromHistoryString constructs a history entry from its single line representation
+func FromHistoryString(entryString string) (entry HistoryEntry, err error) {
+	fields := strings.Fields(entryString)
+	if len(fields) != 3 && len(fields) != 4 {
+		return entry, errors.Errorf("%v is not a valid history entry", entryString)
+	}
+
+	entry.Timestamp, err = time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return entry, errors.Wrapf(err, "Failed to parse timestamp of history entry %v", entryString)
+	}
+
+	entry.Action = fields[1]
+	entry.Version, err = FromVersionString(fields[2])
+	if err != nil {
+		return entry, errors.Wrapf(err, "Failed to parse version of history entry %v", entryString)
+	}
+
+	if len(fields) == 4 {
+		entry.Ref = fields[3]
+	}
+
+	return
+}
+
+// VersionOfRef returns the version recorded with the given source reference, the latest one if there are several
+func VersionOfRef(history []HistoryEntry, ref string) (version Version, found bool) {
+	_, version, found = BumpOfRef(history, ref)
+	return
+}
+
+// BumpOfRef returns the version recorded with the given source reference, the latest one if there are several, and
+// the version of the project recorded right before it, which is the zero version if there is none
+func BumpOfRef(history []HistoryEntry, ref string) (previous Version, version Version, found bool) {
+	history = MainLine(history)
+	for index, entry := range history {
+		if entry.Ref != ref {
+			continue
+		}
+		previous = Version{}
+		if index > 0 {
+			previous = history[index-1].Version
+		}
+		version = entry.Version
+		found = true
+	}
+
+	return
+}
+
+// VersionAt returns the latest version of the project recorded at or before the given time
+func VersionAt(history []HistoryEntry, at time.Time) (version Version, found bool) {
+	var recordedAt time.Time
+
+	for _, entry := range MainLine(history) {
+		if entry.Timestamp.After(at) || (found && entry.Timestamp.Before(recordedAt)) {
+			continue
+		}
+		version = entry.Version
+		recordedAt = entry.Timestamp
+		found = true
+	}
+
+	return
+}
+
+// MergeHistories combines histories into one chronologically sorted history without duplicate entries
+func MergeHistories(histories ...[]HistoryEntry) []HistoryEntry {
+	seen := make(map[string]bool)
+	merged := make([]HistoryEntry, 0)
+
+	for _, history := range histories {
+		for _, entry := range history {
+			if seen[entry.String()] {
+				continue
+			}
+			seen[entry.String()] = true
+			merged = append(merged, entry)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Timestamp.Before(merged[j].Timestamp)
+	})
+
+	return merged
+}
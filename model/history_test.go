@@ -0,0 +1,95 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestHistoryEntryRoundTrip(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	entry := HistoryEntry{Timestamp: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), Action: "patch", Version: NewVersion(1, 0, 1)}
+	actual, err := FromHistoryString(entry.String())
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(actual).To(Equal(entry))
+}
+
+func TestHistoryEntryWithInvalidString(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	_, err := FromHistoryString("2026-03-01T12:00:00Z 1.0.1")
+
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func TestVersionAt(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	history := []HistoryEntry{
+		{Timestamp: base, Action: "set", Version: NewVersion(1, 0, 0)},
+		{Timestamp: base.Add(time.Hour), Action: "minor", Version: NewVersion(1, 1, 0)},
+	}
+
+	_, found := VersionAt(history, base.Add(-time.Second))
+	Ω.Expect(found).To(BeFalse())
+
+	actual, found := VersionAt(history, base.Add(30*time.Minute))
+	Ω.Expect(found).To(BeTrue())
+	Ω.Expect(actual.String()).To(Equal("1.0.0"))
+
+	actual, _ = VersionAt(history, base.Add(time.Hour))
+	Ω.Expect(actual.String()).To(Equal("1.1.0"))
+
+	history = append(history,
+		HistoryEntry{Timestamp: base.Add(2 * time.Hour), Action: LineAction("patch"), Version: NewVersion(1, 0, 1)},
+		HistoryEntry{Timestamp: base.Add(3 * time.Hour), Action: BranchAction("minor"), Version: NewVersion(1, 2, 0).WithPrerelease("x.0")})
+	actual, _ = VersionAt(history, base.Add(3*time.Hour))
+	Ω.Expect(actual.String()).To(Equal("1.1.0"))
+}
+
+func TestHistoryEntryWithRefRoundTrip(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	entry := HistoryEntry{Timestamp: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), Action: "patch", Version: NewVersion(1, 0, 1), Ref: "3f2c1a9"}
+	actual, err := FromHistoryString(entry.String())
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(actual).To(Equal(entry))
+}
+
+func TestVersionOfRef(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	history := []HistoryEntry{
+		{Timestamp: base, Action: "set", Version: NewVersion(1, 0, 0)},
+		{Timestamp: base.Add(time.Hour), Action: "minor", Version: NewVersion(1, 1, 0), Ref: "abc"},
+		{Timestamp: base.Add(90 * time.Minute), Action: LineAction("patch"), Version: NewVersion(1, 0, 1)},
+		{Timestamp: base.Add(2 * time.Hour), Action: "patch", Version: NewVersion(1, 1, 1), Ref: "def"},
+	}
+
+	actual, found := VersionOfRef(history, "abc")
+	Ω.Expect(found).To(BeTrue())
+	Ω.Expect(actual.String()).To(Equal("1.1.0"))
+
+	_, found = VersionOfRef(history, "xyz")
+	Ω.Expect(found).To(BeFalse())
+
+	previous, actual, found := BumpOfRef(history, "def")
+	Ω.Expect(found).To(BeTrue())
+	Ω.Expect(previous.String()).To(Equal("1.1.0"))
+	Ω.Expect(actual.String()).To(Equal("1.1.1"))
+}
+
+func TestValidateRef(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(ValidateRef("3f2c1a9e")).To(Succeed())
+	Ω.Expect(ValidateRef("build-42/1")).To(Succeed())
+	Ω.Expect(ValidateRef("")).NotTo(Succeed())
+	Ω.Expect(ValidateRef("two words")).NotTo(Succeed())
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// IdempotentResponse is the recorded response to the first request sent with an idempotency key, replayed to
+// retries of that request
+type IdempotentResponse struct {
+	Request     string              `json:"request"`
+	Status      int                 `json:"status"`
+	ContentType string              `json:"contentType,omitempty"`
+	Headers     map[string][]string `json:"headers,omitempty"`
+	Body        string              `json:"body"`
+	Created     time.Time           `json:"created"`
+}
+
+// Expired checks if the response was recorded longer than the given window ago
+func (response IdempotentResponse) Expired(window time.Duration, now time.Time) bool {
+	return response.Created.Add(window).Before(now)
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+var linePattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// ValidateLine checks that a release line is keyed by a major like 1 or a major and minor like 1.8
+func ValidateLine(line string) error {
+	if !linePattern.MatchString(line) {
+		return errors.Errorf("%v is not a valid release line", line)
+	}
+
+	return nil
+}
+
+// InLine checks if a version belongs to the given release line
+func InLine(version Version, line string) bool {
+	key, err := FromVersionString(line)
+	if err != nil || key.major.number != version.major.number {
+		return false
+	}
+
+	return !key.minor.isPresent || key.minor.number == version.minor.number
+}
+
+// LineOf returns the release line keyed by the major of the given version
+func LineOf(version Version) string {
+	return strconv.Itoa(version.major.number)
+}
+
+// LatestInLine returns the highest of the given versions belonging to the given release line
+func LatestInLine(line string, versions ...Version) (latest Version, found bool) {
+	for _, version := range versions {
+		if InLine(version, line) && (!found || version.Compare(latest) > 0) {
+			latest = version
+			found = true
+		}
+	}
+
+	return
+}
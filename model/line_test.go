@@ -0,0 +1,28 @@
+package model
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestReleaseLines(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(ValidateLine("1")).To(Succeed())
+	Ω.Expect(ValidateLine("1.8")).To(Succeed())
+	Ω.Expect(ValidateLine("1.8.4")).NotTo(Succeed())
+	Ω.Expect(ValidateLine("x")).NotTo(Succeed())
+
+	Ω.Expect(InLine(NewVersion(1, 8, 4), "1")).To(BeTrue())
+	Ω.Expect(InLine(NewVersion(1, 8, 4), "1.8")).To(BeTrue())
+	Ω.Expect(InLine(NewVersion(1, 9, 0), "1.8")).To(BeFalse())
+	Ω.Expect(InLine(NewVersion(2, 0, 0), "1")).To(BeFalse())
+	Ω.Expect(LineOf(NewVersion(2, 1, 0))).To(Equal("2"))
+
+	latest, found := LatestInLine("1", NewVersion(1, 8, 3), NewVersion(2, 0, 0), NewVersion(1, 8, 4), NewVersion(1, 2, 0))
+	Ω.Expect(found).To(BeTrue())
+	Ω.Expect(latest).To(Equal(NewVersion(1, 8, 4)))
+	_, found = LatestInLine("3", NewVersion(1, 8, 3))
+	Ω.Expect(found).To(BeFalse())
+}
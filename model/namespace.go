@@ -0,0 +1,66 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// NamespaceSeparator separates the namespaces of a hierarchical project name like team/service/component
+const NamespaceSeparator = "/"
+
+// Namespace groups projects and holds settings inherited by all projects within
+type Namespace struct {
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+// NormalizeName removes leading and trailing separators from a project or namespace name
+func NormalizeName(name string) string {
+	return strings.Trim(name, NamespaceSeparator)
+}
+
+// ValidateName checks that a project or namespace name consists of non-empty segments which are not hidden
+func ValidateName(name string) error {
+	if name == "" {
+		return errors.New("Name must not be empty")
+	}
+
+	for _, segment := range strings.Split(name, NamespaceSeparator) {
+		if segment == "" || strings.HasPrefix(segment, ".") || strings.ContainsAny(segment, "\\\x00") {
+			return errors.Errorf("%v is not a valid name", name)
+		}
+	}
+
+	return nil
+}
+
+// ParentNamespaces returns all namespaces containing the given project or namespace, starting with the
+// root namespace "" and ending with the direct parent
+func ParentNamespaces(name string) []string {
+	segments := strings.Split(name, NamespaceSeparator)
+	namespaces := make([]string, 0, len(segments))
+
+	for i := range segments {
+		namespaces = append(namespaces, strings.Join(segments[:i], NamespaceSeparator))
+	}
+
+	return namespaces
+}
+
+// InNamespace checks if a project lies within the given namespace or one of its children
+func InNamespace(project string, namespace string) bool {
+	return namespace == "" || strings.HasPrefix(project, namespace+NamespaceSeparator)
+}
+
+// InheritSettings combines settings from the root namespace down to a project, later ones overriding earlier ones
+func InheritSettings(settings ...map[string]string) map[string]string {
+	inherited := make(map[string]string)
+
+	for _, layer := range settings {
+		for key, value := range layer {
+			inherited[key] = value
+		}
+	}
+
+	return inherited
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestValidateName(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(ValidateName("team/service/component")).To(BeNil())
+	Ω.Expect(ValidateName("p1")).To(BeNil())
+	Ω.Expect(ValidateName("")).NotTo(BeNil())
+	Ω.Expect(ValidateName("team//component")).NotTo(BeNil())
+	Ω.Expect(ValidateName("team/../component")).NotTo(BeNil())
+	Ω.Expect(ValidateName(".history/p1")).NotTo(BeNil())
+}
+
+func TestParentNamespaces(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(ParentNamespaces("team/service/component")).To(Equal([]string{"", "team", "team/service"}))
+	Ω.Expect(ParentNamespaces("p1")).To(Equal([]string{""}))
+}
+
+func TestInNamespace(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(InNamespace("team/service", "team")).To(BeTrue())
+	Ω.Expect(InNamespace("team/service", "")).To(BeTrue())
+	Ω.Expect(InNamespace("teams/service", "team")).To(BeFalse())
+}
+
+func TestInheritSettings(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	actual := InheritSettings(map[string]string{"a": "root", "b": "root"}, nil, map[string]string{"b": "project"})
+
+	Ω.Expect(actual).To(Equal(map[string]string{"a": "root", "b": "project"}))
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// AnyRevision matches the revision of every existing project in preconditions
+const AnyRevision = "*"
+
+// Revision identifies the state of a project, which changes with every modification of the project
+func (project Project) Revision() string {
+	sum := sha256.Sum256([]byte(project.Version.String() + " " + project.Metadata.Modified.UTC().Format(time.RFC3339Nano)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Revision identifies the own settings of a namespace, which changes whenever they change
+func (namespace Namespace) Revision() string {
+	data, _ := json.Marshal(namespace.Settings)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Revision identifies the members and the shared version of a group, which changes whenever they change
+func (group Group) Revision() string {
+	data, _ := json.Marshal(group)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Precondition restricts changes to projects in an expected state, following the HTTP headers If-Match and
+// If-None-Match. Empty lists do not restrict anything.
+type Precondition struct {
+	IfMatch     []string
+	IfNoneMatch []string
+}
+
+// Holds checks if the precondition is met by a project, which exists or is about to be created
+func (precondition Precondition) Holds(project Project, exists bool) bool {
+	revision := ""
+	if exists {
+		revision = project.Revision()
+	}
+
+	return precondition.HoldsRevision(revision, exists)
+}
+
+// HoldsRevision checks if the precondition is met by the given revision of something which exists or is about to be
+// created
+func (precondition Precondition) HoldsRevision(revision string, exists bool) bool {
+	if len(precondition.IfMatch) > 0 && !(exists && matchesRevision(precondition.IfMatch, revision)) {
+		return false
+	}
+	if len(precondition.IfNoneMatch) > 0 && exists && matchesRevision(precondition.IfNoneMatch, revision) {
+		return false
+	}
+
+	return true
+}
+
+func matchesRevision(revisions []string, revision string) bool {
+	for _, candidate := range revisions {
+		if candidate == AnyRevision || candidate == revision {
+			return true
+		}
+	}
+
+	return false
+}
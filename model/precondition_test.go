@@ -0,0 +1,49 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRevisionChangesWithModification(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	project := Project{Version: NewVersion(1, 0, 0), Metadata: Metadata{}.Touch("", at)}
+	modified := Project{Version: NewVersion(1, 0, 0), Metadata: project.Metadata.Touch("", at.Add(time.Second))}
+
+	Ω.Expect(project.Revision()).To(Equal(project.Revision()))
+	Ω.Expect(modified.Revision()).NotTo(Equal(project.Revision()))
+}
+
+func TestPreconditionHolds(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	project := Project{Version: NewVersion(1, 0, 0)}
+	revision := project.Revision()
+
+	Ω.Expect(Precondition{}.Holds(project, true)).To(BeTrue())
+	Ω.Expect(Precondition{IfMatch: []string{revision}}.Holds(project, true)).To(BeTrue())
+	Ω.Expect(Precondition{IfMatch: []string{"other", revision}}.Holds(project, true)).To(BeTrue())
+	Ω.Expect(Precondition{IfMatch: []string{"other"}}.Holds(project, true)).To(BeFalse())
+	Ω.Expect(Precondition{IfMatch: []string{AnyRevision}}.Holds(project, true)).To(BeTrue())
+	Ω.Expect(Precondition{IfMatch: []string{AnyRevision}}.Holds(Project{}, false)).To(BeFalse())
+	Ω.Expect(Precondition{IfNoneMatch: []string{AnyRevision}}.Holds(Project{}, false)).To(BeTrue())
+	Ω.Expect(Precondition{IfNoneMatch: []string{AnyRevision}}.Holds(project, true)).To(BeFalse())
+	Ω.Expect(Precondition{IfNoneMatch: []string{revision}}.Holds(project, true)).To(BeFalse())
+	Ω.Expect(Precondition{IfNoneMatch: []string{"other"}}.Holds(project, true)).To(BeTrue())
+}
+
+func TestNamespaceRevisionChangesWithSettings(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	namespace := Namespace{Settings: map[string]string{"owner": "team-a", "scheme": "semver"}}
+	reordered := Namespace{Settings: map[string]string{"scheme": "semver", "owner": "team-a"}}
+	changed := Namespace{Settings: map[string]string{"owner": "team-b", "scheme": "semver"}}
+
+	Ω.Expect(reordered.Revision()).To(Equal(namespace.Revision()))
+	Ω.Expect(changed.Revision()).NotTo(Equal(namespace.Revision()))
+	Ω.Expect(Precondition{IfMatch: []string{namespace.Revision()}}.HoldsRevision(changed.Revision(), true)).To(BeFalse())
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+)
+
+// DefaultScheme is the versioning scheme of projects which do not state one
+const DefaultScheme = "semver"
+
+// Project represents a project's version together with its metadata
+type Project struct {
+	Version  Version
+	Metadata Metadata
+}
+
+// Metadata describes how and when a project's version was maintained
+type Metadata struct {
+	Scheme       string             `json:"scheme"`
+	Created      time.Time          `json:"created"`
+	Modified     time.Time          `json:"modified"`
+	ModifiedBy   string             `json:"modifiedBy,omitempty"`
+	Settings     map[string]string  `json:"settings,omitempty"`
+	Dependencies []string           `json:"dependencies,omitempty"`
+	Group        string             `json:"group,omitempty"`
+	Branches     map[string]Version `json:"branches,omitempty"`
+	BranchNames  map[string]string  `json:"branchNames,omitempty"`
+	Lines        map[string]Version `json:"lines,omitempty"`
+	Tags         map[string]Version `json:"tags,omitempty"`
+}
+
+// Touch marks the metadata as modified by the given modifier at the given time
+func (metadata Metadata) Touch(modifier string, at time.Time) Metadata {
+	if metadata.Scheme == "" {
+		metadata.Scheme = DefaultScheme
+	}
+	if metadata.Created.IsZero() {
+		metadata.Created = at
+	}
+	metadata.Modified = at
+	metadata.ModifiedBy = modifier
+	return metadata
+}
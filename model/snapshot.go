@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+)
+
+// SnapshotFormat is the format of snapshots written by this version of vbump
+const SnapshotFormat = 2
+
+// Snapshot contains all projects of a storage with their metadata and history and the settings of its namespaces,
+// which are part of snapshots since format 2
+type Snapshot struct {
+	Format     int                 `json:"format"`
+	Created    time.Time           `json:"created"`
+	Projects   []ProjectSnapshot   `json:"projects"`
+	Namespaces []NamespaceSnapshot `json:"namespaces,omitempty"`
+}
+
+// ProjectSnapshot contains a single project with its metadata and history
+type ProjectSnapshot struct {
+	Name     string         `json:"name"`
+	Version  Version        `json:"version"`
+	Metadata Metadata       `json:"metadata"`
+	History  []HistoryEntry `json:"history"`
+}
+
+// NamespaceSnapshot contains the own settings of a single namespace, the root namespace being named ""
+type NamespaceSnapshot struct {
+	Name     string            `json:"name"`
+	Settings map[string]string `json:"settings"`
+}
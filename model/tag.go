@@ -0,0 +1,19 @@
+package model
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+var tagPattern = regexp.MustCompile(`^[A-Za-z][0-9A-Za-z._-]{0,63}$`)
+
+// ValidateTag checks that a distribution tag like stable or next starts with a letter and consists of at most 64
+// letters, digits, dots, underscores and dashes, so it cannot be mistaken for a version
+func ValidateTag(tag string) error {
+	if !tagPattern.MatchString(tag) {
+		return errors.Errorf("%v is not a valid tag", tag)
+	}
+
+	return nil
+}
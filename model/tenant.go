@@ -0,0 +1,27 @@
+package model
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultTenant is the tenant of requests not bound to any other tenant
+const DefaultTenant = "default"
+
+// Tenant is an isolated set of projects with its own quota
+type Tenant struct {
+	Name        string `json:"name"`
+	MaxProjects int    `json:"maxProjects"`
+}
+
+var tenantNamePattern = regexp.MustCompile("^[a-z0-9]([a-z0-9-]*[a-z0-9])?$")
+
+// ValidateTenantName checks that a tenant name consists of lower case letters, digits and inner dashes
+func ValidateTenantName(name string) error {
+	if !tenantNamePattern.MatchString(name) {
+		return errors.Errorf("%v is not a valid tenant name", name)
+	}
+
+	return nil
+}
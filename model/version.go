@@ -6,13 +6,21 @@ import (
 	"strings"
 )
 
-const separator = "."
+const (
+	separator           = "."
+	prereleaseSeparator = "-"
+	// DefaultPrerelease is the identifier of pre-releases of released versions
+	DefaultPrerelease = "rc"
+)
+
+var versionPattern = regexp.MustCompile(`^([0-9]+)(\.[0-9]+)?(\.[0-9]+)?$|^[0-9]+\.[0-9]+\.[0-9]+-[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*$`)
 
-// Version represents a version consisting of a major, minor and patch part
+// Version represents a version consisting of a major, minor and patch part and optionally a pre-release like rc.1
 type Version struct {
-	major versionPart
-	minor versionPart
-	patch versionPart
+	major      versionPart
+	minor      versionPart
+	patch      versionPart
+	prerelease string
 }
 
 type versionPart struct {
@@ -23,14 +31,18 @@ type versionPart struct {
 // NewVersion constructs a new version
 func NewVersion(major int, minor int, patch int) Version {
 	return Version{
-		versionPart{major, true},
-		versionPart{minor, true},
-		versionPart{patch, true},
+		major: versionPart{major, true},
+		minor: versionPart{minor, true},
+		patch: versionPart{patch, true},
 	}
 }
 
 // FromVersionString constructs a new version from a given version string
 func FromVersionString(versionString string) (version Version, err error) {
+	if index := strings.Index(versionString, prereleaseSeparator); index >= 0 {
+		version.prerelease = versionString[index+1:]
+		versionString = versionString[:index]
+	}
 	versionParts := strings.Split(versionString, separator)
 	var major, minor, patch int
 
@@ -63,7 +75,7 @@ func FromVersionString(versionString string) (version Version, err error) {
 
 // ValidateVersionString checks if a given version string conforms to our version syntax
 func ValidateVersionString(versionString string) bool {
-	return regexp.MustCompile("^([0-9]+)(\\" + separator + "[0-9]+)?(\\" + separator + "[0-9]+)?$").MatchString(versionString)
+	return versionPattern.MatchString(versionString)
 }
 
 // String returns the version's string representation
@@ -82,7 +94,39 @@ func (version Version) String() string {
 		versionParts = append(versionParts, strconv.Itoa(version.patch.number))
 	}
 
-	return strings.Join(versionParts, separator)
+	versionString := strings.Join(versionParts, separator)
+	if version.prerelease != "" {
+		versionString += prereleaseSeparator + version.prerelease
+	}
+
+	return versionString
+}
+
+// Prerelease returns the version's pre-release, empty for released versions
+func (version Version) Prerelease() string {
+	return version.prerelease
+}
+
+// WithPrerelease returns the version with the given pre-release
+func (version Version) WithPrerelease(prerelease string) Version {
+	version.prerelease = prerelease
+	return version
+}
+
+// MarshalText returns the version's string representation as text
+func (version Version) MarshalText() ([]byte, error) {
+	return []byte(version.String()), nil
+}
+
+// UnmarshalText parses a version from its text representation, an empty text being an empty version
+func (version *Version) UnmarshalText(text []byte) (err error) {
+	if len(text) == 0 {
+		*version = Version{}
+		return nil
+	}
+
+	*version, err = FromVersionString(string(text))
+	return err
 }
 
 func (part *versionPart) makePresent() {
@@ -98,26 +142,108 @@ func (part *versionPart) reset() {
 	part.number = 0
 }
 
-// BumpMajor bumps the version's major part
+// BumpMajor bumps the version's major part, releasing a pre-release of a major version instead
 func (version Version) BumpMajor() Version {
+	if version.prerelease != "" && version.minor.number == 0 && version.patch.number == 0 {
+		version.prerelease = ""
+		return version
+	}
+	version.prerelease = ""
 	version.major.increment()
 	version.minor.reset()
 	version.patch.reset()
 	return version
 }
 
-// BumpMinor bumps the version's minor part
+// BumpMinor bumps the version's minor part, releasing a pre-release of a minor version instead
 func (version Version) BumpMinor() Version {
+	if version.prerelease != "" && version.patch.number == 0 {
+		version.prerelease = ""
+		return version
+	}
+	version.prerelease = ""
 	version.major.makePresent()
 	version.minor.increment()
 	version.patch.reset()
 	return version
 }
 
-// BumpPatch bumps the version's patch part
+// BumpPatch bumps the version's patch part, releasing a pre-release instead
 func (version Version) BumpPatch() Version {
+	if version.prerelease != "" {
+		version.prerelease = ""
+		return version
+	}
 	version.major.makePresent()
 	version.minor.makePresent()
 	version.patch.increment()
 	return version
 }
+
+// BumpPrerelease increments the number ending the version's pre-release or, for a released version, bumps the patch
+// part and starts its pre-release rc.0
+func (version Version) BumpPrerelease() Version {
+	if version.prerelease == "" {
+		version = version.BumpPatch()
+		version.prerelease = DefaultPrerelease + separator + "0"
+		return version
+	}
+
+	identifiers := strings.Split(version.prerelease, separator)
+	last := len(identifiers) - 1
+	if number, err := strconv.Atoi(identifiers[last]); err == nil {
+		identifiers[last] = strconv.Itoa(number + 1)
+	} else {
+		identifiers = append(identifiers, "0")
+	}
+	version.prerelease = strings.Join(identifiers, separator)
+
+	return version
+}
+
+// Compare orders versions by semver precedence, returning a negative number if the version precedes the other one,
+// a positive number if it follows it and 0 if both are equal. Missing parts count as 0 and a pre-release precedes
+// its release.
+func (version Version) Compare(other Version) int {
+	for _, difference := range []int{
+		version.major.number - other.major.number,
+		version.minor.number - other.minor.number,
+		version.patch.number - other.patch.number,
+	} {
+		if difference != 0 {
+			return difference
+		}
+	}
+
+	if version.prerelease == other.prerelease {
+		return 0
+	}
+	if version.prerelease == "" {
+		return 1
+	}
+	if other.prerelease == "" {
+		return -1
+	}
+
+	return comparePrereleases(strings.Split(version.prerelease, separator), strings.Split(other.prerelease, separator))
+}
+
+// comparePrereleases compares pre-release identifiers, numeric identifiers numerically and preceding alphanumeric ones
+func comparePrereleases(identifiers []string, others []string) int {
+	for index := 0; index < len(identifiers) && index < len(others); index++ {
+		number, err := strconv.Atoi(identifiers[index])
+		otherNumber, otherErr := strconv.Atoi(others[index])
+		switch {
+		case err == nil && otherErr == nil && number != otherNumber:
+			return number - otherNumber
+		case err == nil && otherErr != nil:
+			return -1
+		case err != nil && otherErr == nil:
+			return 1
+		case err != nil && otherErr != nil && identifiers[index] != others[index]:
+			return strings.Compare(identifiers[index], others[index])
+		}
+	}
+
+	return len(identifiers) - len(others)
+}
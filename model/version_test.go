@@ -149,3 +149,71 @@ func TestBumbPatchOnEmptyVersion(t *testing.T) {
 
 	Ω.Expect(actual.String()).To(Equal("0.0.1"))
 }
+
+func TestVersionTextRoundTrip(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	text, _ := NewVersion(1, 2, 3).MarshalText()
+	var actual Version
+	err := actual.UnmarshalText(text)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(actual).To(Equal(NewVersion(1, 2, 3)))
+}
+
+func TestPrereleaseRoundTrip(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	version, err := FromVersionString("1.4.0-feature-x.3")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(version.Prerelease()).To(Equal("feature-x.3"))
+	Ω.Expect(version.String()).To(Equal("1.4.0-feature-x.3"))
+	Ω.Expect(ValidateVersionString("1.4.0-feature-x.3")).To(BeTrue())
+	Ω.Expect(ValidateVersionString("1.4-rc.1")).To(BeFalse())
+	Ω.Expect(ValidateVersionString("1.4.0-")).To(BeFalse())
+	Ω.Expect(ValidateVersionString("1.4.0-rc..1")).To(BeFalse())
+}
+
+func TestBumpPrerelease(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	version := NewVersion(1, 0, 0).BumpPrerelease()
+	Ω.Expect(version.String()).To(Equal("1.0.1-rc.0"))
+
+	version = version.BumpPrerelease()
+	Ω.Expect(version.String()).To(Equal("1.0.1-rc.1"))
+
+	beta, _ := FromVersionString("2.0.0-beta")
+	Ω.Expect(beta.BumpPrerelease().String()).To(Equal("2.0.0-beta.0"))
+}
+
+func TestBumpReleasesPrerelease(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	patch, _ := FromVersionString("1.0.1-rc.1")
+	minor, _ := FromVersionString("1.1.0-rc.1")
+	major, _ := FromVersionString("2.0.0-rc.1")
+
+	Ω.Expect(patch.BumpPatch().String()).To(Equal("1.0.1"))
+	Ω.Expect(patch.BumpMinor().String()).To(Equal("1.1.0"))
+	Ω.Expect(patch.BumpMajor().String()).To(Equal("2.0.0"))
+	Ω.Expect(minor.BumpMinor().String()).To(Equal("1.1.0"))
+	Ω.Expect(minor.BumpMajor().String()).To(Equal("2.0.0"))
+	Ω.Expect(major.BumpMajor().String()).To(Equal("2.0.0"))
+}
+
+func TestCompareVersions(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1", "2"}
+	for index := 1; index < len(ordered); index++ {
+		lower, _ := FromVersionString(ordered[index-1])
+		higher, _ := FromVersionString(ordered[index])
+		Ω.Expect(lower.Compare(higher)).To(BeNumerically("<", 0), ordered[index])
+		Ω.Expect(higher.Compare(lower)).To(BeNumerically(">", 0), ordered[index])
+	}
+
+	short, _ := FromVersionString("1.2")
+	Ω.Expect(short.Compare(NewVersion(1, 2, 0))).To(Equal(0))
+}
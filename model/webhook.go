@@ -0,0 +1,80 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Webhook subscribes a URL to the events of a project, of all projects within a namespace or of all projects of a
+// tenant
+type Webhook struct {
+	ID      string `json:"id"`
+	URL     string `json:"url"`
+	Secret  string `json:"secret,omitempty"`
+	Project string `json:"project,omitempty"`
+	Tenant  string `json:"tenant,omitempty"`
+}
+
+// Delivery is an event waiting to be delivered to a webhook, which is referenced by its ID and looked up when sending,
+// so its secret is only kept with the webhook
+type Delivery struct {
+	ID          string    `json:"id"`
+	Webhook     string    `json:"webhook"`
+	Event       Event     `json:"event"`
+	Queued      time.Time `json:"queued"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"nextAttempt"`
+	LastError   string    `json:"lastError,omitempty"`
+}
+
+// ValidateWebhook checks that a webhook has an absolute http or https URL, a secret to sign its events and a valid
+// project or namespace, if any
+func ValidateWebhook(webhook Webhook) error {
+	parsed, err := url.Parse(webhook.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.Errorf("%v is not a valid webhook URL", webhook.URL)
+	}
+	if webhook.Secret == "" {
+		return errors.New("A webhook needs a secret to sign its events")
+	}
+	if webhook.Project != "" {
+		return ValidateName(webhook.Project)
+	}
+
+	return nil
+}
+
+// Matches checks if the webhook subscribes to the given event
+func (webhook Webhook) Matches(event Event) bool {
+	if webhook.Tenant != event.Tenant {
+		return false
+	}
+
+	return webhook.Project == "" || webhook.Project == event.Project || InNamespace(event.Project, webhook.Project)
+}
+
+// Signature signs a payload with the given secret as sha256=<hex encoded HMAC-SHA256>
+func Signature(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// RetryDelay returns the delay before retrying a delivery after the given number of failed attempts, doubling the
+// initial delay with every attempt up to the maximum delay
+func RetryDelay(attempts int, initial time.Duration, max time.Duration) time.Duration {
+	delay := initial
+	for attempt := 1; attempt < attempts && delay < max; attempt++ {
+		delay *= 2
+	}
+	if delay > max {
+		return max
+	}
+
+	return delay
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestWebhookMatches(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	event := Event{Tenant: DefaultTenant, Project: "team/app"}
+
+	Ω.Expect(Webhook{Tenant: DefaultTenant}.Matches(event)).To(BeTrue())
+	Ω.Expect(Webhook{Tenant: DefaultTenant, Project: "team"}.Matches(event)).To(BeTrue())
+	Ω.Expect(Webhook{Tenant: DefaultTenant, Project: "team/app"}.Matches(event)).To(BeTrue())
+	Ω.Expect(Webhook{Tenant: DefaultTenant, Project: "team/other"}.Matches(event)).To(BeFalse())
+	Ω.Expect(Webhook{Tenant: "team-a"}.Matches(event)).To(BeFalse())
+}
+
+func TestValidateWebhook(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(ValidateWebhook(Webhook{URL: "https://dashboard.example.com/hooks", Secret: "s3cret"})).To(Succeed())
+	Ω.Expect(ValidateWebhook(Webhook{URL: "https://dashboard.example.com/hooks"})).NotTo(Succeed())
+	Ω.Expect(ValidateWebhook(Webhook{URL: "ftp://example.com", Secret: "s3cret"})).NotTo(Succeed())
+	Ω.Expect(ValidateWebhook(Webhook{URL: "/hooks", Secret: "s3cret"})).NotTo(Succeed())
+	Ω.Expect(ValidateWebhook(Webhook{URL: "http://example.com", Secret: "s3cret", Project: ".hidden"})).NotTo(Succeed())
+}
+
+func TestSignatureAndRetryDelay(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	Ω.Expect(Signature("secret", []byte("payload"))).To(Equal("sha256=b82fcb791acec57859b989b430a826488ce2e479fdf92326bd0a2e8375a42ba4"))
+
+	Ω.Expect(RetryDelay(1, time.Second, time.Minute)).To(Equal(time.Second))
+	Ω.Expect(RetryDelay(3, time.Second, time.Minute)).To(Equal(4 * time.Second))
+	Ω.Expect(RetryDelay(30, time.Second, time.Minute)).To(Equal(time.Minute))
+}
+
+func TestEventsOf(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	bump := Bump{Project: "lib", Part: PartMinor, Version: NewVersion(1, 1, 0), Linked: []string{"lib-js"},
+		Cascaded: []Bump{{Project: "app", Part: PartPatch, Version: NewVersion(2, 0, 1), CascadedFrom: []string{"lib"}}}}
+
+	events := EventsOf(DefaultTenant, bump, at)
+
+	Ω.Expect(events).To(HaveLen(3))
+	Ω.Expect(events[1].Project).To(Equal("lib-js"))
+	Ω.Expect(events[1].Version).To(Equal(NewVersion(1, 1, 0)))
+	Ω.Expect(events[2].CascadedFrom).To(Equal([]string{"lib"}))
+	Ω.Expect(EventsOf(DefaultTenant, Bump{Project: "lib", Part: OperationSet}, at)[0].Type).To(Equal(EventSet))
+}
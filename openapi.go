@@ -0,0 +1,225 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var pathParameter = regexp.MustCompile(`:([a-zA-Z]+)`)
+
+var openAPISchemas = gin.H{
+	"ProjectVersion": gin.H{
+		"type":     "object",
+		"required": []string{"project", "version"},
+		"properties": gin.H{
+			"project": gin.H{"type": "string"},
+			"version": gin.H{"type": "string"},
+			"at":      gin.H{"type": "string", "format": "date-time"},
+			"ref":     gin.H{"type": "string"},
+			"branch":  gin.H{"type": "string"},
+			"line":    gin.H{"type": "string"},
+			"tag":     gin.H{"type": "string"},
+			"tags":    gin.H{"$ref": "#/components/schemas/Tags"},
+		},
+	},
+	"Tags": gin.H{
+		"type":                 "object",
+		"description":          "Distribution tags like stable or next with the versions they point to",
+		"additionalProperties": gin.H{"type": "string"},
+	},
+	"Line": gin.H{
+		"type":     "object",
+		"required": []string{"line", "version"},
+		"properties": gin.H{
+			"line":    gin.H{"type": "string", "description": "Major like 1 or major and minor like 1.8"},
+			"version": gin.H{"type": "string"},
+		},
+	},
+	"Bump": gin.H{
+		"type":     "object",
+		"required": []string{"part", "previousVersion", "version"},
+		"properties": gin.H{
+			"project":         gin.H{"type": "string"},
+			"part":            gin.H{"type": "string"},
+			"previousVersion": gin.H{"type": "string"},
+			"version":         gin.H{"type": "string"},
+			"ref":             gin.H{"type": "string"},
+			"branch":          gin.H{"type": "string", "description": "Branch whose pre-release stream was bumped"},
+			"line":            gin.H{"type": "string", "description": "Release line bumped within"},
+			"reused":          gin.H{"type": "boolean", "description": "The source reference was bumped before"},
+			"dryRun":          gin.H{"type": "boolean", "description": "The bump was only previewed"},
+			"linked":          gin.H{"type": "array", "items": gin.H{"type": "string"}, "description": "Other members of the project's group bumped to the same version"},
+			"cascadedFrom":    gin.H{"type": "array", "items": gin.H{"type": "string"}, "description": "Dependencies whose bumps cascaded to the project"},
+			"cascaded":        gin.H{"type": "array", "items": gin.H{"$ref": "#/components/schemas/Bump"}, "description": "Bumps cascaded to dependent projects"},
+		},
+	},
+	"BumpRequest": gin.H{
+		"type":     "object",
+		"required": []string{"part"},
+		"properties": gin.H{
+			"part":    gin.H{"type": "string", "enum": []string{"major", "minor", "patch", "prerelease"}},
+			"version": gin.H{"type": "string", "description": "Version to bump transiently"},
+			"ref":     gin.H{"type": "string", "description": "Source reference like a commit SHA, bumped only once per project"},
+			"branch":  gin.H{"type": "string", "description": "Branch like feature/x to bump a pre-release of the next version for"},
+			"line":    gin.H{"type": "string", "description": "Release line like 1 or 1.8 to bump within"},
+		},
+	},
+	"SetVersionRequest": gin.H{
+		"type":     "object",
+		"required": []string{"version"},
+		"properties": gin.H{
+			"version": gin.H{"type": "string"},
+		},
+	},
+	"BatchRequest": gin.H{
+		"type":     "object",
+		"required": []string{"items"},
+		"properties": gin.H{
+			"items": gin.H{
+				"type": "array",
+				"items": gin.H{
+					"type":     "object",
+					"required": []string{"project", "operation"},
+					"properties": gin.H{
+						"project":   gin.H{"type": "string"},
+						"operation": gin.H{"type": "string", "enum": []string{"major", "minor", "patch", "prerelease", "set"}},
+						"version":   gin.H{"type": "string", "description": "Version to set"},
+						"ifMatch":   gin.H{"type": "string", "description": "ETag the project must match, like the If-Match header"},
+					},
+				},
+			},
+		},
+	},
+	"BatchResponse": gin.H{
+		"type":     "object",
+		"required": []string{"bumps"},
+		"properties": gin.H{
+			"bumps": gin.H{"type": "array", "items": gin.H{"$ref": "#/components/schemas/Bump"}},
+		},
+	},
+	"ProjectNames": gin.H{
+		"type":  "array",
+		"items": gin.H{"type": "string"},
+	},
+	"Group": gin.H{
+		"type":     "object",
+		"required": []string{"name", "members", "version"},
+		"properties": gin.H{
+			"name":    gin.H{"type": "string"},
+			"members": gin.H{"type": "array", "items": gin.H{"type": "string"}},
+			"version": gin.H{"type": "string"},
+		},
+	},
+	"Webhook": gin.H{
+		"type":     "object",
+		"required": []string{"url"},
+		"properties": gin.H{
+			"id":      gin.H{"type": "string", "readOnly": true},
+			"url":     gin.H{"type": "string"},
+			"secret":  gin.H{"type": "string", "writeOnly": true, "description": "Signs deliveries as sha256=<HMAC> in X-Vbump-Signature"},
+			"project": gin.H{"type": "string", "description": "Project or namespace to subscribe to, all projects if empty"},
+			"tenant":  gin.H{"type": "string", "readOnly": true},
+		},
+	},
+	"Settings": gin.H{
+		"type":                 "object",
+		"additionalProperties": gin.H{"type": "string"},
+	},
+	"Error": gin.H{
+		"type":     "object",
+		"required": []string{"error"},
+		"properties": gin.H{
+			"error": gin.H{
+				"type":     "object",
+				"required": []string{"code", "message"},
+				"properties": gin.H{
+					"code":    gin.H{"type": "string"},
+					"message": gin.H{"type": "string"},
+				},
+			},
+		},
+	},
+}
+
+// openAPIDocument generates the OpenAPI 3 document describing the given routes of the versioned REST API
+func openAPIDocument(routes []apiRoute) gin.H {
+	paths := gin.H{}
+	for _, route := range routes {
+		path := openAPIPath(route.path)
+		operations, found := paths[path].(gin.H)
+		if !found {
+			operations = gin.H{}
+			paths[path] = operations
+		}
+		operations[strings.ToLower(route.method)] = openAPIOperation(route)
+	}
+
+	return gin.H{
+		"openapi": "3.0.3",
+		"info": gin.H{
+			"title":   "vbump",
+			"version": "1",
+		},
+		"servers":    []gin.H{{"url": apiPrefix}},
+		"paths":      paths,
+		"components": gin.H{"schemas": openAPISchemas},
+	}
+}
+
+// openAPIPath converts a route path like /projects/:project to its OpenAPI form /projects/{project}
+func openAPIPath(path string) string {
+	return pathParameter.ReplaceAllString(path, "{$1}")
+}
+
+func openAPIOperation(route apiRoute) gin.H {
+	parameters := make([]gin.H, 0)
+	for _, match := range pathParameter.FindAllStringSubmatch(route.path, -1) {
+		parameters = append(parameters, gin.H{
+			"name":        match[1],
+			"in":          "path",
+			"required":    true,
+			"description": "Namespaced names have their slashes URL encoded",
+			"schema":      gin.H{"type": "string"},
+		})
+	}
+	for _, name := range route.query {
+		parameters = append(parameters, gin.H{"name": name, "in": "query", "schema": gin.H{"type": "string"}})
+	}
+
+	operation := gin.H{
+		"summary":    route.summary,
+		"parameters": parameters,
+		"responses": gin.H{
+			"200":     openAPIContent("Success", route.response),
+			"default": openAPIContent("Error", "Error"),
+		},
+	}
+	if route.request != "" {
+		body := openAPIContent("", route.request)
+		body["required"] = true
+		delete(body, "description")
+		operation["requestBody"] = body
+	}
+
+	return operation
+}
+
+func openAPIContent(description string, schema string) gin.H {
+	return gin.H{
+		"description": description,
+		"content": gin.H{
+			gin.MIMEJSON: gin.H{"schema": openAPISchema(schema)},
+		},
+	}
+}
+
+// openAPISchema references a schema by name, a name prefixed with [] referencing an array of it
+func openAPISchema(schema string) gin.H {
+	if strings.HasPrefix(schema, "[]") {
+		return gin.H{"type": "array", "items": openAPISchema(strings.TrimPrefix(schema, "[]"))}
+	}
+
+	return gin.H{"$ref": "#/components/schemas/" + schema}
+}
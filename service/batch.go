@@ -0,0 +1,184 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/model"
+)
+
+// projectState is the state of a project before a batch or a cascade changed it
+type projectState struct {
+	project model.Project
+	history []model.HistoryEntry
+	exists  bool
+}
+
+// ApplyBatch applies the changes of all items in order, all or nothing: if one of them fails, the projects changed
+// before are restored to their previous state. All projects the batch affects are locked until it is applied or
+// rolled back, so the rollback cannot undo concurrent changes. It returns one bump per item, setting a version being a
+// bump of the part "set".
+func (vm *VersionManager) ApplyBatch(items []model.BatchItem) ([]model.Bump, error) {
+	if len(items) == 0 {
+		return nil, errors.Wrap(ErrInvalidInput, "Batch contains no items")
+	}
+
+	vm.locks.topology.RLock()
+	defer vm.locks.topology.RUnlock()
+
+	graph, err := vm.dependencyGraph()
+	if err != nil {
+		return nil, err
+	}
+	groups, err := vm.topology.groupMembers(vm.storageProvider)
+	if err != nil {
+		return nil, err
+	}
+	projects := make([]string, 0, len(items))
+	for _, item := range items {
+		linked := withGroupMembers([]string{item.Project}, groups)
+		projects = append(projects, linked...)
+		if item.Operation != model.OperationSet {
+			dependents, err := graph.DependentsInOrder(linked...)
+			if err != nil {
+				return nil, errors.Wrapf(ErrConflict, "Failed to cascade bump of project %v: %v", item.Project, err)
+			}
+			projects = append(projects, withGroupMembers(dependents, groups)...)
+		}
+	}
+	defer vm.locks.lock(projects...)()
+	states, err := vm.readStates(projects)
+	if err != nil {
+		return nil, err
+	}
+
+	bumps := make([]model.Bump, 0, len(items))
+	changed := make([]string, 0, len(items))
+	for index, item := range items {
+		changed = append(changed, item.Project)
+		bump, err := vm.applyItem(item)
+		if err != nil {
+			err = errors.Wrapf(err, "Failed to apply item %v of batch to project %v", index, item.Project)
+			if rollbackErr := vm.rollback(changed, states); rollbackErr != nil {
+				return nil, errors.Wrapf(err, "Failed to roll back batch: %v", rollbackErr)
+			}
+			return nil, err
+		}
+		changed = append(changed, bump.Linked...)
+		changed = append(changed, bumpedProjects(bump.Cascaded)...)
+		bumps = append(bumps, bump)
+	}
+	vm.publish(bumps...)
+
+	return bumps, nil
+}
+
+// readStates reads the state of each of the given projects once
+func (vm *VersionManager) readStates(projects []string) (map[string]projectState, error) {
+	states := make(map[string]projectState, len(projects))
+	for _, project := range projects {
+		if _, found := states[project]; found {
+			continue
+		}
+		state, err := vm.readState(project)
+		if err != nil {
+			return nil, err
+		}
+		states[project] = state
+	}
+
+	return states, nil
+}
+
+// bumpedProjects returns the projects of the given bumps including the projects linked with them
+func bumpedProjects(bumps []model.Bump) []string {
+	projects := make([]string, 0, len(bumps))
+	for _, bump := range bumps {
+		projects = append(projects, bump.Project)
+		projects = append(projects, bump.Linked...)
+	}
+
+	return projects
+}
+
+// withGroupMembers adds the members of the groups of the given projects
+func withGroupMembers(projects []string, groups map[string][]string) []string {
+	extended := append([]string{}, projects...)
+	for _, members := range groups {
+		for _, member := range members {
+			if containsString(projects, member) {
+				extended = append(extended, members...)
+				break
+			}
+		}
+	}
+
+	return extended
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (vm *VersionManager) readState(project string) (projectState, error) {
+	storedProject, err := vm.storageProvider.ReadProject(project)
+	if errors.Cause(err) == ErrNotFound {
+		// a rollback deletes the project including its history
+		return projectState{}, nil
+	}
+	if err != nil {
+		return projectState{}, errors.Wrapf(err, "Failed to read project %v", project)
+	}
+
+	history, err := vm.storageProvider.ReadHistory(project)
+	if err != nil {
+		return projectState{}, errors.Wrapf(err, "Failed to get history for project %v", project)
+	}
+
+	return projectState{project: storedProject, history: history, exists: true}, nil
+}
+
+func (vm *VersionManager) applyItem(item model.BatchItem) (model.Bump, error) {
+	if item.IfMatch != "" {
+		vm = vm.WithPrecondition(model.Precondition{IfMatch: []string{strings.Trim(item.IfMatch, `"`)}})
+	}
+	if item.Operation != model.OperationSet {
+		return vm.bump(item.Project, item.Operation, "", false)
+	}
+
+	return vm.setVersionBump(item.Project, item.Version)
+}
+
+// rollback restores the given projects to their state before the batch, deleting projects created by it
+func (vm *VersionManager) rollback(projects []string, states map[string]projectState) error {
+	restored := make(map[string]bool, len(projects))
+
+	for _, project := range projects {
+		if restored[project] {
+			continue
+		}
+		restored[project] = true
+
+		state := states[project]
+		if !state.exists {
+			if err := vm.storageProvider.DeleteProject(project); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := vm.storageProvider.StoreProject(project, state.project); err != nil {
+			return err
+		}
+		if err := vm.storageProvider.StoreHistory(project, state.history); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
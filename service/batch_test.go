@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+)
+
+func TestApplyBatch(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("A", "1.0.0")
+
+	bumps, err := versionManager.ApplyBatch([]model.BatchItem{
+		{Project: "A", Operation: model.PartMinor},
+		{Project: "B", Operation: model.OperationSet, Version: "2.0.0"},
+		{Project: "A", Operation: model.PartPatch},
+	})
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(bumps).To(HaveLen(3))
+	Ω.Expect(bumps[0].Version.String()).To(Equal("1.1.0"))
+	Ω.Expect(bumps[1].Part).To(Equal(model.OperationSet))
+	Ω.Expect(bumps[1].Version.String()).To(Equal("2.0.0"))
+	Ω.Expect(bumps[2].PreviousVersion.String()).To(Equal("1.1.0"))
+	Ω.Expect(bumps[2].Version.String()).To(Equal("1.1.1"))
+}
+
+func TestApplyBatchRollsBackOnFailure(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	fileProvider := adapter.NewFileProvider(t.TempDir())
+	versionManager := NewVersionManager(fileProvider)
+	_, _ = versionManager.SetVersion("A", "1.0.0")
+	projectBefore, _ := fileProvider.ReadProject("A")
+	historyBefore, _ := fileProvider.ReadHistory("A")
+
+	_, err := versionManager.ApplyBatch([]model.BatchItem{
+		{Project: "A", Operation: model.PartMajor},
+		{Project: "B", Operation: model.OperationSet, Version: "1.0.0"},
+		{Project: "C", Operation: model.PartPatch},
+	})
+
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+	projectAfter, _ := fileProvider.ReadProject("A")
+	historyAfter, _ := fileProvider.ReadHistory("A")
+	Ω.Expect(projectAfter).To(Equal(projectBefore))
+	Ω.Expect(historyAfter).To(Equal(historyBefore))
+	projects, _ := fileProvider.ListProjects()
+	Ω.Expect(projects).To(Equal([]string{"A"}))
+}
+
+func TestApplyBatchWithInvalidItems(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("A", "1.0.0")
+
+	_, err := versionManager.ApplyBatch(nil)
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+
+	_, err = versionManager.ApplyBatch([]model.BatchItem{{Project: "A", Operation: model.PartPatch}, {Project: "A", Operation: "build"}})
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+	version, _ := versionManager.GetVersion("A")
+	Ω.Expect(version.String()).To(Equal("1.0.0"))
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/model"
+)
+
+// BumpBranch bumps the version stream of the given branch of the given project to the next pre-release of the version
+// bumping the given part of the main line would result in, leaving the main line and the project's revision unchanged.
+// The bump is recorded in the project's history as branch-<part>.
+func (vm *VersionManager) BumpBranch(project string, part string, branch string) (model.Bump, error) {
+	defer vm.lockProjects(project)()
+
+	bump, err := vm.bumpBranch(project, part, branch, false)
+	if err == nil {
+		vm.publish(bump)
+	}
+
+	return bump, err
+}
+
+// PreviewBranchBump returns the bump BumpBranch would apply without changing the project
+func (vm *VersionManager) PreviewBranchBump(project string, part string, branch string) (model.Bump, error) {
+	return vm.bumpBranch(project, part, branch, true)
+}
+
+func (vm *VersionManager) bumpBranch(project string, part string, branch string, dryRun bool) (model.Bump, error) {
+	bump := model.Bump{Project: project, Part: part, Branch: branch, DryRun: dryRun}
+
+	identifier, err := model.BranchIdentifier(branch)
+	if err != nil {
+		return bump, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+
+	currentProject, err := vm.storageProvider.ReadProject(project)
+	if err != nil {
+		return bump, err
+	}
+	if err := vm.checkPrecondition(project, currentProject, true); err != nil {
+		return bump, err
+	}
+	if other, taken := otherBranch(currentProject, identifier, branch); taken {
+		return bump, errors.Wrapf(ErrConflict, "Branch %v of project %v has the same identifier %v as branch %v", branch, project, identifier, other)
+	}
+
+	next, err := model.BumpPart(currentProject.Version, part)
+	if err != nil {
+		return bump, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+	bump.PreviousVersion = currentProject.Metadata.Branches[identifier]
+	bump.Version = model.BranchVersion(next, identifier, bump.PreviousVersion)
+	if dryRun {
+		return bump, nil
+	}
+
+	branches := make(map[string]model.Version, len(currentProject.Metadata.Branches)+1)
+	for name, version := range currentProject.Metadata.Branches {
+		branches[name] = version
+	}
+	branches[identifier] = bump.Version
+	currentProject.Metadata.Branches = branches
+	names := make(map[string]string, len(currentProject.Metadata.BranchNames)+1)
+	for name, branch := range currentProject.Metadata.BranchNames {
+		names[name] = branch
+	}
+	names[identifier] = branch
+	currentProject.Metadata.BranchNames = names
+
+	err = vm.storageProvider.StoreProject(project, currentProject)
+	if err != nil {
+		return bump, errors.Wrapf(err, "Failed to bump branch %v of project %v", branch, project)
+	}
+
+	return bump, vm.recordHistory(project, model.BranchAction(part), bump.Version, "")
+}
+
+// DeleteBranch removes the version stream of the given branch of the given project, e.g. after the branch was
+// merged, returning its latest version. Bumping the branch again starts a new stream.
+func (vm *VersionManager) DeleteBranch(project string, branch string) (model.Version, error) {
+	identifier, err := model.BranchIdentifier(branch)
+	if err != nil {
+		return model.Version{}, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+	defer vm.lockProjects(project)()
+
+	storedProject, err := vm.storageProvider.ReadProject(project)
+	if err != nil {
+		return model.Version{}, errors.Wrapf(err, "Failed to delete branch %v of project %v", branch, project)
+	}
+	if err := vm.checkPrecondition(project, storedProject, true); err != nil {
+		return model.Version{}, err
+	}
+	version, found := storedProject.Metadata.Branches[identifier]
+	if _, taken := otherBranch(storedProject, identifier, branch); !found || taken {
+		return model.Version{}, errors.Wrapf(ErrNotFound, "Project %v has no version for branch %v", project, branch)
+	}
+
+	branches := make(map[string]model.Version, len(storedProject.Metadata.Branches))
+	for name, version := range storedProject.Metadata.Branches {
+		if name != identifier {
+			branches[name] = version
+		}
+	}
+	storedProject.Metadata.Branches = branches
+	names := make(map[string]string, len(storedProject.Metadata.BranchNames))
+	for name, branch := range storedProject.Metadata.BranchNames {
+		if name != identifier {
+			names[name] = branch
+		}
+	}
+	storedProject.Metadata.BranchNames = names
+	if err := vm.storageProvider.StoreProject(project, storedProject); err != nil {
+		return version, errors.Wrapf(err, "Failed to delete branch %v of project %v", branch, project)
+	}
+
+	return version, nil
+}
+
+// GetBranchVersion returns the latest version of the stream of the given branch of the given project
+func (vm *VersionManager) GetBranchVersion(project string, branch string) (model.Version, error) {
+	identifier, err := model.BranchIdentifier(branch)
+	if err != nil {
+		return model.Version{}, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+
+	storedProject, err := vm.storageProvider.ReadProject(project)
+	if err != nil {
+		return model.Version{}, errors.Wrapf(err, "Failed to get version of branch %v of project %v", branch, project)
+	}
+
+	version, found := storedProject.Metadata.Branches[identifier]
+	if _, taken := otherBranch(storedProject, identifier, branch); !found || taken {
+		return model.Version{}, errors.Wrapf(ErrNotFound, "Project %v has no version for branch %v", project, branch)
+	}
+
+	return version, nil
+}
+
+// otherBranch returns the branch of the project using the given identifier if it is not the given branch. Streams
+// created before branch names were recorded belong to every branch with their identifier.
+func otherBranch(storedProject model.Project, identifier string, branch string) (string, bool) {
+	other, found := storedProject.Metadata.BranchNames[identifier]
+	return other, found && other != branch
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+)
+
+func TestBumpBranchKeepsMainLine(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	fileProvider := adapter.NewFileProvider(t.TempDir())
+	versionManager := NewVersionManager(fileProvider)
+	_, _ = versionManager.SetVersion("app", "1.3.2")
+
+	project, _ := versionManager.GetProject("app")
+	_, _ = versionManager.BumpBranch("app", model.PartMinor, "feature-x")
+	bump, err := versionManager.BumpBranch("app", model.PartMinor, "feature-x")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(bump.PreviousVersion.String()).To(Equal("1.4.0-feature-x.0"))
+	Ω.Expect(bump.Version.String()).To(Equal("1.4.0-feature-x.1"))
+	bumped, _ := versionManager.GetProject("app")
+	Ω.Expect(bumped.Version.String()).To(Equal("1.3.2"))
+	Ω.Expect(bumped.Revision()).To(Equal(project.Revision()))
+	history, _ := fileProvider.ReadHistory("app")
+	Ω.Expect(history).To(HaveLen(3))
+	Ω.Expect(history[2].Action).To(Equal("branch-minor"))
+	at, _ := versionManager.GetVersionAt("app", history[2].Timestamp)
+	Ω.Expect(at.String()).To(Equal("1.3.2"))
+
+	// branches sharing an identifier do not share a stream
+	_, err = versionManager.BumpBranch("app", model.PartMinor, "feature/x")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrConflict))
+	_, err = versionManager.GetBranchVersion("app", "feature/x")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+	_, err = versionManager.DeleteBranch("app", "feature/x")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+
+	_, _ = versionManager.BumpPatch("app")
+	branchVersion, _ := versionManager.GetBranchVersion("app", "feature-x")
+	Ω.Expect(branchVersion.String()).To(Equal("1.4.0-feature-x.1"))
+	_, err = versionManager.GetBranchVersion("app", "feature/y")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+}
+
+func TestDeleteBranch(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("app", "1.3.2")
+	_, _ = versionManager.BumpBranch("app", model.PartMinor, "feature/x")
+	_, _ = versionManager.BumpBranch("app", model.PartMinor, "feature/y")
+
+	version, err := versionManager.DeleteBranch("app", "feature/x")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(version.String()).To(Equal("1.4.0-feature-x.0"))
+	_, err = versionManager.GetBranchVersion("app", "feature/x")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+	_, err = versionManager.GetBranchVersion("app", "feature/y")
+	Ω.Expect(err).To(BeNil())
+	_, err = versionManager.DeleteBranch("app", "feature/x")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+	bump, _ := versionManager.BumpBranch("app", model.PartMinor, "feature/x")
+	Ω.Expect(bump.Version.String()).To(Equal("1.4.0-feature-x.0"))
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/model"
+)
+
+// GetDependencies returns the projects the given project depends on
+func (vm *VersionManager) GetDependencies(project string) ([]string, error) {
+	storedProject, err := vm.storageProvider.ReadProject(project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get dependencies of project %v", project)
+	}
+
+	dependencies := storedProject.Metadata.Dependencies
+	if dependencies == nil {
+		dependencies = []string{}
+	}
+
+	return dependencies, nil
+}
+
+// SetDependencies replaces the projects the given project depends on. All dependencies must exist and must not
+// depend on the project themselves.
+func (vm *VersionManager) SetDependencies(project string, dependencies []string) error {
+	defer vm.lockTopology()()
+
+	storedProject, err := vm.storageProvider.ReadProject(project)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to set dependencies of project %v", project)
+	}
+	if err := vm.checkPrecondition(project, storedProject, true); err != nil {
+		return err
+	}
+
+	unique := make(map[string]bool, len(dependencies))
+	for _, dependency := range dependencies {
+		dependency = model.NormalizeName(dependency)
+		if err := model.ValidateName(dependency); err != nil {
+			return errors.Wrap(ErrInvalidInput, err.Error())
+		}
+		if dependency == project {
+			return errors.Wrapf(ErrConflict, "Project %v cannot depend on itself", project)
+		}
+		if _, err := vm.storageProvider.ReadProject(dependency); err != nil {
+			return errors.Wrapf(err, "Failed to read dependency %v of project %v", dependency, project)
+		}
+		unique[dependency] = true
+	}
+	normalized := make([]string, 0, len(unique))
+	for dependency := range unique {
+		normalized = append(normalized, dependency)
+	}
+	sort.Strings(normalized)
+
+	graph, err := vm.dependencyGraph()
+	if err != nil {
+		return err
+	}
+	changed := make(model.DependencyGraph, len(graph)+1)
+	for dependent, dependencies := range graph {
+		changed[dependent] = dependencies
+	}
+	changed[project] = normalized
+	if _, err := changed.DependentsInOrder(project); err != nil {
+		return errors.Wrap(ErrConflict, err.Error())
+	}
+
+	storedProject.Metadata = storedProject.Metadata.Touch(vm.modifier, vm.now().UTC())
+	storedProject.Metadata.Dependencies = normalized
+
+	err = vm.storageProvider.StoreProject(project, storedProject)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to set dependencies of project %v", project)
+	}
+	vm.topology.setDependencies(project, normalized)
+
+	return nil
+}
+
+// dependencyGraph returns the dependencies of all projects from the topology index
+func (vm *VersionManager) dependencyGraph() (model.DependencyGraph, error) {
+	return vm.topology.dependencyGraph(vm.storageProvider)
+}
+
+// planCascade returns the bumps of all projects depending directly or transitively on the given projects when they
+// bump the given part, ordered so that every project is bumped after its dependencies. Each dependent bumps the most
+// significant part its cascade settings demand for the bumps of its dependencies.
+func (vm *VersionManager) planCascade(projects []string, part string) ([]model.Bump, error) {
+	graph, err := vm.dependencyGraph()
+	if err != nil {
+		return nil, err
+	}
+	dependents, err := graph.DependentsInOrder(projects...)
+	if err != nil {
+		return nil, errors.Wrap(ErrConflict, err.Error())
+	}
+
+	bumpedParts := make(map[string]string, len(projects)+len(dependents))
+	for _, project := range projects {
+		bumpedParts[project] = part
+	}
+	cascaded := make([]model.Bump, 0, len(dependents))
+	for _, dependent := range dependents {
+		if _, bumped := bumpedParts[dependent]; bumped {
+			// already bumped along with another member of its group
+			continue
+		}
+		settings, err := vm.GetSettings(dependent)
+		if err != nil {
+			return nil, err
+		}
+
+		bump := model.Bump{Project: dependent}
+		for _, dependency := range graph[dependent] {
+			dependencyPart, bumped := bumpedParts[dependency]
+			if !bumped {
+				continue
+			}
+			if cascadePart := model.CascadePart(settings, dependencyPart); cascadePart != "" {
+				bump.Part = model.StrongerPart(bump.Part, cascadePart)
+				bump.CascadedFrom = append(bump.CascadedFrom, dependency)
+			}
+		}
+		if bump.Part == "" {
+			continue
+		}
+
+		currentProject, err := vm.storageProvider.ReadProject(dependent)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to cascade bump to project %v", dependent)
+		}
+		bump.PreviousVersion = currentProject.Version
+		bump.Version, err = model.BumpPart(currentProject.Version, bump.Part)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidInput, err.Error())
+		}
+
+		bump.Linked, err = vm.linkedProjects(dependent, currentProject)
+		if err != nil {
+			return nil, err
+		}
+
+		bumpedParts[dependent] = bump.Part
+		for _, linked := range bump.Linked {
+			bumpedParts[linked] = bump.Part
+		}
+		cascaded = append(cascaded, bump)
+	}
+
+	return cascaded, nil
+}
+
+// applyCascade stores the cascaded bumps of a project's bump and the members of their groups, recording them in the
+// history as cascade-<part>
+func (vm *VersionManager) applyCascade(cascaded []model.Bump) error {
+	for _, bump := range cascaded {
+		currentProject, err := vm.storageProvider.ReadProject(bump.Project)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to cascade bump to project %v", bump.Project)
+		}
+		if err := vm.storeProject(bump.Project, currentProject, bump.Version); err != nil {
+			return err
+		}
+		if err := vm.recordHistory(bump.Project, cascadeAction(bump.Part), bump.Version, ""); err != nil {
+			return err
+		}
+		if err := vm.applyLinked(bump.Linked, bump.Version, cascadeAction(bump.Part), ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cascadeAction is the history action of a cascaded bump of the given part
+func cascadeAction(part string) string {
+	return "cascade-" + part
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+)
+
+func TestBumpCascadesToDependents(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	fileProvider := adapter.NewFileProvider(t.TempDir())
+	versionManager := NewVersionManager(fileProvider)
+	_, _ = versionManager.SetVersion("lib", "1.0.0")
+	_, _ = versionManager.SetVersion("client", "2.0.0")
+	_, _ = versionManager.SetVersion("app", "3.0.0")
+	Ω.Expect(versionManager.SetDependencies("client", []string{"lib"})).To(Succeed())
+	Ω.Expect(versionManager.SetDependencies("app", []string{"client", "lib"})).To(Succeed())
+	Ω.Expect(versionManager.SetSettings("app", map[string]string{"cascade.patch": model.PartMinor})).To(Succeed())
+
+	bump, err := versionManager.Bump("lib", model.PartMajor)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(bump.Version.String()).To(Equal("2.0.0"))
+	Ω.Expect(bump.Cascaded).To(HaveLen(2))
+	Ω.Expect(bump.Cascaded[0].Project).To(Equal("client"))
+	Ω.Expect(bump.Cascaded[0].Version.String()).To(Equal("2.0.1"))
+	Ω.Expect(bump.Cascaded[1].Project).To(Equal("app"))
+	Ω.Expect(bump.Cascaded[1].Part).To(Equal(model.PartMinor))
+	Ω.Expect(bump.Cascaded[1].CascadedFrom).To(Equal([]string{"client", "lib"}))
+	Ω.Expect(bump.Cascaded[1].Version.String()).To(Equal("3.1.0"))
+	version, _ := versionManager.GetVersion("app")
+	Ω.Expect(version.String()).To(Equal("3.1.0"))
+	history, _ := fileProvider.ReadHistory("app")
+	Ω.Expect(history[len(history)-1].Action).To(Equal("cascade-minor"))
+}
+
+func TestPreviewBumpIncludesCascade(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("lib", "1.0.0")
+	_, _ = versionManager.SetVersion("app", "3.0.0")
+	_ = versionManager.SetDependencies("app", []string{"lib"})
+
+	bump, err := versionManager.PreviewBump("lib", model.PartMinor, "")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(bump.Cascaded).To(HaveLen(1))
+	Ω.Expect(bump.Cascaded[0].Version.String()).To(Equal("3.0.1"))
+	version, _ := versionManager.GetVersion("app")
+	Ω.Expect(version.String()).To(Equal("3.0.0"))
+}
+
+func TestSetDependenciesRejectsInvalidDependencies(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("a", "1.0.0")
+	_, _ = versionManager.SetVersion("b", "1.0.0")
+	_ = versionManager.SetDependencies("b", []string{"a"})
+
+	Ω.Expect(errors.Cause(versionManager.SetDependencies("a", []string{"b"}))).To(Equal(ErrConflict))
+	Ω.Expect(errors.Cause(versionManager.SetDependencies("a", []string{"a"}))).To(Equal(ErrConflict))
+	Ω.Expect(errors.Cause(versionManager.SetDependencies("a", []string{"unknown"}))).To(Equal(ErrNotFound))
+	Ω.Expect(errors.Cause(versionManager.SetDependencies("a", []string{".hidden"}))).To(Equal(ErrInvalidInput))
+	dependencies, _ := versionManager.GetDependencies("a")
+	Ω.Expect(dependencies).To(BeEmpty())
+}
+
+func TestApplyBatchRollsBackCascades(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("lib", "1.0.0")
+	_, _ = versionManager.SetVersion("app", "3.0.0")
+	_ = versionManager.SetDependencies("app", []string{"lib"})
+
+	_, err := versionManager.ApplyBatch([]model.BatchItem{
+		{Project: "lib", Operation: model.PartMinor},
+		{Project: "unknown", Operation: model.PartPatch},
+	})
+
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+	version, _ := versionManager.GetVersion("app")
+	Ω.Expect(version.String()).To(Equal("3.0.0"))
+}
+
+func TestBumpSkipsBrokenProjects(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	versionManager := NewVersionManager(adapter.NewFileProvider(basePath))
+	_, _ = versionManager.SetVersion("lib", "1.0.0")
+	_, _ = versionManager.SetVersion("app", "3.0.0")
+	_ = versionManager.SetDependencies("app", []string{"lib"})
+	_ = os.WriteFile(path.Join(basePath, "broken"), []byte("not a version"), 0644)
+
+	bump, err := NewVersionManager(adapter.NewFileProvider(basePath)).Bump("lib", model.PartMinor)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(bump.Cascaded).To(HaveLen(1))
+}
+
+func TestDependencyIndexFollowsChanges(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("lib", "1.0.0")
+	_, _ = versionManager.SetVersion("app", "3.0.0")
+	bump, _ := versionManager.PreviewBump("lib", model.PartMinor, "")
+	Ω.Expect(bump.Cascaded).To(BeEmpty())
+
+	_ = versionManager.SetDependencies("app", []string{"lib"})
+	bump, _ = versionManager.PreviewBump("lib", model.PartMinor, "")
+	Ω.Expect(bump.Cascaded).To(HaveLen(1))
+
+	_ = versionManager.SetDependencies("app", []string{})
+	bump, _ = versionManager.PreviewBump("lib", model.PartMinor, "")
+	Ω.Expect(bump.Cascaded).To(BeEmpty())
+
+	snapshot, _ := versionManager.Export()
+	for index := range snapshot.Projects {
+		if snapshot.Projects[index].Name == "app" {
+			snapshot.Projects[index].Metadata.Dependencies = []string{"lib"}
+		}
+	}
+	_, err := versionManager.Import(snapshot, ImportOverwrite)
+	Ω.Expect(err).To(BeNil())
+	bump, _ = versionManager.PreviewBump("lib", model.PartMinor, "")
+	Ω.Expect(bump.Cascaded).To(HaveLen(1))
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/adapter"
+)
+
+// Kinds of errors returned by the version manager as cause of the errors wrapping them, shared with the storage
+var (
+	// ErrNotFound is returned when a project or its version at a point in time does not exist
+	ErrNotFound = adapter.ErrNotFound
+	// ErrInvalidInput is returned for invalid versions, names, parts and snapshots
+	ErrInvalidInput = adapter.ErrInvalidInput
+	// ErrConflict is returned when a change conflicts with the current state
+	ErrConflict = adapter.ErrConflict
+	// ErrUnavailable is returned when the storage cannot be read or written
+	ErrUnavailable = adapter.ErrUnavailable
+)
+
+// ErrPreconditionFailed is returned when a project is not in the state a change expects
+var ErrPreconditionFailed = errors.New("Precondition failed")
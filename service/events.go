@@ -0,0 +1,244 @@
+package service
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+)
+
+// subscriptionBuffer is the number of events a subscriber may fall behind before it is dropped
+const subscriptionBuffer = 64
+
+// Publisher receives the events of version changes
+type Publisher interface {
+	Publish(events ...model.Event) error
+}
+
+// EventLog numbers events consecutively, retains the latest ones of each tenant in a store and streams them to
+// subscribers, so subscribers can resume after the last event they received
+type EventLog struct {
+	store       adapter.EventStore
+	retain      int
+	mutex       sync.Mutex
+	events      map[string][]model.Event
+	dropped     map[string]uint64
+	count       int
+	stored      int
+	last        uint64
+	subscribers map[chan model.Event]string
+}
+
+// Subscription holds the retained events a subscriber missed and receives all later events of its tenant until it is
+// cancelled
+type Subscription struct {
+	Missed []model.Event
+	// Reset reports that events after the last event ID the subscriber received are no longer retained
+	Reset  bool
+	Events <-chan model.Event
+	Cancel func()
+}
+
+// NewEventLog constructs an event log retaining the given number of events per tenant, continuing the events in the
+// store
+func NewEventLog(store adapter.EventStore, retain int) (*EventLog, error) {
+	events, err := store.ReadEvents()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read event log")
+	}
+
+	eventLog := &EventLog{
+		store:       store,
+		retain:      retain,
+		events:      make(map[string][]model.Event),
+		dropped:     make(map[string]uint64),
+		stored:      len(events),
+		subscribers: make(map[chan model.Event]string),
+	}
+	if len(events) > 0 {
+		eventLog.last, err = strconv.ParseUint(events[len(events)-1].ID, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Event log ends with invalid event ID %v", events[len(events)-1].ID)
+		}
+	}
+	for _, event := range events {
+		eventLog.retainEvent(event)
+	}
+	// the store does not tell if earlier events of tenants retaining the maximum number of events were dropped
+	for tenant, retained := range eventLog.events {
+		if first := eventID(retained[0]); len(retained) == retain && eventLog.dropped[tenant] < first-1 {
+			eventLog.dropped[tenant] = first - 1
+		}
+	}
+
+	return eventLog, nil
+}
+
+// Append numbers the given events, retains them and passes them to the subscribers of their tenants, returning the
+// numbered events. Subscribers which fell behind are dropped by closing their channel.
+func (eventLog *EventLog) Append(events ...model.Event) ([]model.Event, error) {
+	eventLog.mutex.Lock()
+	defer eventLog.mutex.Unlock()
+
+	numbered := make([]model.Event, len(events))
+	for index, event := range events {
+		event.ID = strconv.FormatUint(eventLog.last+uint64(index)+1, 10)
+		numbered[index] = event
+	}
+
+	if err := eventLog.store.AppendEvents(numbered...); err != nil {
+		return nil, errors.Wrap(err, "Failed to append to event log")
+	}
+	eventLog.last += uint64(len(numbered))
+	eventLog.stored += len(numbered)
+	for _, event := range numbered {
+		eventLog.retainEvent(event)
+	}
+
+	// compact the store once it holds twice the retained events
+	if eventLog.stored > 2*eventLog.count {
+		if err := eventLog.store.StoreEvents(eventLog.retained()); err != nil {
+			return numbered, errors.Wrap(err, "Failed to compact event log")
+		}
+		eventLog.stored = eventLog.count
+	}
+
+	for subscriber, tenant := range eventLog.subscribers {
+		if !offer(subscriber, tenant, numbered) {
+			delete(eventLog.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+
+	return numbered, nil
+}
+
+// Subscribe subscribes to the events of the given tenant after the given event ID, or to all retained events if it is
+// empty
+func (eventLog *EventLog) Subscribe(tenant string, lastEventID string) (Subscription, error) {
+	var after uint64
+	if lastEventID != "" {
+		var err error
+		after, err = strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			return Subscription{}, errors.Wrapf(ErrInvalidInput, "%v is not a valid event ID", lastEventID)
+		}
+	}
+
+	eventLog.mutex.Lock()
+	defer eventLog.mutex.Unlock()
+
+	missed := make([]model.Event, 0)
+	for _, event := range eventLog.events[tenant] {
+		if eventID(event) > after {
+			missed = append(missed, event)
+		}
+	}
+
+	subscriber := make(chan model.Event, subscriptionBuffer)
+	eventLog.subscribers[subscriber] = tenant
+	cancel := func() {
+		eventLog.mutex.Lock()
+		defer eventLog.mutex.Unlock()
+
+		if _, subscribed := eventLog.subscribers[subscriber]; subscribed {
+			delete(eventLog.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+
+	return Subscription{
+		Missed: missed,
+		Reset:  lastEventID != "" && (after < eventLog.dropped[tenant] || after > eventLog.last),
+		Events: subscriber,
+		Cancel: cancel,
+	}, nil
+}
+
+// retainEvent retains the event with the events of its tenant, dropping the oldest one beyond the retained number
+func (eventLog *EventLog) retainEvent(event model.Event) {
+	retained := append(eventLog.events[event.Tenant], event)
+	eventLog.count++
+	if len(retained) > eventLog.retain {
+		eventLog.dropped[event.Tenant] = eventID(retained[0])
+		retained = append([]model.Event(nil), retained[1:]...)
+		eventLog.count--
+	}
+	eventLog.events[event.Tenant] = retained
+}
+
+// retained returns the retained events of all tenants ordered by their ID
+func (eventLog *EventLog) retained() []model.Event {
+	events := make([]model.Event, 0, eventLog.count)
+	for _, retained := range eventLog.events {
+		events = append(events, retained...)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return eventID(events[i]) < eventID(events[j])
+	})
+
+	return events
+}
+
+// publish appends the events of the given bumps to the event log and passes them to all publishers. It is called while
+// the changed projects are still locked, so events are numbered in the order of the changes. Failures are logged only
+// since the version change already happened.
+func (vm *VersionManager) publish(bumps ...model.Bump) {
+	if vm.eventLog == nil && len(vm.publishers) == 0 {
+		return
+	}
+
+	var events []model.Event
+	now := vm.now().UTC()
+	for _, bump := range bumps {
+		events = append(events, model.EventsOf(vm.tenant, bump, now)...)
+	}
+
+	if vm.eventLog != nil {
+		appended, err := vm.eventLog.Append(events...)
+		if err != nil {
+			log.Error().Err(err).Int("events", len(events)).Msg("Failed to append events to the event log")
+		}
+		if appended != nil {
+			events = appended
+		}
+	}
+	for index := range events {
+		if events[index].ID == "" {
+			events[index].ID = randomID()
+		}
+	}
+
+	for _, publisher := range vm.publishers {
+		if err := publisher.Publish(events...); err != nil {
+			log.Error().Err(err).Int("events", len(events)).Msg("Failed to publish events")
+		}
+	}
+}
+
+// offer passes the events of the subscriber's tenant to it without blocking, reporting if the subscriber could take
+// all of them
+func offer(subscriber chan model.Event, tenant string, events []model.Event) bool {
+	for _, event := range events {
+		if event.Tenant != tenant {
+			continue
+		}
+		select {
+		case subscriber <- event:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// eventID returns the number of a numbered event
+func eventID(event model.Event) uint64 {
+	id, _ := strconv.ParseUint(event.ID, 10, 64)
+	return id
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+)
+
+func TestEventLogNumbersAndRetainsEvents(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	eventLog, err := NewEventLog(adapter.NewFileEventStore(basePath), 2)
+	Ω.Expect(err).To(BeNil())
+
+	numbered, err := eventLog.Append(model.Event{Project: "p1"}, model.Event{Project: "p2"})
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(numbered[0].ID).To(Equal("1"))
+	Ω.Expect(numbered[1].ID).To(Equal("2"))
+	_, _ = eventLog.Append(model.Event{Project: "p3"})
+
+	subscription, _ := eventLog.Subscribe("", "")
+	subscription.Cancel()
+	Ω.Expect(subscription.Missed).To(HaveLen(2))
+	Ω.Expect(subscription.Missed[0].ID).To(Equal("2"))
+
+	// the log continues after a restart
+	restarted, err := NewEventLog(adapter.NewFileEventStore(basePath), 2)
+	Ω.Expect(err).To(BeNil())
+	numbered, _ = restarted.Append(model.Event{Project: "p4"}, model.Event{Project: "p5"})
+	Ω.Expect(numbered[1].ID).To(Equal("5"))
+	subscription, _ = restarted.Subscribe("", "3")
+	subscription.Cancel()
+	Ω.Expect(subscription.Reset).To(BeFalse())
+	Ω.Expect(subscription.Missed).To(HaveLen(2))
+	Ω.Expect(subscription.Missed[0].Project).To(Equal("p4"))
+
+	stored, _ := adapter.NewFileEventStore(basePath).ReadEvents()
+	Ω.Expect(len(stored)).To(BeNumerically("<=", 4))
+}
+
+func TestSubscribeToEventLog(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	eventLog, _ := NewEventLog(adapter.NewFileEventStore(t.TempDir()), 10)
+	_, _ = eventLog.Append(model.Event{Project: "p1"})
+
+	subscription, err := eventLog.Subscribe("", "1")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(subscription.Missed).To(BeEmpty())
+
+	_, _ = eventLog.Append(model.Event{Project: "p2"})
+	Ω.Expect((<-subscription.Events).Project).To(Equal("p2"))
+
+	subscription.Cancel()
+	_, open := <-subscription.Events
+	Ω.Expect(open).To(BeFalse())
+	subscription.Cancel()
+
+	_, err = eventLog.Subscribe("", "latest")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+}
+
+func TestDropSlowSubscribers(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	eventLog, _ := NewEventLog(adapter.NewFileEventStore(t.TempDir()), 1000)
+	subscription, _ := eventLog.Subscribe("", "")
+	defer subscription.Cancel()
+
+	for i := 0; i <= subscriptionBuffer; i++ {
+		_, _ = eventLog.Append(model.Event{Project: "p1"})
+	}
+
+	received := 0
+	for range subscription.Events {
+		received++
+	}
+	Ω.Expect(received).To(Equal(subscriptionBuffer))
+}
+
+func TestEventsAreNumberedInTheOrderOfChanges(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	eventLog, _ := NewEventLog(adapter.NewFileEventStore(basePath), 100)
+	tenants := NewSingleTenant(NewVersionManager(adapter.NewFileProvider(basePath)))
+	tenants.LogEvents(eventLog)
+	versionManager, _ := tenants.Manager(model.DefaultTenant)
+	_, _ = versionManager.SetVersion("p1", "1.0.0")
+
+	var wait sync.WaitGroup
+	for index := 0; index < 20; index++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			_, _ = versionManager.Bump("p1", model.PartPatch)
+		}()
+	}
+	wait.Wait()
+
+	subscription, _ := eventLog.Subscribe(model.DefaultTenant, "")
+	subscription.Cancel()
+	events := subscription.Missed
+	Ω.Expect(events).To(HaveLen(21))
+	for index := 1; index < len(events); index++ {
+		Ω.Expect(events[index].Tenant).To(Equal(model.DefaultTenant))
+		Ω.Expect(events[index].PreviousVersion).To(Equal(events[index-1].Version))
+	}
+}
+
+func TestEventLogRetainsEventsPerTenant(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	eventLog, _ := NewEventLog(adapter.NewFileEventStore(basePath), 2)
+	quiet, _ := eventLog.Subscribe("quiet", "")
+	defer quiet.Cancel()
+	_, _ = eventLog.Append(model.Event{Tenant: "quiet", Project: "p1"})
+	for index := 0; index < subscriptionBuffer+5; index++ {
+		_, _ = eventLog.Append(model.Event{Tenant: "busy", Project: "p1"})
+	}
+
+	// the busy tenant neither pushes the quiet tenant's events out nor its subscribers behind
+	Ω.Expect((<-quiet.Events).ID).To(Equal("1"))
+	subscription, _ := eventLog.Subscribe("quiet", "")
+	subscription.Cancel()
+	Ω.Expect(subscription.Missed).To(HaveLen(1))
+	subscription, _ = eventLog.Subscribe("busy", "")
+	subscription.Cancel()
+	Ω.Expect(subscription.Missed).To(HaveLen(2))
+
+	// resuming after dropped events resets the subscriber
+	subscription, _ = eventLog.Subscribe("busy", "2")
+	subscription.Cancel()
+	Ω.Expect(subscription.Reset).To(BeTrue())
+	subscription, _ = eventLog.Subscribe("quiet", "1")
+	subscription.Cancel()
+	Ω.Expect(subscription.Reset).To(BeFalse())
+	subscription, _ = eventLog.Subscribe("quiet", "1000")
+	subscription.Cancel()
+	Ω.Expect(subscription.Reset).To(BeTrue())
+
+	restarted, _ := NewEventLog(adapter.NewFileEventStore(basePath), 2)
+	subscription, _ = restarted.Subscribe("busy", "2")
+	subscription.Cancel()
+	Ω.Expect(subscription.Reset).To(BeTrue())
+	Ω.Expect(subscription.Missed).To(HaveLen(2))
+	subscription, _ = restarted.Subscribe("quiet", "")
+	subscription.Cancel()
+	Ω.Expect(subscription.Missed).To(HaveLen(1))
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/model"
+)
+
+// linkAction is the history action of aligning a project's version with the shared version of the group it joined
+const linkAction = "link"
+
+// ListGroups returns all groups with their members and shared version
+func (vm *VersionManager) ListGroups() ([]model.Group, error) {
+	groups, err := vm.readGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]model.Group, 0, len(groups))
+	for _, group := range groups {
+		list = append(list, group)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list, nil
+}
+
+// GetGroup returns the members and the shared version of the given group
+func (vm *VersionManager) GetGroup(name string) (model.Group, error) {
+	groups, err := vm.topology.groupMembers(vm.storageProvider)
+	if err != nil {
+		return model.Group{}, err
+	}
+
+	members, found := groups[name]
+	if !found {
+		return model.Group{Name: name, Members: []string{}}, errors.Wrapf(ErrNotFound, "Group %v does not exist", name)
+	}
+
+	return vm.readGroup(name, members)
+}
+
+// SetGroup replaces the members of the given group, an empty list of members dissolving it. Members which are new to
+// the group adopt the highest version of all members as shared version, which is published like a set version.
+// Projects cannot be members of several groups.
+func (vm *VersionManager) SetGroup(name string, members []string) (model.Group, error) {
+	group := model.Group{Name: name, Members: []string{}}
+	if err := model.ValidateName(name); err != nil {
+		return group, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+	defer vm.lockTopology()()
+
+	previous, err := vm.GetGroup(name)
+	exists := err == nil
+	if err != nil && errors.Cause(err) != ErrNotFound {
+		return group, err
+	}
+	if !vm.precondition.HoldsRevision(previous.Revision(), exists) {
+		return group, errors.Wrapf(ErrPreconditionFailed, "Group %v changed", name)
+	}
+
+	projects := make(map[string]model.Project, len(members))
+	for _, member := range members {
+		member = model.NormalizeName(member)
+		if err := model.ValidateName(member); err != nil {
+			return group, errors.Wrap(ErrInvalidInput, err.Error())
+		}
+		storedProject, err := vm.storageProvider.ReadProject(member)
+		if err != nil {
+			return group, errors.Wrapf(err, "Failed to add project %v to group %v", member, name)
+		}
+		if other := storedProject.Metadata.Group; other != "" && other != name {
+			return group, errors.Wrapf(ErrConflict, "Project %v is already a member of group %v", member, other)
+		}
+		if _, found := projects[member]; !found {
+			group.Members = append(group.Members, member)
+		}
+		projects[member] = storedProject
+		if group.Version.Compare(storedProject.Version) < 0 || len(group.Members) == 1 {
+			group.Version = storedProject.Version
+		}
+	}
+	sort.Strings(group.Members)
+
+	removed := make([]string, 0, len(previous.Members))
+	for _, member := range previous.Members {
+		if _, found := projects[member]; !found {
+			removed = append(removed, member)
+		}
+	}
+
+	changed := append(append([]string{}, removed...), group.Members...)
+	states, err := vm.readStates(changed)
+	if err != nil {
+		return group, err
+	}
+	aligned, err := vm.applyGroup(group, projects, removed)
+	if err != nil {
+		err = errors.Wrapf(err, "Failed to set members of group %v", name)
+		if rollbackErr := vm.rollback(changed, states); rollbackErr != nil {
+			// the stored groups are unknown, read them again
+			vm.topology.invalidate()
+			return group, errors.Wrapf(err, "Failed to roll back group: %v", rollbackErr)
+		}
+		return group, err
+	}
+	vm.topology.setGroup(name, group.Members)
+	vm.publish(aligned...)
+
+	return group, nil
+}
+
+// applyGroup stores the members of the given group and removes the given projects from it, returning the changes of
+// the members' versions as set versions
+func (vm *VersionManager) applyGroup(group model.Group, projects map[string]model.Project, removed []string) ([]model.Bump, error) {
+	for _, project := range removed {
+		storedProject, err := vm.storageProvider.ReadProject(project)
+		if err != nil {
+			return nil, err
+		}
+		storedProject.Metadata = storedProject.Metadata.Touch(vm.modifier, vm.now().UTC())
+		storedProject.Metadata.Group = ""
+		if err := vm.storageProvider.StoreProject(project, storedProject); err != nil {
+			return nil, err
+		}
+	}
+
+	var aligned []model.Bump
+	for _, member := range group.Members {
+		storedProject := projects[member]
+		previousVersion := storedProject.Version
+		storedProject.Version = group.Version
+		storedProject.Metadata = storedProject.Metadata.Touch(vm.modifier, vm.now().UTC())
+		storedProject.Metadata.Group = group.Name
+		if err := vm.storageProvider.StoreProject(member, storedProject); err != nil {
+			return nil, err
+		}
+		if previousVersion.String() != group.Version.String() {
+			if err := vm.recordHistory(member, linkAction, group.Version, ""); err != nil {
+				return nil, err
+			}
+			aligned = append(aligned, model.Bump{Project: member, Part: model.OperationSet, PreviousVersion: previousVersion, Version: group.Version})
+		}
+	}
+
+	return aligned, nil
+}
+
+// readGroups reads the shared version of all groups
+func (vm *VersionManager) readGroups() (map[string]model.Group, error) {
+	members, err := vm.topology.groupMembers(vm.storageProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]model.Group, len(members))
+	for name := range members {
+		group, err := vm.readGroup(name, members[name])
+		if err != nil {
+			return nil, err
+		}
+		groups[name] = group
+	}
+
+	return groups, nil
+}
+
+// readGroup reads the shared version of a group, the highest version of its members
+func (vm *VersionManager) readGroup(name string, members []string) (model.Group, error) {
+	group := model.Group{Name: name, Members: members}
+	for index, member := range members {
+		version, err := vm.storageProvider.ReadVersion(member)
+		if err != nil {
+			return group, errors.Wrapf(err, "Failed to get version of project %v in group %v", member, name)
+		}
+		if index == 0 || group.Version.Compare(version) < 0 {
+			group.Version = version
+		}
+	}
+
+	return group, nil
+}
+
+// linkedProjects returns the other members of the group of the given project
+func (vm *VersionManager) linkedProjects(project string, currentProject model.Project) ([]string, error) {
+	if currentProject.Metadata.Group == "" {
+		return nil, nil
+	}
+
+	groups, err := vm.topology.groupMembers(vm.storageProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	members := groups[currentProject.Metadata.Group]
+	linked := make([]string, 0, len(members))
+	for _, member := range members {
+		if member != project {
+			linked = append(linked, member)
+		}
+	}
+
+	return linked, nil
+}
+
+// applyLinked stores the version of a project's bump or set version for the other members of its group
+func (vm *VersionManager) applyLinked(linked []string, version model.Version, action string, ref string) error {
+	for _, project := range linked {
+		currentProject, err := vm.storageProvider.ReadProject(project)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to change linked project %v", project)
+		}
+		if err := vm.storeProject(project, currentProject, version); err != nil {
+			return err
+		}
+		if err := vm.recordHistory(project, action, version, ref); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
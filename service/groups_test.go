@@ -0,0 +1,161 @@
+package service
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+)
+
+func TestSetGroupAlignsVersions(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	fileProvider := adapter.NewFileProvider(t.TempDir())
+	versionManager := NewVersionManager(fileProvider)
+	_, _ = versionManager.SetVersion("sdk/java", "1.2.0")
+	_, _ = versionManager.SetVersion("sdk/go", "1.4.0")
+
+	group, err := versionManager.SetGroup("sdk", []string{"sdk/java", "sdk/go", "sdk/java"})
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(group.Members).To(Equal([]string{"sdk/go", "sdk/java"}))
+	Ω.Expect(group.Version.String()).To(Equal("1.4.0"))
+	version, _ := versionManager.GetVersion("sdk/java")
+	Ω.Expect(version.String()).To(Equal("1.4.0"))
+	history, _ := fileProvider.ReadHistory("sdk/java")
+	Ω.Expect(history[len(history)-1].Action).To(Equal("link"))
+}
+
+func TestBumpAndSetVersionOfGroupMember(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("a", "1.0.0")
+	_, _ = versionManager.SetVersion("b", "1.0.0")
+	_, _ = versionManager.SetVersion("c", "1.0.0")
+	_, _ = versionManager.SetGroup("ab", []string{"a", "b"})
+
+	bump, err := versionManager.Bump("a", model.PartMinor)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(bump.Linked).To(Equal([]string{"b"}))
+	versions, _ := versionManager.GetVersions()
+	Ω.Expect(versions["a"].String()).To(Equal("1.1.0"))
+	Ω.Expect(versions["b"].String()).To(Equal("1.1.0"))
+	Ω.Expect(versions["c"].String()).To(Equal("1.0.0"))
+
+	_, err = versionManager.SetVersion("b", "2.0.0")
+
+	Ω.Expect(err).To(BeNil())
+	group, _ := versionManager.GetGroup("ab")
+	Ω.Expect(group.Version.String()).To(Equal("2.0.0"))
+	version, _ := versionManager.GetVersion("a")
+	Ω.Expect(version.String()).To(Equal("2.0.0"))
+}
+
+func TestSetGroupChangesMembership(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("a", "1.0.0")
+	_, _ = versionManager.SetVersion("b", "1.0.0")
+	_, _ = versionManager.SetGroup("ab", []string{"a", "b"})
+
+	_, err := versionManager.SetGroup("other", []string{"a"})
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrConflict))
+	_, err = versionManager.SetGroup("ab", []string{"a", "unknown"})
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+
+	_, err = versionManager.SetGroup("ab", []string{"a"})
+	Ω.Expect(err).To(BeNil())
+	_, _ = versionManager.Bump("a", model.PartMajor)
+	version, _ := versionManager.GetVersion("b")
+	Ω.Expect(version.String()).To(Equal("1.0.0"))
+
+	_, err = versionManager.SetGroup("ab", nil)
+	Ω.Expect(err).To(BeNil())
+	_, err = versionManager.GetGroup("ab")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+}
+
+func TestBumpOfGroupCascadesFromAllMembers(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("a", "1.0.0")
+	_, _ = versionManager.SetVersion("b", "1.0.0")
+	_, _ = versionManager.SetVersion("app", "3.0.0")
+	_, _ = versionManager.SetGroup("ab", []string{"a", "b"})
+	_ = versionManager.SetDependencies("app", []string{"b"})
+
+	bump, err := versionManager.Bump("a", model.PartPatch)
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(bump.Cascaded).To(HaveLen(1))
+	Ω.Expect(bump.Cascaded[0].CascadedFrom).To(Equal([]string{"b"}))
+	version, _ := versionManager.GetVersion("app")
+	Ω.Expect(version.String()).To(Equal("3.0.1"))
+}
+
+func TestGroupVersionIsTheHighestMemberVersion(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	fileProvider := adapter.NewFileProvider(t.TempDir())
+	versionManager := NewVersionManager(fileProvider)
+	_, _ = versionManager.SetVersion("a", "1.0.0")
+	_, _ = versionManager.SetVersion("b", "1.0.0")
+	_, _ = versionManager.SetGroup("ab", []string{"a", "b"})
+	_, _ = versionManager.SetVersion("a", "1.10.0")
+	stored, _ := fileProvider.ReadProject("b")
+	stored.Version = model.NewVersion(1, 9, 0)
+	_ = fileProvider.StoreProject("b", stored)
+
+	group, err := versionManager.GetGroup("ab")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(group.Version.String()).To(Equal("1.10.0"))
+}
+
+func TestSetGroupWithPrecondition(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("a", "1.0.0")
+	_, _ = versionManager.SetVersion("b", "1.0.0")
+	group, _ := versionManager.SetGroup("ab", []string{"a"})
+	revision := group.Revision()
+
+	_, err := versionManager.WithPrecondition(model.Precondition{IfNoneMatch: []string{model.AnyRevision}}).SetGroup("ab", []string{"a", "b"})
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrPreconditionFailed))
+
+	_, err = versionManager.WithPrecondition(model.Precondition{IfMatch: []string{revision}}).SetGroup("ab", []string{"a", "b"})
+	Ω.Expect(err).To(BeNil())
+
+	_, err = versionManager.WithPrecondition(model.Precondition{IfMatch: []string{revision}}).SetGroup("ab", []string{"b"})
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrPreconditionFailed))
+	group, _ = versionManager.GetGroup("ab")
+	Ω.Expect(group.Members).To(Equal([]string{"a", "b"}))
+}
+
+func TestSetGroupPublishesAlignedVersions(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	eventLog, _ := NewEventLog(adapter.NewFileEventStore(basePath), 100)
+	versionManager := NewVersionManager(adapter.NewFileProvider(basePath)).withEvents(model.DefaultTenant, eventLog, nil)
+	_, _ = versionManager.SetVersion("a", "1.0.0")
+	_, _ = versionManager.SetVersion("b", "1.2.0")
+
+	_, _ = versionManager.SetGroup("ab", []string{"a", "b"})
+
+	subscription, _ := eventLog.Subscribe(model.DefaultTenant, "2")
+	subscription.Cancel()
+	events := subscription.Missed
+	Ω.Expect(events).To(HaveLen(1))
+	Ω.Expect(events[0].Type).To(Equal(model.EventSet))
+	Ω.Expect(events[0].Project).To(Equal("a"))
+	Ω.Expect(events[0].PreviousVersion.String()).To(Equal("1.0.0"))
+	Ω.Expect(events[0].Version.String()).To(Equal("1.2.0"))
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/model"
+)
+
+// BumpLine bumps the given part of the latest version within the given release line of the given project, like 1.8
+// or 1 for maintaining 1.x next to 2.x. The project's version only follows if the line's version becomes the highest,
+// which aligns the members of its group and cascades to its dependents like a bump; otherwise the bump is recorded in
+// the project's history as line-<part>.
+func (vm *VersionManager) BumpLine(project string, part string, line string) (model.Bump, error) {
+	unlock, err := vm.lockAffectedProjects(project, true)
+	if err != nil {
+		return model.Bump{Project: project, Part: part, Line: line}, err
+	}
+	defer unlock()
+
+	bump, err := vm.bumpLine(project, part, line, false)
+	if err == nil {
+		vm.publish(bump)
+	}
+
+	return bump, err
+}
+
+// PreviewLineBump returns the bump BumpLine would apply without changing the project
+func (vm *VersionManager) PreviewLineBump(project string, part string, line string) (model.Bump, error) {
+	return vm.bumpLine(project, part, line, true)
+}
+
+func (vm *VersionManager) bumpLine(project string, part string, line string, dryRun bool) (model.Bump, error) {
+	bump := model.Bump{Project: project, Part: part, Line: line, DryRun: dryRun}
+	if err := model.ValidateLine(line); err != nil {
+		return bump, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+
+	currentProject, err := vm.storageProvider.ReadProject(project)
+	if err != nil {
+		return bump, err
+	}
+	if err := vm.checkPrecondition(project, currentProject, true); err != nil {
+		return bump, err
+	}
+
+	bump.PreviousVersion, err = vm.lineVersion(project, currentProject, line)
+	if err != nil {
+		return bump, err
+	}
+	bump.Version, err = model.BumpPart(bump.PreviousVersion, part)
+	if err != nil {
+		return bump, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+	if !model.InLine(bump.Version, line) {
+		return bump, errors.Wrapf(ErrInvalidInput, "Bumping the %v part of %v leaves release line %v", part, bump.PreviousVersion.String(), line)
+	}
+
+	lines := make(map[string]model.Version, len(currentProject.Metadata.Lines)+1)
+	for key, version := range currentProject.Metadata.Lines {
+		lines[key] = version
+	}
+	lines[line] = bump.Version
+	currentProject.Metadata.Lines = lines
+
+	if bump.Version.Compare(currentProject.Version) > 0 {
+		bump.Linked, err = vm.linkedProjects(project, currentProject)
+		if err != nil {
+			return bump, err
+		}
+		bump.Cascaded, err = vm.planCascade(append([]string{project}, bump.Linked...), part)
+		if err != nil || dryRun {
+			return bump, err
+		}
+
+		err = vm.applyVersion(project, currentProject, bump.Version, part, "", bump.Linked, bump.Cascaded)
+		if err != nil {
+			return bump, errors.Wrapf(err, "Failed to bump release line %v of project %v", line, project)
+		}
+		return bump, nil
+	}
+	if dryRun {
+		return bump, nil
+	}
+
+	currentProject.Metadata = currentProject.Metadata.Touch(vm.modifier, vm.now().UTC())
+	err = vm.storageProvider.StoreProject(project, currentProject)
+	if err != nil {
+		return bump, errors.Wrapf(err, "Failed to bump release line %v of project %v", line, project)
+	}
+
+	return bump, vm.recordHistory(project, model.LineAction(part), bump.Version, "")
+}
+
+// GetLineVersion returns the latest version within the given release line of the given project
+func (vm *VersionManager) GetLineVersion(project string, line string) (model.Version, error) {
+	if err := model.ValidateLine(line); err != nil {
+		return model.Version{}, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+
+	storedProject, err := vm.storageProvider.ReadProject(project)
+	if err != nil {
+		return model.Version{}, errors.Wrapf(err, "Failed to get release line %v of project %v", line, project)
+	}
+
+	return vm.lineVersion(project, storedProject, line)
+}
+
+// GetLines returns the latest version of every release line of the given project keyed by major, plus the lines
+// keyed by major and minor which were bumped
+func (vm *VersionManager) GetLines(project string) (map[string]model.Version, error) {
+	storedProject, err := vm.storageProvider.ReadProject(project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get release lines of project %v", project)
+	}
+	versions, err := vm.lineCandidates(project, storedProject)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make(map[string]model.Version)
+	for _, version := range versions {
+		lines[model.LineOf(version)] = model.Version{}
+	}
+	for line := range storedProject.Metadata.Lines {
+		lines[line] = model.Version{}
+	}
+	for line := range lines {
+		lines[line], _ = model.LatestInLine(line, versions...)
+	}
+
+	return lines, nil
+}
+
+// lineVersion returns the latest version within the given release line, which is the highest version recorded in
+// the project's history or bumped within the line
+func (vm *VersionManager) lineVersion(project string, storedProject model.Project, line string) (model.Version, error) {
+	versions, err := vm.lineCandidates(project, storedProject)
+	if err != nil {
+		return model.Version{}, err
+	}
+
+	version, found := model.LatestInLine(line, versions...)
+	if !found {
+		return version, errors.Wrapf(ErrNotFound, "Project %v has no release line %v", project, line)
+	}
+
+	return version, nil
+}
+
+func (vm *VersionManager) lineCandidates(project string, storedProject model.Project) ([]model.Version, error) {
+	history, err := vm.storageProvider.ReadHistory(project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get history for project %v", project)
+	}
+
+	versions := []model.Version{storedProject.Version}
+	for _, entry := range history {
+		if !entry.OfBranch() {
+			versions = append(versions, entry.Version)
+		}
+	}
+	for _, version := range storedProject.Metadata.Lines {
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+)
+
+func TestBumpLineMaintainsOlderLine(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("app", "1.8.4")
+	_, _ = versionManager.BumpMajor("app")
+
+	bump, err := versionManager.BumpLine("app", model.PartPatch, "1")
+
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(bump.PreviousVersion.String()).To(Equal("1.8.4"))
+	Ω.Expect(bump.Version.String()).To(Equal("1.8.5"))
+	version, _ := versionManager.GetVersion("app")
+	Ω.Expect(version.String()).To(Equal("2.0.0"))
+
+	bump, _ = versionManager.BumpLine("app", model.PartPatch, "1.8")
+	Ω.Expect(bump.Version.String()).To(Equal("1.8.6"))
+	lineVersion, _ := versionManager.GetLineVersion("app", "1")
+	Ω.Expect(lineVersion.String()).To(Equal("1.8.6"))
+
+	lines, _ := versionManager.GetLines("app")
+	Ω.Expect(lines).To(HaveLen(3))
+	Ω.Expect(lines["1"].String()).To(Equal("1.8.6"))
+	Ω.Expect(lines["1.8"].String()).To(Equal("1.8.6"))
+	Ω.Expect(lines["2"].String()).To(Equal("2.0.0"))
+}
+
+func TestBumpLineRecordsHistory(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	fileProvider := adapter.NewFileProvider(t.TempDir())
+	versionManager := NewVersionManager(fileProvider)
+	_, _ = versionManager.SetVersion("app", "1.8.4")
+	_, _ = versionManager.BumpMajor("app")
+
+	_, _ = versionManager.BumpLine("app", model.PartPatch, "1")
+
+	history, _ := fileProvider.ReadHistory("app")
+	Ω.Expect(history).To(HaveLen(3))
+	Ω.Expect(history[2].Action).To(Equal("line-patch"))
+	Ω.Expect(history[2].Version.String()).To(Equal("1.8.5"))
+	at, _ := versionManager.GetVersionAt("app", history[2].Timestamp)
+	Ω.Expect(at.String()).To(Equal("2.0.0"))
+
+	bump, _ := versionManager.BumpRef("app", model.PartMinor, "abc")
+	Ω.Expect(bump.PreviousVersion.String()).To(Equal("2.0.0"))
+	reused, _ := versionManager.BumpRef("app", model.PartMinor, "abc")
+	Ω.Expect(reused.Reused).To(BeTrue())
+	Ω.Expect(reused.PreviousVersion.String()).To(Equal("2.0.0"))
+}
+
+func TestBumpHighestLineMovesLatest(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	fileProvider := adapter.NewFileProvider(t.TempDir())
+	versionManager := NewVersionManager(fileProvider)
+	_, _ = versionManager.SetVersion("app", "2.0.0")
+
+	_, err := versionManager.BumpLine("app", model.PartMinor, "2")
+
+	Ω.Expect(err).To(BeNil())
+	version, _ := versionManager.GetVersion("app")
+	Ω.Expect(version.String()).To(Equal("2.1.0"))
+	history, _ := fileProvider.ReadHistory("app")
+	Ω.Expect(history).To(HaveLen(2))
+}
+
+func TestBumpLineRejectsLeavingTheLine(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("app", "1.8.4")
+
+	_, err := versionManager.BumpLine("app", model.PartMinor, "1.8")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+	_, err = versionManager.BumpLine("app", model.PartMajor, "1")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+	_, err = versionManager.BumpLine("app", model.PartPatch, "3")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+	_, err = versionManager.BumpLine("app", model.PartPatch, "1.x")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+}
+
+func TestBumpHighestLineAlignsGroupAndCascades(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("a", "2.0.0")
+	_, _ = versionManager.SetVersion("b", "2.0.0")
+	_, _ = versionManager.SetVersion("app", "1.0.0")
+	_, _ = versionManager.SetGroup("ab", []string{"a", "b"})
+	_ = versionManager.SetDependencies("app", []string{"a"})
+
+	preview, _ := versionManager.PreviewLineBump("a", model.PartMinor, "2")
+	Ω.Expect(preview.Linked).To(Equal([]string{"b"}))
+	Ω.Expect(preview.Cascaded).To(HaveLen(1))
+
+	bump, err := versionManager.BumpLine("a", model.PartMinor, "2")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(bump.Linked).To(Equal([]string{"b"}))
+	Ω.Expect(bump.Cascaded).To(HaveLen(1))
+	b, _ := versionManager.GetVersion("b")
+	Ω.Expect(b.String()).To(Equal("2.1.0"))
+	app, _ := versionManager.GetVersion("app")
+	Ω.Expect(app.String()).To(Equal("1.0.1"))
+	lineVersion, _ := versionManager.GetLineVersion("a", "2")
+	Ω.Expect(lineVersion.String()).To(Equal("2.1.0"))
+}
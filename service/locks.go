@@ -0,0 +1,133 @@
+package service
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// projectLocks serializes changes of the same projects, so reading a project, checking it and storing it is atomic.
+// Changes of dependencies and groups hold the topology lock exclusively, all other changes share it, so the projects
+// affected by a change through its group or its dependents cannot change while it is applied.
+type projectLocks struct {
+	topology sync.RWMutex
+	mutex    sync.Mutex
+	projects map[string]*projectLock
+}
+
+// projectLock is the lock of a single project, dropped once nobody holds or waits for it
+type projectLock struct {
+	sync.Mutex
+	holders int
+}
+
+func newProjectLocks() *projectLocks {
+	return &projectLocks{projects: make(map[string]*projectLock)}
+}
+
+// lock locks the given projects in sorted order, so changes of overlapping projects cannot deadlock, and returns a
+// function unlocking them again
+func (locks *projectLocks) lock(projects ...string) func() {
+	unique := make(map[string]bool, len(projects))
+	sorted := make([]string, 0, len(projects))
+	for _, project := range projects {
+		if !unique[project] {
+			unique[project] = true
+			sorted = append(sorted, project)
+		}
+	}
+	sort.Strings(sorted)
+
+	held := make([]*projectLock, 0, len(sorted))
+	for _, project := range sorted {
+		locks.mutex.Lock()
+		lock, found := locks.projects[project]
+		if !found {
+			lock = &projectLock{}
+			locks.projects[project] = lock
+		}
+		lock.holders++
+		locks.mutex.Unlock()
+
+		lock.Lock()
+		held = append(held, lock)
+	}
+
+	return func() {
+		for index := len(held) - 1; index >= 0; index-- {
+			held[index].Unlock()
+			locks.mutex.Lock()
+			held[index].holders--
+			if held[index].holders == 0 {
+				delete(locks.projects, sorted[index])
+			}
+			locks.mutex.Unlock()
+		}
+	}
+}
+
+// namespaceLockKey returns the lock key of a namespace, which cannot collide with a project name since hidden
+// segments are not allowed in names
+func namespaceLockKey(namespace string) string {
+	return "." + namespace
+}
+
+// lockProjects shares the topology lock and locks the given projects, returning a function unlocking both
+func (vm *VersionManager) lockProjects(projects ...string) func() {
+	vm.locks.topology.RLock()
+	unlock := vm.locks.lock(projects...)
+
+	return func() {
+		unlock()
+		vm.locks.topology.RUnlock()
+	}
+}
+
+// lockAffectedProjects shares the topology lock and locks the given project along with the projects a change of it
+// affects: the members of its group and, if it cascades, its dependents and the members of their groups
+func (vm *VersionManager) lockAffectedProjects(project string, cascades bool) (func(), error) {
+	vm.locks.topology.RLock()
+
+	affected, err := vm.affectedProjects(project, cascades)
+	if err != nil {
+		vm.locks.topology.RUnlock()
+		return nil, err
+	}
+	unlock := vm.locks.lock(affected...)
+
+	return func() {
+		unlock()
+		vm.locks.topology.RUnlock()
+	}, nil
+}
+
+// lockTopology locks out all other changes while dependencies or groups change, returning a function unlocking it
+func (vm *VersionManager) lockTopology() func() {
+	vm.locks.topology.Lock()
+	return vm.locks.topology.Unlock
+}
+
+// affectedProjects returns the given project, the members of its group and, if it cascades, its dependents and the
+// members of their groups
+func (vm *VersionManager) affectedProjects(project string, cascades bool) ([]string, error) {
+	groups, err := vm.topology.groupMembers(vm.storageProvider)
+	if err != nil {
+		return nil, err
+	}
+	affected := withGroupMembers([]string{project}, groups)
+	if !cascades {
+		return affected, nil
+	}
+
+	graph, err := vm.dependencyGraph()
+	if err != nil {
+		return nil, err
+	}
+	dependents, err := graph.DependentsInOrder(affected...)
+	if err != nil {
+		return nil, errors.Wrap(ErrConflict, err.Error())
+	}
+
+	return append(affected, withGroupMembers(dependents, groups)...), nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+)
+
+func TestConcurrentChangesWithPrecondition(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("p1", "1.0.0")
+	project, _ := versionManager.GetProject("p1")
+	conditional := versionManager.WithPrecondition(model.Precondition{IfMatch: []string{project.Revision()}})
+
+	var wait sync.WaitGroup
+	var mutex sync.Mutex
+	succeeded := 0
+	for index := 0; index < 10; index++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			if _, err := conditional.Bump("p1", model.PartPatch); err == nil {
+				mutex.Lock()
+				succeeded++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wait.Wait()
+
+	actual, _ := versionManager.GetVersion("p1")
+	Ω.Expect(succeeded).To(Equal(1))
+	Ω.Expect(actual.String()).To(Equal("1.0.1"))
+}
+
+func TestProjectLocksAreDropped(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	locks := newProjectLocks()
+	unlock := locks.lock("b", "a", "b")
+	Ω.Expect(locks.projects).To(HaveLen(2))
+
+	unlock()
+	Ω.Expect(locks.projects).To(BeEmpty())
+}
+
+func TestConcurrentBumpsWithTheSameRef(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("p1", "1.0.0")
+
+	var wait sync.WaitGroup
+	for index := 0; index < 10; index++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			bump, err := versionManager.BumpRef("p1", model.PartMinor, "abc")
+			Ω.Expect(err).To(BeNil())
+			Ω.Expect(bump.PreviousVersion.String()).To(Equal("1.0.0"))
+			Ω.Expect(bump.Version.String()).To(Equal("1.1.0"))
+		}()
+	}
+	wait.Wait()
+
+	actual, _ := versionManager.GetVersion("p1")
+	Ω.Expect(actual.String()).To(Equal("1.1.0"))
+}
+
+func TestFailingBatchesKeepConcurrentBumps(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("p1", "1.0.0")
+
+	var wait sync.WaitGroup
+	for index := 0; index < 10; index++ {
+		wait.Add(2)
+		go func() {
+			defer wait.Done()
+			_, _ = versionManager.Bump("p1", model.PartPatch)
+		}()
+		go func() {
+			defer wait.Done()
+			_, _ = versionManager.ApplyBatch([]model.BatchItem{
+				{Project: "p1", Operation: model.PartMajor},
+				{Project: "missing", Operation: model.PartPatch},
+			})
+		}()
+	}
+	wait.Wait()
+
+	actual, _ := versionManager.GetVersion("p1")
+	Ω.Expect(actual.String()).To(Equal("1.0.10"))
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"maibornwolff/vbump/model"
+)
+
+// ListProjects returns the names of all projects within the given namespace, the root namespace "" containing all
+func (vm *VersionManager) ListProjects(namespace string) ([]string, error) {
+	projects, err := vm.storageProvider.ListProjects()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list projects")
+	}
+
+	namespaceProjects := make([]string, 0, len(projects))
+	for _, project := range projects {
+		if model.InNamespace(project, namespace) {
+			namespaceProjects = append(namespaceProjects, project)
+		}
+	}
+
+	return namespaceProjects, nil
+}
+
+// GetProjects returns all projects within the given namespace with their versions and metadata, reading each of them
+// once. Projects which cannot be decoded are skipped and returned separately, so a single broken project does not fail
+// the whole list; fsck reports them.
+func (vm *VersionManager) GetProjects(namespace string) (map[string]model.Project, []string, error) {
+	projects, err := vm.ListProjects(namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	storedProjects := make(map[string]model.Project, len(projects))
+	skipped := make([]string, 0)
+	for _, project := range projects {
+		storedProject, err := vm.storageProvider.ReadProject(project)
+		if errors.Cause(err) == ErrUnavailable {
+			return nil, nil, errors.Wrapf(err, "Failed to get project %v", project)
+		}
+		if err != nil {
+			log.Warn().Str("project", project).Err(err).Msg("Skipping unreadable project")
+			skipped = append(skipped, project)
+			continue
+		}
+		storedProjects[project] = storedProject
+	}
+
+	return storedProjects, skipped, nil
+}
+
+// GetSettings returns the settings of the given project, inherited from all its namespaces and overridden by its own
+func (vm *VersionManager) GetSettings(project string) (map[string]string, error) {
+	settings, err := vm.inheritedSettings(model.ParentNamespaces(project))
+	if err != nil {
+		return nil, err
+	}
+
+	storedProject, err := vm.storageProvider.ReadProject(project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get settings of project %v", project)
+	}
+
+	return model.InheritSettings(settings, storedProject.Metadata.Settings), nil
+}
+
+// SetSettings replaces the own settings of the given project
+func (vm *VersionManager) SetSettings(project string, settings map[string]string) error {
+	defer vm.lockProjects(project)()
+
+	storedProject, err := vm.storageProvider.ReadProject(project)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to set settings of project %v", project)
+	}
+	if err := vm.checkPrecondition(project, storedProject, true); err != nil {
+		return err
+	}
+
+	storedProject.Metadata = storedProject.Metadata.Touch(vm.modifier, vm.now().UTC())
+	storedProject.Metadata.Settings = settings
+
+	err = vm.storageProvider.StoreProject(project, storedProject)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to set settings of project %v", project)
+	}
+
+	return nil
+}
+
+// GetNamespaceSettings returns the settings of the given namespace, inherited from all its parent namespaces
+func (vm *VersionManager) GetNamespaceSettings(namespace string) (map[string]string, error) {
+	namespaces := []string{""}
+	if namespace != "" {
+		namespaces = append(model.ParentNamespaces(namespace), namespace)
+	}
+
+	return vm.inheritedSettings(namespaces)
+}
+
+// GetNamespace returns the given namespace with its own settings only
+func (vm *VersionManager) GetNamespace(namespace string) (model.Namespace, error) {
+	storedNamespace, err := vm.storageProvider.ReadNamespace(namespace)
+	if err != nil {
+		return storedNamespace, errors.Wrapf(err, "Failed to read settings of namespace %v", namespace)
+	}
+
+	return storedNamespace, nil
+}
+
+// SetNamespaceSettings replaces the own settings of the given namespace. Namespaces always exist, so preconditions
+// are checked against the revision of their own settings.
+func (vm *VersionManager) SetNamespaceSettings(namespace string, settings map[string]string) error {
+	defer vm.lockProjects(namespaceLockKey(namespace))()
+
+	storedNamespace, err := vm.GetNamespace(namespace)
+	if err != nil {
+		return err
+	}
+	if !vm.precondition.HoldsRevision(storedNamespace.Revision(), true) {
+		return errors.Wrapf(ErrPreconditionFailed, "Namespace %v changed", namespace)
+	}
+
+	err = vm.storageProvider.StoreNamespace(namespace, model.Namespace{Settings: settings})
+	if err != nil {
+		return errors.Wrapf(err, "Failed to set settings of namespace %v", namespace)
+	}
+
+	return nil
+}
+
+func (vm *VersionManager) inheritedSettings(namespaces []string) (map[string]string, error) {
+	layers := make([]map[string]string, 0, len(namespaces))
+
+	for _, namespace := range namespaces {
+		storedNamespace, err := vm.storageProvider.ReadNamespace(namespace)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read settings of namespace %v", namespace)
+		}
+		layers = append(layers, storedNamespace.Settings)
+	}
+
+	return model.InheritSettings(layers...), nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+)
+
+func TestListProjectsInNamespace(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	versionManager := NewVersionManager(adapter.NewFileProvider(basePath))
+	_, _ = versionManager.SetVersion("team/a", "1.0")
+	_, _ = versionManager.SetVersion("team/sub/b", "1.0")
+	_, _ = versionManager.SetVersion("other/c", "1.0")
+	_ = os.WriteFile(path.Join(basePath, "team", "broken"), []byte("not a version"), 0644)
+
+	actual, _ := versionManager.ListProjects("team")
+	Ω.Expect(actual).To(Equal([]string{"team/a", "team/broken", "team/sub/b"}))
+
+	actual, _ = versionManager.ListProjects("")
+	Ω.Expect(actual).To(HaveLen(4))
+
+	projects, skipped, err := versionManager.GetProjects("team")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(projects).To(HaveLen(2))
+	Ω.Expect(skipped).To(Equal([]string{"team/broken"}))
+	Ω.Expect(projects["team/sub/b"].Version.String()).To(Equal("1.0"))
+}
+
+func TestSettingsInheritFromNamespaces(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("team/service/component", "1.0")
+	_ = versionManager.SetNamespaceSettings("", map[string]string{"owner": "platform", "scheme": "semver"})
+	_ = versionManager.SetNamespaceSettings("team", map[string]string{"owner": "team-a"})
+	_ = versionManager.SetSettings("team/service/component", map[string]string{"scheme": "calver"})
+
+	actual, err := versionManager.GetSettings("team/service/component")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(actual).To(Equal(map[string]string{"owner": "team-a", "scheme": "calver"}))
+
+	actual, _ = versionManager.GetNamespaceSettings("team/service")
+	Ω.Expect(actual).To(Equal(map[string]string{"owner": "team-a", "scheme": "semver"}))
+}
+
+func TestNamespaceSettingsWithPrecondition(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_ = versionManager.SetNamespaceSettings("team", map[string]string{"owner": "team-a"})
+	namespace, _ := versionManager.GetNamespace("team")
+	conditional := versionManager.WithPrecondition(model.Precondition{IfMatch: []string{namespace.Revision()}})
+
+	Ω.Expect(conditional.SetNamespaceSettings("team", map[string]string{"owner": "team-b"})).To(Succeed())
+	err := conditional.SetNamespaceSettings("team", map[string]string{"owner": "team-c"})
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrPreconditionFailed))
+
+	actual, _ := versionManager.GetNamespaceSettings("team")
+	Ω.Expect(actual).To(Equal(map[string]string{"owner": "team-b"}))
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/model"
+)
+
+// ImportStrategy decides how imported projects conflicting with existing ones are handled
+type ImportStrategy string
+
+const (
+	// ImportMerge keeps the more recently modified project and combines both histories
+	ImportMerge ImportStrategy = "merge"
+	// ImportOverwrite replaces existing projects and their history
+	ImportOverwrite ImportStrategy = "overwrite"
+	// ImportSkip leaves existing projects untouched
+	ImportSkip ImportStrategy = "skip"
+)
+
+// ImportOutcome describes what an import did with a single project
+type ImportOutcome string
+
+const (
+	// ImportCreated means the project did not exist before
+	ImportCreated ImportOutcome = "created"
+	// ImportMerged means the project existed and was merged
+	ImportMerged ImportOutcome = "merged"
+	// ImportOverwritten means the project existed and was replaced
+	ImportOverwritten ImportOutcome = "overwritten"
+	// ImportSkipped means the project existed and was left untouched
+	ImportSkipped ImportOutcome = "skipped"
+)
+
+// ParseImportStrategy returns the import strategy of the given name
+func ParseImportStrategy(name string) (ImportStrategy, error) {
+	switch strategy := ImportStrategy(name); strategy {
+	case ImportMerge, ImportOverwrite, ImportSkip:
+		return strategy, nil
+	}
+
+	return "", errors.Wrapf(ErrInvalidInput, "%v is not a valid import strategy", name)
+}
+
+// Export returns a snapshot of all projects with their metadata and history and of all namespace settings
+func (vm *VersionManager) Export() (model.Snapshot, error) {
+	snapshot := model.Snapshot{
+		Format:     model.SnapshotFormat,
+		Created:    vm.now().UTC(),
+		Projects:   make([]model.ProjectSnapshot, 0),
+		Namespaces: make([]model.NamespaceSnapshot, 0),
+	}
+
+	projects, err := vm.storageProvider.ListProjects()
+	if err != nil {
+		return snapshot, errors.Wrap(err, "Failed to list projects")
+	}
+
+	for _, project := range projects {
+		storedProject, err := vm.storageProvider.ReadProject(project)
+		if err != nil {
+			return snapshot, errors.Wrapf(err, "Failed to export project %v", project)
+		}
+
+		history, err := vm.storageProvider.ReadHistory(project)
+		if err != nil {
+			return snapshot, errors.Wrapf(err, "Failed to export history of project %v", project)
+		}
+
+		snapshot.Projects = append(snapshot.Projects, model.ProjectSnapshot{
+			Name:     project,
+			Version:  storedProject.Version,
+			Metadata: storedProject.Metadata,
+			History:  history,
+		})
+	}
+
+	namespaces, err := vm.storageProvider.ListNamespaces()
+	if err != nil {
+		return snapshot, errors.Wrap(err, "Failed to list namespaces")
+	}
+
+	for _, namespace := range namespaces {
+		storedNamespace, err := vm.storageProvider.ReadNamespace(namespace)
+		if err != nil {
+			return snapshot, errors.Wrapf(err, "Failed to export namespace %v", namespace)
+		}
+
+		snapshot.Namespaces = append(snapshot.Namespaces, model.NamespaceSnapshot{
+			Name:     namespace,
+			Settings: storedNamespace.Settings,
+		})
+	}
+
+	return snapshot, nil
+}
+
+// Import restores all projects and namespace settings of a snapshot, handling existing projects and namespaces with
+// the given strategy. The import is all or nothing: the snapshot is validated before anything is written and projects
+// and namespaces imported before a failure are restored to their previous state.
+func (vm *VersionManager) Import(snapshot model.Snapshot, strategy ImportStrategy) (map[string]ImportOutcome, error) {
+	outcomes := make(map[string]ImportOutcome, len(snapshot.Projects))
+	// imported projects may change dependencies and groups
+	defer vm.lockTopology()()
+	defer vm.topology.invalidate()
+	if vm.maxProjects > 0 {
+		vm.creating.Lock()
+		defer vm.creating.Unlock()
+	}
+
+	existingProjects, err := vm.storageProvider.ListProjects()
+	if err != nil {
+		return outcomes, errors.Wrap(err, "Failed to list projects")
+	}
+	exists := make(map[string]bool, len(existingProjects))
+	for _, project := range existingProjects {
+		exists[project] = true
+	}
+	if err := vm.validateSnapshot(snapshot, exists); err != nil {
+		return outcomes, err
+	}
+
+	names := make([]string, 0, len(snapshot.Projects))
+	for _, imported := range snapshot.Projects {
+		names = append(names, imported.Name)
+	}
+	states, err := vm.readStates(names)
+	if err != nil {
+		return outcomes, err
+	}
+	namespaceStates, err := vm.readNamespaceStates(snapshot.Namespaces)
+	if err != nil {
+		return outcomes, err
+	}
+
+	imported := make([]string, 0, len(snapshot.Projects))
+	importedNamespaces := make([]string, 0, len(snapshot.Namespaces))
+	rollback := func(err error) (map[string]ImportOutcome, error) {
+		if rollbackErr := vm.rollback(imported, states); rollbackErr != nil {
+			return map[string]ImportOutcome{}, errors.Wrapf(err, "Failed to roll back import: %v", rollbackErr)
+		}
+		if rollbackErr := vm.rollbackNamespaces(importedNamespaces, namespaceStates); rollbackErr != nil {
+			return map[string]ImportOutcome{}, errors.Wrapf(err, "Failed to roll back import: %v", rollbackErr)
+		}
+		return map[string]ImportOutcome{}, err
+	}
+
+	for _, project := range snapshot.Projects {
+		imported = append(imported, project.Name)
+		outcome, err := vm.importProject(project, exists[project.Name], strategy)
+		if err != nil {
+			return rollback(errors.Wrapf(err, "Failed to import project %v", project.Name))
+		}
+		outcomes[project.Name] = outcome
+	}
+
+	for _, namespace := range snapshot.Namespaces {
+		importedNamespaces = append(importedNamespaces, namespace.Name)
+		if err := vm.importNamespace(namespace, namespaceStates[namespace.Name], strategy); err != nil {
+			return rollback(errors.Wrapf(err, "Failed to import namespace %v", namespace.Name))
+		}
+	}
+
+	return outcomes, nil
+}
+
+// validateSnapshot checks the format and the project names of a snapshot and that the projects it creates stay
+// within the quota
+func (vm *VersionManager) validateSnapshot(snapshot model.Snapshot, exists map[string]bool) error {
+	if snapshot.Format > model.SnapshotFormat {
+		return errors.Wrapf(ErrInvalidInput, "Snapshot format %v is newer than supported format %v", snapshot.Format, model.SnapshotFormat)
+	}
+
+	names := make(map[string]bool, len(snapshot.Projects))
+	created := 0
+	for _, imported := range snapshot.Projects {
+		if imported.Name == "" {
+			return errors.Wrap(ErrInvalidInput, "Snapshot contains a project without name")
+		}
+		if err := model.ValidateName(imported.Name); err != nil {
+			return errors.Wrap(ErrInvalidInput, err.Error())
+		}
+		if names[imported.Name] {
+			return errors.Wrapf(ErrInvalidInput, "Snapshot contains project %v more than once", imported.Name)
+		}
+		names[imported.Name] = true
+		if !exists[imported.Name] {
+			created++
+		}
+	}
+
+	namespaces := make(map[string]bool, len(snapshot.Namespaces))
+	for _, imported := range snapshot.Namespaces {
+		if imported.Name != "" {
+			if err := model.ValidateName(imported.Name); err != nil {
+				return errors.Wrap(ErrInvalidInput, err.Error())
+			}
+		}
+		if names[imported.Name] || exists[imported.Name] {
+			return errors.Wrapf(ErrInvalidInput, "Snapshot contains namespace %v conflicting with the project of the same name", imported.Name)
+		}
+		if namespaces[imported.Name] {
+			return errors.Wrapf(ErrInvalidInput, "Snapshot contains namespace %v more than once", imported.Name)
+		}
+		namespaces[imported.Name] = true
+	}
+
+	if vm.maxProjects > 0 && created > 0 && len(exists)+created > vm.maxProjects {
+		return errors.Wrapf(ErrQuotaExceeded, "Only %v projects are allowed", vm.maxProjects)
+	}
+
+	return nil
+}
+
+func (vm *VersionManager) importProject(imported model.ProjectSnapshot, exists bool, strategy ImportStrategy) (ImportOutcome, error) {
+	importedProject := model.Project{Version: imported.Version, Metadata: imported.Metadata}
+
+	if !exists {
+		return ImportCreated, vm.storeImportedProject(imported.Name, importedProject, imported.History)
+	}
+
+	switch strategy {
+	case ImportOverwrite:
+		return ImportOverwritten, vm.storeImportedProject(imported.Name, importedProject, imported.History)
+	case ImportMerge:
+		currentProject, err := vm.storageProvider.ReadProject(imported.Name)
+		if err != nil {
+			return "", err
+		}
+		currentHistory, err := vm.storageProvider.ReadHistory(imported.Name)
+		if err != nil {
+			return "", err
+		}
+
+		mergedProject := currentProject
+		if imported.Metadata.Modified.After(currentProject.Metadata.Modified) {
+			mergedProject = importedProject
+		}
+		return ImportMerged, vm.storeImportedProject(imported.Name, mergedProject, model.MergeHistories(currentHistory, imported.History))
+	}
+
+	return ImportSkipped, nil
+}
+
+func (vm *VersionManager) storeImportedProject(project string, storedProject model.Project, history []model.HistoryEntry) error {
+	if err := vm.storageProvider.StoreProject(project, storedProject); err != nil {
+		return err
+	}
+
+	return vm.storageProvider.StoreHistory(project, history)
+}
+
+// readNamespaceStates reads the current settings of the given namespaces to restore them if an import fails
+func (vm *VersionManager) readNamespaceStates(namespaces []model.NamespaceSnapshot) (map[string]model.Namespace, error) {
+	states := make(map[string]model.Namespace, len(namespaces))
+	for _, namespace := range namespaces {
+		storedNamespace, err := vm.storageProvider.ReadNamespace(namespace.Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read settings of namespace %v", namespace.Name)
+		}
+		states[namespace.Name] = storedNamespace
+	}
+
+	return states, nil
+}
+
+func (vm *VersionManager) rollbackNamespaces(namespaces []string, states map[string]model.Namespace) error {
+	for _, namespace := range namespaces {
+		if err := vm.storageProvider.StoreNamespace(namespace, states[namespace]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importNamespace stores the settings of an imported namespace. Namespaces without settings of their own are always
+// created; merging keeps the existing settings and adds the imported ones not set yet.
+func (vm *VersionManager) importNamespace(imported model.NamespaceSnapshot, current model.Namespace, strategy ImportStrategy) error {
+	settings := imported.Settings
+	if len(current.Settings) > 0 {
+		switch strategy {
+		case ImportSkip:
+			return nil
+		case ImportMerge:
+			settings = model.InheritSettings(imported.Settings, current.Settings)
+		}
+	}
+
+	return vm.storageProvider.StoreNamespace(imported.Name, model.Namespace{Settings: settings})
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+)
+
+func TestExportAndImportIntoEmptyStorage(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	source := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = source.SetVersion("A", "1.0.0")
+	_, _ = source.BumpPatch("A")
+	targetProvider := adapter.NewFileProvider(t.TempDir())
+	target := NewVersionManager(targetProvider)
+
+	snapshot, err := source.Export()
+	Ω.Expect(err).To(BeNil())
+	outcomes, err := target.Import(snapshot, ImportSkip)
+	Ω.Expect(err).To(BeNil())
+
+	actual, _ := target.GetVersion("A")
+	history, _ := targetProvider.ReadHistory("A")
+	Ω.Expect(outcomes).To(Equal(map[string]ImportOutcome{"A": ImportCreated}))
+	Ω.Expect(actual.String()).To(Equal("1.0.1"))
+	Ω.Expect(history).To(HaveLen(2))
+}
+
+func TestExportAndImportNamespaceSettings(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	source := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = source.SetVersion("team/A", "1.0.0")
+	_ = source.SetNamespaceSettings("", map[string]string{"owner": "ops"})
+	_ = source.SetNamespaceSettings("team", map[string]string{"owner": "team-a", "channel": "#team"})
+	snapshot, err := source.Export()
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(snapshot.Namespaces).To(Equal([]model.NamespaceSnapshot{
+		{Name: "", Settings: map[string]string{"owner": "ops"}},
+		{Name: "team", Settings: map[string]string{"owner": "team-a", "channel": "#team"}},
+	}))
+
+	for strategy, expected := range map[ImportStrategy]map[string]string{
+		ImportSkip:      {"owner": "team-b"},
+		ImportOverwrite: {"owner": "team-a", "channel": "#team"},
+		ImportMerge:     {"owner": "team-b", "channel": "#team"},
+	} {
+		target := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+		_ = target.SetNamespaceSettings("team", map[string]string{"owner": "team-b"})
+
+		_, err := target.Import(snapshot, strategy)
+		root, _ := target.GetNamespace("")
+		team, _ := target.GetNamespace("team")
+
+		Ω.Expect(err).To(BeNil())
+		Ω.Expect(root.Settings).To(Equal(map[string]string{"owner": "ops"}), string(strategy))
+		Ω.Expect(team.Settings).To(Equal(expected), string(strategy))
+	}
+
+	target := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, err = target.Import(model.Snapshot{
+		Projects:   []model.ProjectSnapshot{{Name: "team", Version: model.NewVersion(1, 0, 0)}},
+		Namespaces: []model.NamespaceSnapshot{{Name: "team"}},
+	}, ImportSkip)
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+}
+
+func TestImportStrategies(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	snapshot := model.Snapshot{
+		Format: model.SnapshotFormat,
+		Projects: []model.ProjectSnapshot{{
+			Name:     "A",
+			Version:  model.NewVersion(2, 0, 0),
+			Metadata: model.Metadata{Modified: base.Add(time.Hour)},
+			History:  []model.HistoryEntry{{Timestamp: base.Add(time.Hour), Action: "set", Version: model.NewVersion(2, 0, 0)}},
+		}},
+	}
+
+	for strategy, expected := range map[ImportStrategy]string{ImportSkip: "1.0.0", ImportOverwrite: "2.0.0", ImportMerge: "2.0.0"} {
+		provider := adapter.NewFileProvider(t.TempDir())
+		versionManager := NewVersionManager(provider)
+		versionManager.now = func() time.Time { return base }
+		_, _ = versionManager.SetVersion("A", "1.0.0")
+
+		_, err := versionManager.Import(snapshot, strategy)
+		actual, _ := versionManager.GetVersion("A")
+		history, _ := provider.ReadHistory("A")
+
+		Ω.Expect(err).To(BeNil())
+		Ω.Expect(actual.String()).To(Equal(expected), string(strategy))
+		if strategy == ImportMerge {
+			Ω.Expect(history).To(HaveLen(2))
+		}
+	}
+}
+
+// failingProvider fails to store one project
+type failingProvider struct {
+	adapter.StorageProvider
+	project string
+}
+
+func (provider failingProvider) StoreProject(project string, storedProject model.Project) error {
+	if project == provider.project {
+		return errors.Wrapf(adapter.ErrUnavailable, "Failed to store project %v", project)
+	}
+
+	return provider.StorageProvider.StoreProject(project, storedProject)
+}
+
+func TestImportIsAllOrNothing(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	fileProvider := adapter.NewFileProvider(t.TempDir())
+	versionManager := NewVersionManager(failingProvider{StorageProvider: fileProvider, project: "C"})
+	_, _ = versionManager.SetVersion("A", "1.0.0")
+	project := func(name string) model.ProjectSnapshot {
+		return model.ProjectSnapshot{Name: name, Version: model.NewVersion(2, 0, 0)}
+	}
+
+	_, err := versionManager.Import(model.Snapshot{Projects: []model.ProjectSnapshot{project("A"), project("B"), project("C")}}, ImportOverwrite)
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrUnavailable))
+	_, err = versionManager.Import(model.Snapshot{Projects: []model.ProjectSnapshot{project("B"), project("B")}}, ImportSkip)
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+	_, err = versionManager.Import(model.Snapshot{Projects: []model.ProjectSnapshot{project("B"), project("../D")}}, ImportSkip)
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+
+	projects, _ := fileProvider.ListProjects()
+	actual, _ := versionManager.GetVersion("A")
+	history, _ := fileProvider.ReadHistory("A")
+	Ω.Expect(projects).To(Equal([]string{"A"}))
+	Ω.Expect(actual.String()).To(Equal("1.0.0"))
+	Ω.Expect(history).To(HaveLen(1))
+}
+
+func TestImportWithinQuota(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	provider := adapter.NewFileProvider(t.TempDir())
+	versionManager := NewVersionManager(provider).withMaxProjects(2)
+	_, _ = versionManager.SetVersion("A", "1.0.0")
+
+	_, err := versionManager.Import(model.Snapshot{Projects: []model.ProjectSnapshot{{Name: "B"}, {Name: "C"}}}, ImportSkip)
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrQuotaExceeded))
+	projects, _ := provider.ListProjects()
+	Ω.Expect(projects).To(Equal([]string{"A"}))
+}
+
+func TestParseImportStrategy(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	actual, err := ParseImportStrategy("merge")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(actual).To(Equal(ImportMerge))
+
+	_, err = ParseImportStrategy("replace")
+	Ω.Expect(err).NotTo(BeNil())
+}
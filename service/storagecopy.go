@@ -0,0 +1,128 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/adapter"
+)
+
+// CopyReport lists the projects and the namespaces with settings handled by a storage copy
+type CopyReport struct {
+	Copied     []string
+	Unchanged  []string
+	Namespaces []string
+}
+
+// CopyStorage copies every project with its metadata and history and the settings of every namespace from the source
+// to the target storage and verifies the target afterwards. An incremental copy skips projects whose version,
+// metadata and history are the same in the target.
+func CopyStorage(source adapter.StorageProvider, target adapter.StorageProvider, incremental bool) (CopyReport, error) {
+	report := CopyReport{Copied: make([]string, 0), Unchanged: make([]string, 0), Namespaces: make([]string, 0)}
+
+	projects, err := source.ListProjects()
+	if err != nil {
+		return report, errors.Wrap(err, "Failed to list projects of source storage")
+	}
+
+	for _, project := range projects {
+		sourceProject, err := source.ReadProject(project)
+		if err != nil {
+			return report, errors.Wrapf(err, "Failed to read project %v from source storage", project)
+		}
+		sourceHistory, err := source.ReadHistory(project)
+		if err != nil {
+			return report, errors.Wrapf(err, "Failed to read history of project %v from source storage", project)
+		}
+
+		if incremental {
+			targetProject, err := target.ReadProject(project)
+			if err == nil && targetProject.Metadata.Modified.Equal(sourceProject.Metadata.Modified) && sameContent(targetProject, sourceProject) {
+				targetHistory, err := target.ReadHistory(project)
+				if err == nil && sameContent(targetHistory, sourceHistory) {
+					report.Unchanged = append(report.Unchanged, project)
+					continue
+				}
+			}
+		}
+
+		if err := target.StoreProject(project, sourceProject); err != nil {
+			return report, errors.Wrapf(err, "Failed to write project %v to target storage", project)
+		}
+		if err := target.StoreHistory(project, sourceHistory); err != nil {
+			return report, errors.Wrapf(err, "Failed to write history of project %v to target storage", project)
+		}
+		report.Copied = append(report.Copied, project)
+	}
+
+	namespaces, err := source.ListNamespaces()
+	if err != nil {
+		return report, errors.Wrap(err, "Failed to list namespaces of source storage")
+	}
+
+	for _, namespace := range namespaces {
+		sourceNamespace, err := source.ReadNamespace(namespace)
+		if err != nil {
+			return report, errors.Wrapf(err, "Failed to read namespace %v from source storage", namespace)
+		}
+		if err := target.StoreNamespace(namespace, sourceNamespace); err != nil {
+			return report, errors.Wrapf(err, "Failed to write namespace %v to target storage", namespace)
+		}
+		report.Namespaces = append(report.Namespaces, namespace)
+	}
+
+	return report, verifyCopy(source, target, projects, namespaces)
+}
+
+// sameContent compares two values by their JSON encoding, which is what the storages keep of them
+func sameContent(a interface{}, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
+
+func verifyCopy(source adapter.StorageProvider, target adapter.StorageProvider, projects []string, namespaces []string) error {
+	targetProjects, err := target.ListProjects()
+	if err != nil {
+		return errors.Wrap(err, "Failed to list projects of target storage")
+	}
+	if len(targetProjects) < len(projects) {
+		return errors.Errorf("Target storage contains %v projects, but source storage contains %v", len(targetProjects), len(projects))
+	}
+
+	mismatches := make([]string, 0)
+	for _, project := range projects {
+		sourceVersion, err := source.ReadVersion(project)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to verify project %v in source storage", project)
+		}
+		targetVersion, err := target.ReadVersion(project)
+		if err != nil || targetVersion != sourceVersion {
+			mismatches = append(mismatches, project)
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return errors.Errorf("Verification failed for projects %v", strings.Join(mismatches, ", "))
+	}
+
+	for _, namespace := range namespaces {
+		sourceNamespace, err := source.ReadNamespace(namespace)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to verify namespace %v in source storage", namespace)
+		}
+		targetNamespace, err := target.ReadNamespace(namespace)
+		if err != nil || !sameContent(targetNamespace, sourceNamespace) {
+			mismatches = append(mismatches, namespace)
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return errors.Errorf("Verification failed for namespaces %v", strings.Join(mismatches, ", "))
+	}
+
+	return nil
+}
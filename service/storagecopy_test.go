@@ -0,0 +1,96 @@
+package service
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+)
+
+func TestCopyStorage(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	source := adapter.NewFileProvider(t.TempDir())
+	target := adapter.NewFileProvider(t.TempDir())
+	versionManager := NewVersionManager(source)
+	_, _ = versionManager.SetVersion("A", "1.0.0")
+	_, _ = versionManager.SetVersion("B", "2.0.0")
+
+	report, err := CopyStorage(source, target, false)
+
+	actual, _ := target.ReadVersion("B")
+	history, _ := target.ReadHistory("B")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(report.Copied).To(Equal([]string{"A", "B"}))
+	Ω.Expect(actual.String()).To(Equal("2.0.0"))
+	Ω.Expect(history).To(HaveLen(1))
+}
+
+func TestCopyStorageWithNamespaceSettings(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	source := adapter.NewFileProvider(t.TempDir())
+	target := adapter.NewFileProvider(t.TempDir())
+	versionManager := NewVersionManager(source)
+	_, _ = versionManager.SetVersion("team/A", "1.0.0")
+	_ = versionManager.SetNamespaceSettings("", map[string]string{"owner": "ops"})
+	_ = versionManager.SetNamespaceSettings("team", map[string]string{"owner": "team-a"})
+
+	report, err := CopyStorage(source, target, true)
+
+	namespace, _ := target.ReadNamespace("team")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(report.Namespaces).To(Equal([]string{"", "team"}))
+	Ω.Expect(namespace.Settings).To(Equal(map[string]string{"owner": "team-a"}))
+
+	_ = target.StoreNamespace("team", model.Namespace{Settings: map[string]string{"owner": "team-b"}})
+	err = verifyCopy(source, target, []string{"team/A"}, []string{"", "team"})
+	Ω.Expect(err).To(MatchError(ContainSubstring("namespaces team")))
+}
+
+func TestCopyStorageIncrementally(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	source := adapter.NewFileProvider(t.TempDir())
+	target := adapter.NewFileProvider(t.TempDir())
+	versionManager := NewVersionManager(source)
+	_, _ = versionManager.SetVersion("A", "1.0.0")
+	_, _ = versionManager.SetVersion("B", "2.0.0")
+	_, _ = CopyStorage(source, target, true)
+	_, _ = versionManager.BumpPatch("B")
+
+	report, err := CopyStorage(source, target, true)
+
+	actual, _ := target.ReadVersion("B")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(report.Copied).To(Equal([]string{"B"}))
+	Ω.Expect(report.Unchanged).To(Equal([]string{"A"}))
+	Ω.Expect(actual.String()).To(Equal("2.0.1"))
+}
+
+func TestCopyStorageIncrementallyComparesContent(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	source := adapter.NewFileProvider(t.TempDir())
+	target := adapter.NewFileProvider(t.TempDir())
+	versionManager := NewVersionManager(source)
+	_, _ = versionManager.SetVersion("A", "1.0.0")
+	_, _ = versionManager.SetVersion("B", "2.0.0")
+	_, _ = CopyStorage(source, target, true)
+
+	// change the metadata of A and rewrite the history of B without changing its length
+	storedProject, _ := source.ReadProject("A")
+	storedProject.Metadata.Settings = map[string]string{"owner": "team-a"}
+	_ = source.StoreProject("A", storedProject)
+	history, _ := source.ReadHistory("B")
+	history[0].Ref = "3f2c1a9"
+	_ = source.StoreHistory("B", history)
+
+	report, err := CopyStorage(source, target, true)
+
+	settings, _ := target.ReadProject("A")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(report.Copied).To(Equal([]string{"A", "B"}))
+	Ω.Expect(settings.Metadata.Settings).To(Equal(map[string]string{"owner": "team-a"}))
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/model"
+)
+
+// GetTags returns the distribution tags of the given project with the versions they point to
+func (vm *VersionManager) GetTags(project string) (map[string]model.Version, error) {
+	storedProject, err := vm.storageProvider.ReadProject(project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get tags of project %v", project)
+	}
+
+	tags := storedProject.Metadata.Tags
+	if tags == nil {
+		tags = map[string]model.Version{}
+	}
+
+	return tags, nil
+}
+
+// GetTag returns the version the given distribution tag of the given project points to
+func (vm *VersionManager) GetTag(project string, tag string) (model.Version, error) {
+	tags, err := vm.GetTags(project)
+	if err != nil {
+		return model.Version{}, err
+	}
+
+	version, found := tags[tag]
+	if !found {
+		return version, errors.Wrapf(ErrNotFound, "Project %v has no tag %v", project, tag)
+	}
+
+	return version, nil
+}
+
+// SetTag points the given distribution tag of the given project to the given version, which the project must have
+// had, creating the tag or moving it
+func (vm *VersionManager) SetTag(project string, tag string, versionString string) (model.Version, error) {
+	if err := model.ValidateTag(tag); err != nil {
+		return model.Version{}, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+	if !model.ValidateVersionString(versionString) {
+		return model.Version{}, errors.Wrapf(ErrInvalidInput, "%v is not a valid version", versionString)
+	}
+	version, err := model.FromVersionString(versionString)
+	if err != nil {
+		return version, errors.Wrapf(err, "Failed to convert version %v", versionString)
+	}
+
+	defer vm.lockProjects(project)()
+
+	storedProject, err := vm.storageProvider.ReadProject(project)
+	if err != nil {
+		return version, errors.Wrapf(err, "Failed to set tag %v of project %v", tag, project)
+	}
+	if err := vm.checkPrecondition(project, storedProject, true); err != nil {
+		return version, err
+	}
+	if known, err := vm.hadVersion(project, storedProject, version); err != nil || !known {
+		if err == nil {
+			err = errors.Wrapf(ErrNotFound, "Project %v never had version %v", project, versionString)
+		}
+		return version, err
+	}
+
+	tags := make(map[string]model.Version, len(storedProject.Metadata.Tags)+1)
+	for name, tagged := range storedProject.Metadata.Tags {
+		tags[name] = tagged
+	}
+	tags[tag] = version
+
+	return version, vm.storeTags(project, storedProject, tags)
+}
+
+// DeleteTag removes the given distribution tag of the given project
+func (vm *VersionManager) DeleteTag(project string, tag string) error {
+	defer vm.lockProjects(project)()
+
+	storedProject, err := vm.storageProvider.ReadProject(project)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to delete tag %v of project %v", tag, project)
+	}
+	if err := vm.checkPrecondition(project, storedProject, true); err != nil {
+		return err
+	}
+	if _, found := storedProject.Metadata.Tags[tag]; !found {
+		return errors.Wrapf(ErrNotFound, "Project %v has no tag %v", project, tag)
+	}
+
+	tags := make(map[string]model.Version, len(storedProject.Metadata.Tags))
+	for name, tagged := range storedProject.Metadata.Tags {
+		if name != tag {
+			tags[name] = tagged
+		}
+	}
+
+	return vm.storeTags(project, storedProject, tags)
+}
+
+func (vm *VersionManager) storeTags(project string, storedProject model.Project, tags map[string]model.Version) error {
+	storedProject.Metadata = storedProject.Metadata.Touch(vm.modifier, vm.now().UTC())
+	storedProject.Metadata.Tags = tags
+
+	err := vm.storageProvider.StoreProject(project, storedProject)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to store tags of project %v", project)
+	}
+
+	return nil
+}
+
+// hadVersion checks if the given project has or had the given version, including versions of its branches and
+// release lines
+func (vm *VersionManager) hadVersion(project string, storedProject model.Project, version model.Version) (bool, error) {
+	versions, err := vm.lineCandidates(project, storedProject)
+	if err != nil {
+		return false, err
+	}
+	for _, branchVersion := range storedProject.Metadata.Branches {
+		versions = append(versions, branchVersion)
+	}
+
+	for _, candidate := range versions {
+		if candidate.String() == version.String() {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
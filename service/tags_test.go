@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+)
+
+func TestSetAndMoveTags(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("app", "1.0.0")
+	_, _ = versionManager.BumpMinor("app")
+
+	_, err := versionManager.SetTag("app", "stable", "1.0.0")
+	Ω.Expect(err).To(BeNil())
+	_, err = versionManager.SetTag("app", "next", "1.1.0")
+	Ω.Expect(err).To(BeNil())
+	_, err = versionManager.SetTag("app", "stable", "1.1.0")
+	Ω.Expect(err).To(BeNil())
+
+	tags, _ := versionManager.GetTags("app")
+	Ω.Expect(tags).To(Equal(map[string]model.Version{"stable": model.NewVersion(1, 1, 0), "next": model.NewVersion(1, 1, 0)}))
+	projects, _, _ := versionManager.GetProjects("")
+	Ω.Expect(projects["app"].Metadata.Tags).To(Equal(tags))
+
+	Ω.Expect(versionManager.DeleteTag("app", "next")).To(Succeed())
+	_, err = versionManager.GetTag("app", "next")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+	Ω.Expect(errors.Cause(versionManager.DeleteTag("app", "next"))).To(Equal(ErrNotFound))
+}
+
+func TestSetTagRejectsUnknownVersionsAndInvalidTags(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("app", "1.0.0")
+
+	_, err := versionManager.SetTag("app", "stable", "2.0.0")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+	_, err = versionManager.SetTag("app", "1.0", "1.0.0")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+	_, err = versionManager.SetTag("app", "stable", "x")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+	_, err = versionManager.SetTag("unknown", "stable", "1.0.0")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+}
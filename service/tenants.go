@@ -0,0 +1,219 @@
+package service
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+)
+
+var (
+	// ErrUnknownTenant is returned for tenants which are not registered
+	ErrUnknownTenant = errors.New("Unknown tenant")
+	// ErrQuotaExceeded is returned when a tenant is not allowed to create more projects
+	ErrQuotaExceeded = errors.New("Project quota exceeded")
+)
+
+// Tenants binds every registered tenant to a version manager with its own isolated storage. The registered tenants
+// are read from the store once and kept until they are changed with Put or Delete.
+type Tenants struct {
+	defaultManager *VersionManager
+	store          adapter.TenantStore
+	newManager     func(tenant string) (*VersionManager, error)
+	mutex          sync.Mutex
+	managers       map[string]*VersionManager
+	registryMutex  sync.Mutex
+	registered     []model.Tenant
+	eventLog       *EventLog
+	publishers     []Publisher
+}
+
+// NewTenants constructs tenants persisted in the given store, creating version managers for tenants other
+// than the default tenant with newManager
+func NewTenants(defaultManager *VersionManager, store adapter.TenantStore, newManager func(tenant string) (*VersionManager, error)) *Tenants {
+	return &Tenants{
+		defaultManager: defaultManager,
+		store:          store,
+		newManager:     newManager,
+		managers:       make(map[string]*VersionManager),
+	}
+}
+
+// NewSingleTenant constructs tenants consisting of the default tenant only
+func NewSingleTenant(defaultManager *VersionManager) *Tenants {
+	return NewTenants(defaultManager, nil, nil)
+}
+
+// Manager returns the version manager of the given tenant, limited to the tenant's quota
+func (tenants *Tenants) Manager(name string) (*VersionManager, error) {
+	tenant, found, err := tenants.find(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == model.DefaultTenant {
+		return tenants.defaultManager.withMaxProjects(tenant.MaxProjects).withEvents(name, tenants.eventLog, tenants.publishers), nil
+	}
+	if !found {
+		return nil, errors.Wrapf(ErrUnknownTenant, "Tenant %v does not exist", name)
+	}
+
+	tenants.mutex.Lock()
+	defer tenants.mutex.Unlock()
+
+	manager, cached := tenants.managers[name]
+	if !cached {
+		manager, err = tenants.newManager(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to set up storage of tenant %v", name)
+		}
+		tenants.managers[name] = manager
+	}
+
+	return manager.withMaxProjects(tenant.MaxProjects).withEvents(name, tenants.eventLog, tenants.publishers), nil
+}
+
+// LogEvents makes the version managers of all tenants number the events of their version changes in the given event
+// log. It must be called before serving requests.
+func (tenants *Tenants) LogEvents(eventLog *EventLog) {
+	tenants.eventLog = eventLog
+}
+
+// PublishTo makes the version managers of all tenants pass the events of their version changes to the given
+// publisher. It must be called before serving requests.
+func (tenants *Tenants) PublishTo(publisher Publisher) {
+	tenants.publishers = append(tenants.publishers, publisher)
+}
+
+// List returns all registered tenants sorted by name
+func (tenants *Tenants) List() ([]model.Tenant, error) {
+	tenants.registryMutex.Lock()
+	defer tenants.registryMutex.Unlock()
+
+	return tenants.list()
+}
+
+// list returns a copy of the registered tenants, reading them from the store unless they are cached. The caller must
+// hold the registry mutex.
+func (tenants *Tenants) list() ([]model.Tenant, error) {
+	if tenants.store == nil {
+		return []model.Tenant{{Name: model.DefaultTenant}}, nil
+	}
+	if tenants.registered != nil {
+		return append([]model.Tenant(nil), tenants.registered...), nil
+	}
+
+	registered, err := tenants.store.ReadTenants()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read tenants")
+	}
+
+	hasDefault := false
+	for _, tenant := range registered {
+		hasDefault = hasDefault || tenant.Name == model.DefaultTenant
+	}
+	if !hasDefault {
+		registered = append(registered, model.Tenant{Name: model.DefaultTenant})
+	}
+
+	sort.Slice(registered, func(i, j int) bool {
+		return registered[i].Name < registered[j].Name
+	})
+	tenants.registered = registered
+
+	return append([]model.Tenant(nil), registered...), nil
+}
+
+// storeRegistered writes the registered tenants, caching them on success. The caller must hold the registry mutex.
+func (tenants *Tenants) storeRegistered(registered []model.Tenant) error {
+	if err := tenants.store.StoreTenants(registered); err != nil {
+		tenants.registered = nil
+		return err
+	}
+
+	sort.Slice(registered, func(i, j int) bool {
+		return registered[i].Name < registered[j].Name
+	})
+	tenants.registered = registered
+
+	return nil
+}
+
+// Put registers a tenant or updates its quota
+func (tenants *Tenants) Put(tenant model.Tenant) error {
+	if err := model.ValidateTenantName(tenant.Name); err != nil {
+		return errors.Wrap(ErrInvalidInput, err.Error())
+	}
+	if tenant.MaxProjects < 0 {
+		return errors.Wrapf(ErrInvalidInput, "Quota %v of tenant %v must not be negative", tenant.MaxProjects, tenant.Name)
+	}
+	if tenants.store == nil {
+		return errors.Wrap(ErrConflict, "Tenants are not enabled")
+	}
+
+	tenants.registryMutex.Lock()
+	defer tenants.registryMutex.Unlock()
+
+	registered, err := tenants.list()
+	if err != nil {
+		return err
+	}
+
+	updated := make([]model.Tenant, 0, len(registered)+1)
+	for _, existing := range registered {
+		if existing.Name != tenant.Name {
+			updated = append(updated, existing)
+		}
+	}
+	updated = append(updated, tenant)
+
+	return tenants.storeRegistered(updated)
+}
+
+// Delete unregisters a tenant, keeping its projects in storage
+func (tenants *Tenants) Delete(name string) error {
+	if name == model.DefaultTenant {
+		return errors.Wrap(ErrConflict, "The default tenant cannot be deleted")
+	}
+
+	tenants.registryMutex.Lock()
+	defer tenants.registryMutex.Unlock()
+
+	registered, err := tenants.list()
+	if err != nil {
+		return err
+	}
+
+	updated := make([]model.Tenant, 0, len(registered))
+	for _, existing := range registered {
+		if existing.Name != name {
+			updated = append(updated, existing)
+		}
+	}
+	if len(updated) == len(registered) {
+		return errors.Wrapf(ErrUnknownTenant, "Tenant %v does not exist", name)
+	}
+
+	tenants.mutex.Lock()
+	delete(tenants.managers, name)
+	tenants.mutex.Unlock()
+
+	return tenants.storeRegistered(updated)
+}
+
+func (tenants *Tenants) find(name string) (model.Tenant, bool, error) {
+	registered, err := tenants.List()
+	if err != nil {
+		return model.Tenant{}, false, err
+	}
+
+	for _, tenant := range registered {
+		if tenant.Name == name {
+			return tenant, true, nil
+		}
+	}
+
+	return model.Tenant{Name: name}, false, nil
+}
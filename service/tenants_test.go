@@ -0,0 +1,141 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+)
+
+func newTestTenants(t *testing.T) *Tenants {
+	basePath := t.TempDir()
+	return NewTenants(NewVersionManager(adapter.NewFileProvider(basePath)), adapter.NewFileTenantStore(basePath), func(tenant string) (*VersionManager, error) {
+		return NewVersionManager(adapter.NewFileProvider(t.TempDir())), nil
+	})
+}
+
+func TestTenantsAreIsolated(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	tenants := newTestTenants(t)
+	_ = tenants.Put(model.Tenant{Name: "team-a"})
+	_ = tenants.Put(model.Tenant{Name: "team-b"})
+
+	managerA, _ := tenants.Manager("team-a")
+	managerB, _ := tenants.Manager("team-b")
+	_, _ = managerA.SetVersion("p1", "1.0")
+	_, _ = managerB.SetVersion("p1", "2.0")
+
+	actualA, _ := managerA.GetVersion("p1")
+	actualB, _ := managerB.GetVersion("p1")
+	Ω.Expect(actualA.String()).To(Equal("1.0"))
+	Ω.Expect(actualB.String()).To(Equal("2.0"))
+
+	defaultManager, _ := tenants.Manager(model.DefaultTenant)
+	_, err := defaultManager.GetVersion("p1")
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func TestUnknownAndDeletedTenants(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	tenants := newTestTenants(t)
+	_, err := tenants.Manager("team-a")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrUnknownTenant))
+
+	_ = tenants.Put(model.Tenant{Name: "team-a"})
+	_, err = tenants.Manager("team-a")
+	Ω.Expect(err).To(BeNil())
+
+	_ = tenants.Delete("team-a")
+	_, err = tenants.Manager("team-a")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrUnknownTenant))
+
+	Ω.Expect(tenants.Delete(model.DefaultTenant)).NotTo(BeNil())
+	Ω.Expect(tenants.Put(model.Tenant{Name: "Team A"})).NotTo(BeNil())
+}
+
+func TestTenantQuota(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	tenants := newTestTenants(t)
+	_ = tenants.Put(model.Tenant{Name: "team-a", MaxProjects: 1})
+	manager, _ := tenants.Manager("team-a")
+
+	_, err := manager.SetVersion("p1", "1.0")
+	Ω.Expect(err).To(BeNil())
+	_, err = manager.SetVersion("p1", "1.1")
+	Ω.Expect(err).To(BeNil())
+	_, err = manager.SetVersion("p2", "1.0")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrQuotaExceeded))
+
+	listed, _ := tenants.List()
+	Ω.Expect(listed).To(Equal([]model.Tenant{{Name: model.DefaultTenant}, {Name: "team-a", MaxProjects: 1}}))
+}
+
+func TestTenantQuotaWithConcurrentCreates(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	tenants := newTestTenants(t)
+	_ = tenants.Put(model.Tenant{Name: "team-a", MaxProjects: 3})
+	manager, _ := tenants.Manager("team-a")
+
+	var wait sync.WaitGroup
+	var mutex sync.Mutex
+	created := 0
+	for index := 0; index < 10; index++ {
+		wait.Add(1)
+		go func(project string) {
+			defer wait.Done()
+			if _, err := manager.SetVersion(project, "1.0"); err == nil {
+				mutex.Lock()
+				created++
+				mutex.Unlock()
+			}
+		}(fmt.Sprintf("p%d", index))
+	}
+	wait.Wait()
+
+	projects, _ := manager.GetVersions()
+	Ω.Expect(created).To(Equal(3))
+	Ω.Expect(projects).To(HaveLen(3))
+}
+
+// countingTenantStore counts the reads of the tenants it passes through
+type countingTenantStore struct {
+	adapter.TenantStore
+	reads int
+}
+
+func (store *countingTenantStore) ReadTenants() ([]model.Tenant, error) {
+	store.reads++
+	return store.TenantStore.ReadTenants()
+}
+
+func TestTenantsAreCached(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	basePath := t.TempDir()
+	store := &countingTenantStore{TenantStore: adapter.NewFileTenantStore(basePath)}
+	tenants := NewTenants(NewVersionManager(adapter.NewFileProvider(basePath)), store, func(tenant string) (*VersionManager, error) {
+		return NewVersionManager(adapter.NewFileProvider(t.TempDir())), nil
+	})
+
+	_ = tenants.Put(model.Tenant{Name: "team-a"})
+	for index := 0; index < 3; index++ {
+		_, err := tenants.Manager("team-a")
+		Ω.Expect(err).To(BeNil())
+	}
+	_ = tenants.Delete("team-a")
+	_, err := tenants.Manager("team-a")
+
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrUnknownTenant))
+	Ω.Expect(store.reads).To(Equal(1))
+
+	reread, _ := NewTenants(nil, store, nil).List()
+	Ω.Expect(reread).To(Equal([]model.Tenant{{Name: model.DefaultTenant}}))
+}
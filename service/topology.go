@@ -0,0 +1,135 @@
+package service
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+)
+
+// topologyIndex keeps the dependencies and group members of all projects in memory, shared by all copies of a version
+// manager. It is read from storage on first use and updated by the changes of dependencies and groups, which hold the
+// topology lock exclusively. Readers get snapshots which are never modified.
+type topologyIndex struct {
+	mutex        sync.Mutex
+	loaded       bool
+	dependencies model.DependencyGraph
+	groups       map[string][]string
+}
+
+// dependencyGraph returns the dependencies of all projects, reading them from the given storage unless they are
+// indexed already
+func (index *topologyIndex) dependencyGraph(provider adapter.StorageProvider) (model.DependencyGraph, error) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	if err := index.load(provider); err != nil {
+		return nil, err
+	}
+
+	return index.dependencies, nil
+}
+
+// groupMembers returns the sorted members of all groups, reading them from the given storage unless they are indexed
+// already
+func (index *topologyIndex) groupMembers(provider adapter.StorageProvider) (map[string][]string, error) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	if err := index.load(provider); err != nil {
+		return nil, err
+	}
+
+	return index.groups, nil
+}
+
+// setDependencies indexes the changed dependencies of a project, unless nothing is indexed yet
+func (index *topologyIndex) setDependencies(project string, dependencies []string) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	if !index.loaded {
+		return
+	}
+
+	updated := make(model.DependencyGraph, len(index.dependencies)+1)
+	for dependent, dependencies := range index.dependencies {
+		updated[dependent] = dependencies
+	}
+	delete(updated, project)
+	if len(dependencies) > 0 {
+		updated[project] = dependencies
+	}
+	index.dependencies = updated
+}
+
+// setGroup indexes the changed members of a group, unless nothing is indexed yet
+func (index *topologyIndex) setGroup(name string, members []string) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	if !index.loaded {
+		return
+	}
+
+	updated := make(map[string][]string, len(index.groups)+1)
+	for group, members := range index.groups {
+		updated[group] = members
+	}
+	delete(updated, name)
+	if len(members) > 0 {
+		updated[name] = members
+	}
+	index.groups = updated
+}
+
+// invalidate drops the index after projects were replaced, e.g. by an import, so it is read again on next use
+func (index *topologyIndex) invalidate() {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	index.loaded = false
+	index.dependencies = nil
+	index.groups = nil
+}
+
+// load reads the dependencies and groups of all projects unless they are indexed already. Projects which cannot be
+// decoded are skipped, so a single broken project does not fail all bumps; fsck reports them.
+func (index *topologyIndex) load(provider adapter.StorageProvider) error {
+	if index.loaded {
+		return nil
+	}
+
+	projects, err := provider.ListProjects()
+	if err != nil {
+		return errors.Wrap(err, "Failed to list projects")
+	}
+
+	dependencies := make(model.DependencyGraph)
+	groups := make(map[string][]string)
+	for _, project := range projects {
+		storedProject, err := provider.ReadProject(project)
+		if errors.Cause(err) == ErrUnavailable {
+			return errors.Wrapf(err, "Failed to get dependencies and group of project %v", project)
+		}
+		if err != nil {
+			continue
+		}
+		if len(storedProject.Metadata.Dependencies) > 0 {
+			dependencies[project] = storedProject.Metadata.Dependencies
+		}
+		if group := storedProject.Metadata.Group; group != "" {
+			groups[group] = append(groups[group], project)
+		}
+	}
+	for _, members := range groups {
+		sort.Strings(members)
+	}
+	index.dependencies = dependencies
+	index.groups = groups
+	index.loaded = true
+
+	return nil
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"sync"
+	"time"
+
 	"github.com/pkg/errors"
 	"maibornwolff/vbump/adapter"
 	"maibornwolff/vbump/model"
@@ -9,84 +12,234 @@ import (
 // VersionManager bumps major, minor, patch part of a given project
 type VersionManager struct {
 	storageProvider adapter.StorageProvider
+	now             func() time.Time
+	modifier        string
+	precondition    model.Precondition
+	maxProjects     int
+	tenant          string
+	eventLog        *EventLog
+	publishers      []Publisher
+	// locks, topology and creating are shared by all copies of the version manager, creating serializing creating
+	// projects with a quota
+	locks    *projectLocks
+	topology *topologyIndex
+	creating *sync.Mutex
 }
 
 // NewVersionManager constructs a new version manager
 func NewVersionManager(provider adapter.StorageProvider) *VersionManager {
 	return &VersionManager{
 		storageProvider: provider,
+		now:             time.Now,
+		locks:           newProjectLocks(),
+		topology:        &topologyIndex{},
+		creating:        &sync.Mutex{},
 	}
 }
 
-// BumpMajor bumps major version for given project
-func (vm *VersionManager) BumpMajor(project string) (model.Version, error) {
-	currentVersion, err := vm.storageProvider.ReadVersion(project)
-	if err != nil {
-		return currentVersion, err
-	}
+// WithModifier returns a version manager recording the given modifier in the metadata of changed projects
+func (vm *VersionManager) WithModifier(modifier string) *VersionManager {
+	modified := *vm
+	modified.modifier = modifier
+	return &modified
+}
 
-	newVersion := currentVersion.BumpMajor()
+// WithPrecondition returns a version manager changing only projects meeting the given precondition
+func (vm *VersionManager) WithPrecondition(precondition model.Precondition) *VersionManager {
+	conditional := *vm
+	conditional.precondition = precondition
+	return &conditional
+}
 
-	err = vm.storageProvider.StoreVersion(project, newVersion)
-	if err != nil {
-		return currentVersion, err
-	}
+func (vm *VersionManager) withMaxProjects(maxProjects int) *VersionManager {
+	limited := *vm
+	limited.maxProjects = maxProjects
+	return &limited
+}
 
-	return newVersion, nil
+func (vm *VersionManager) withEvents(tenant string, eventLog *EventLog, publishers []Publisher) *VersionManager {
+	publishing := *vm
+	publishing.tenant = tenant
+	publishing.eventLog = eventLog
+	publishing.publishers = publishers
+	return &publishing
+}
+
+// BumpMajor bumps major version for given project
+func (vm *VersionManager) BumpMajor(project string) (model.Version, error) {
+	bump, err := vm.Bump(project, model.PartMajor)
+	return bump.Version, err
 }
 
 // BumpMinor bumps minor version for given project
 func (vm *VersionManager) BumpMinor(project string) (model.Version, error) {
-	currentVersion, err := vm.storageProvider.ReadVersion(project)
-	if err != nil {
-		return currentVersion, err
-	}
+	bump, err := vm.Bump(project, model.PartMinor)
+	return bump.Version, err
+}
 
-	newVersion := currentVersion.BumpMinor()
+// BumpPatch bumps patch version for given project
+func (vm *VersionManager) BumpPatch(project string) (model.Version, error) {
+	bump, err := vm.Bump(project, model.PartPatch)
+	return bump.Version, err
+}
 
-	err = vm.storageProvider.StoreVersion(project, newVersion)
+// Bump bumps the given part of the version for given project
+func (vm *VersionManager) Bump(project string, part string) (model.Bump, error) {
+	return vm.BumpRef(project, part, "")
+}
+
+// BumpRef bumps the given part of the version for given project on behalf of a source reference like a commit SHA
+// or build ID, returning the bump recorded before if the reference was already bumped for the project. Looking up
+// the reference and bumping is atomic, so concurrent retries bump only once.
+func (vm *VersionManager) BumpRef(project string, part string, ref string) (model.Bump, error) {
+	unlock, err := vm.lockAffectedProjects(project, true)
 	if err != nil {
-		return currentVersion, err
+		return model.Bump{Project: project, Part: part, Ref: ref}, err
 	}
+	defer unlock()
 
-	return newVersion, nil
+	bump, err := vm.bump(project, part, ref, false)
+	if err == nil && !bump.Reused {
+		vm.publish(bump)
+	}
+
+	return bump, err
 }
 
-// BumpPatch bumps patch version for given project
-func (vm *VersionManager) BumpPatch(project string) (model.Version, error) {
-	currentVersion, err := vm.storageProvider.ReadVersion(project)
+// PreviewBump returns the bump BumpRef would apply without changing the project
+func (vm *VersionManager) PreviewBump(project string, part string, ref string) (model.Bump, error) {
+	return vm.bump(project, part, ref, true)
+}
+
+func (vm *VersionManager) bump(project string, part string, ref string, dryRun bool) (model.Bump, error) {
+	bump := model.Bump{Project: project, Part: part, Ref: ref, DryRun: dryRun}
+
+	if ref != "" {
+		if err := model.ValidateRef(ref); err != nil {
+			return bump, errors.Wrap(ErrInvalidInput, err.Error())
+		}
+
+		history, err := vm.storageProvider.ReadHistory(project)
+		if err != nil {
+			return bump, errors.Wrapf(err, "Failed to get history for project %v", project)
+		}
+		if previous, version, found := model.BumpOfRef(history, ref); found {
+			bump.PreviousVersion = previous
+			bump.Version = version
+			bump.Reused = true
+			return bump, nil
+		}
+	}
+
+	currentProject, err := vm.storageProvider.ReadProject(project)
 	if err != nil {
-		return currentVersion, err
+		return bump, err
 	}
+	if err := vm.checkPrecondition(project, currentProject, true); err != nil {
+		return bump, err
+	}
+	bump.PreviousVersion = currentProject.Version
 
-	newVersion := currentVersion.BumpPatch()
+	newVersion, err := model.BumpPart(currentProject.Version, part)
+	if err != nil {
+		return bump, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+	linked, err := vm.linkedProjects(project, currentProject)
+	if err != nil {
+		return bump, err
+	}
+	cascaded, err := vm.planCascade(append([]string{project}, linked...), part)
+	if err != nil {
+		return bump, err
+	}
+	if dryRun {
+		bump.Version = newVersion
+		bump.Linked = linked
+		bump.Cascaded = cascaded
+		return bump, nil
+	}
 
-	err = vm.storageProvider.StoreVersion(project, newVersion)
+	err = vm.applyVersion(project, currentProject, newVersion, part, ref, linked, cascaded)
 	if err != nil {
-		return currentVersion, err
+		return bump, err
 	}
+	bump.Version = newVersion
+	bump.Linked = linked
+	bump.Cascaded = cascaded
 
-	return newVersion, err
+	return bump, nil
 }
 
 // SetVersion sets the current given version for the given project
 func (vm *VersionManager) SetVersion(project string, versionString string) (model.Version, error) {
+	bump, err := vm.SetVersionBump(project, versionString)
+	return bump.Version, err
+}
+
+// SetVersionBump sets the current given version for the given project like SetVersion and describes the change as a
+// bump of the part set
+func (vm *VersionManager) SetVersionBump(project string, versionString string) (model.Bump, error) {
+	unlock, err := vm.lockAffectedProjects(project, false)
+	if err != nil {
+		return model.Bump{Project: project, Part: model.OperationSet}, err
+	}
+	defer unlock()
+
+	bump, err := vm.setVersionBump(project, versionString)
+	if err == nil {
+		vm.publish(bump)
+	}
+
+	return bump, err
+}
+
+func (vm *VersionManager) setVersionBump(project string, versionString string) (model.Bump, error) {
+	bump := model.Bump{Project: project, Part: model.OperationSet}
 	isValidated := model.ValidateVersionString(versionString)
 	if !isValidated {
-		return model.Version{}, errors.Errorf("%v is not a valid version", versionString)
+		return bump, errors.Wrapf(ErrInvalidInput, "%v is not a valid version", versionString)
 	}
 
 	version, err := model.FromVersionString(versionString)
+	bump.Version = version
 	if err != nil {
-		return version, errors.Wrapf(err, "Failed to convert version %v", versionString)
+		return bump, errors.Wrapf(err, "Failed to convert version %v", versionString)
 	}
 
-	err = vm.storageProvider.StoreVersion(project, version)
+	if vm.maxProjects > 0 {
+		// concurrent creates must not exceed the quota together
+		vm.creating.Lock()
+		defer vm.creating.Unlock()
+	}
+
+	currentProject, err := vm.storageProvider.ReadProject(project)
+	exists := err == nil
+	if err != nil && errors.Cause(err) != ErrNotFound {
+		return bump, errors.Wrapf(err, "Failed to set version %v for project %v", versionString, project)
+	}
+	if err := vm.checkPrecondition(project, currentProject, exists); err != nil {
+		return bump, err
+	}
+	if !exists {
+		if err := vm.checkQuota(); err != nil {
+			return bump, err
+		}
+		currentProject = model.Project{}
+	}
+	bump.PreviousVersion = currentProject.Version
+
+	linked, err := vm.linkedProjects(project, currentProject)
+	if err != nil {
+		return bump, err
+	}
+
+	err = vm.applyVersion(project, currentProject, version, "set", "", linked, nil)
 	if err != nil {
-		return version, errors.Wrapf(err, "Failed to set version %v for project %v", versionString, project)
+		return bump, errors.Wrapf(err, "Failed to set version %v for project %v", versionString, project)
 	}
+	bump.Linked = linked
 
-	return version, err
+	return bump, nil
 }
 
 // GetVersion returns current version for given project
@@ -99,28 +252,106 @@ func (vm *VersionManager) GetVersion(project string) (model.Version, error) {
 	return version, nil
 }
 
-// BumpTransientPatch bumps only the patch part on given version without change any project
-func (vm *VersionManager) BumpTransientPatch(versionString string) (model.Version, error) {
-	isValidated := model.ValidateVersionString(versionString)
-	if !isValidated {
-		return model.Version{}, errors.Errorf("%v is not a valid version", versionString)
+// GetProject returns the given project with its version and metadata
+func (vm *VersionManager) GetProject(project string) (model.Project, error) {
+	storedProject, err := vm.storageProvider.ReadProject(project)
+	if err != nil {
+		return storedProject, errors.Wrapf(err, "Failed to get project %v", project)
 	}
 
-	version, err := model.FromVersionString(versionString)
+	return storedProject, nil
+}
+
+// GetVersionByRef returns the version bumped for the given source reference of the given project
+func (vm *VersionManager) GetVersionByRef(project string, ref string) (model.Version, error) {
+	history, err := vm.storageProvider.ReadHistory(project)
 	if err != nil {
-		return version, errors.Wrapf(err, "Failed to convert version %v", versionString)
+		return model.Version{}, errors.Wrapf(err, "Failed to get history for project %v", project)
 	}
 
-	newVersion := version.BumpPatch()
+	version, found := model.VersionOfRef(history, ref)
+	if !found {
+		return version, errors.Wrapf(ErrNotFound, "Project %v has no version for source reference %v", project, ref)
+	}
 
-	return newVersion, nil
+	return version, nil
+}
+
+// GetVersionAt returns the version the given project had at the given point in time
+func (vm *VersionManager) GetVersionAt(project string, at time.Time) (model.Version, error) {
+	history, err := vm.storageProvider.ReadHistory(project)
+	if err != nil {
+		return model.Version{}, errors.Wrapf(err, "Failed to get history for project %v", project)
+	}
+
+	version, found := model.VersionAt(history, at)
+	if !found {
+		return version, errors.Wrapf(ErrNotFound, "Project %v has no version at %v", project, at.Format(time.RFC3339))
+	}
+
+	return version, nil
+}
+
+// GetVersions returns the current versions of all projects
+func (vm *VersionManager) GetVersions() (map[string]model.Version, error) {
+	projects, err := vm.storageProvider.ListProjects()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list projects")
+	}
+
+	versions := make(map[string]model.Version, len(projects))
+	for _, project := range projects {
+		version, err := vm.GetVersion(project)
+		if err != nil {
+			return nil, err
+		}
+		versions[project] = version
+	}
+
+	return versions, nil
+}
+
+// GetVersionsAt returns the versions all projects had at the given point in time, omitting projects without a version then
+func (vm *VersionManager) GetVersionsAt(at time.Time) (map[string]model.Version, error) {
+	projects, err := vm.storageProvider.ListProjects()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list projects")
+	}
+
+	versions := make(map[string]model.Version, len(projects))
+	for _, project := range projects {
+		history, err := vm.storageProvider.ReadHistory(project)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to get history for project %v", project)
+		}
+		if version, found := model.VersionAt(history, at); found {
+			versions[project] = version
+		}
+	}
+
+	return versions, nil
+}
+
+// BumpTransientMajor bumps only the major part on given version without change any project
+func (vm *VersionManager) BumpTransientMajor(versionString string) (model.Version, error) {
+	return vm.BumpTransient(versionString, model.PartMajor)
 }
 
 // BumpTransientMinor bumps only the minor part on given version without change any project
 func (vm *VersionManager) BumpTransientMinor(versionString string) (model.Version, error) {
+	return vm.BumpTransient(versionString, model.PartMinor)
+}
+
+// BumpTransientPatch bumps only the patch part on given version without change any project
+func (vm *VersionManager) BumpTransientPatch(versionString string) (model.Version, error) {
+	return vm.BumpTransient(versionString, model.PartPatch)
+}
+
+// BumpTransient bumps the given part on given version without change any project
+func (vm *VersionManager) BumpTransient(versionString string, part string) (model.Version, error) {
 	isValidated := model.ValidateVersionString(versionString)
 	if !isValidated {
-		return model.Version{}, errors.Errorf("%v is not a valid version", versionString)
+		return model.Version{}, errors.Wrapf(ErrInvalidInput, "%v is not a valid version", versionString)
 	}
 
 	version, err := model.FromVersionString(versionString)
@@ -128,7 +359,89 @@ func (vm *VersionManager) BumpTransientMinor(versionString string) (model.Versio
 		return model.Version{}, errors.Wrapf(err, "Failed to convert version %v", versionString)
 	}
 
-	newVersion := version.BumpMinor()
+	newVersion, err := model.BumpPart(version, part)
+	if err != nil {
+		return model.Version{}, errors.Wrap(ErrInvalidInput, err.Error())
+	}
 
 	return newVersion, nil
 }
+
+// checkPrecondition fails if the given project, which exists or is about to be created, does not meet the precondition
+func (vm *VersionManager) checkPrecondition(project string, currentProject model.Project, exists bool) error {
+	if !vm.precondition.Holds(currentProject, exists) {
+		return errors.Wrapf(ErrPreconditionFailed, "Project %v changed", project)
+	}
+
+	return nil
+}
+
+// checkQuota fails if creating another project would exceed the maximum number of projects
+func (vm *VersionManager) checkQuota() error {
+	if vm.maxProjects <= 0 {
+		return nil
+	}
+
+	projects, err := vm.storageProvider.ListProjects()
+	if err != nil {
+		return errors.Wrap(err, "Failed to count projects")
+	}
+	if len(projects) >= vm.maxProjects {
+		return errors.Wrapf(ErrQuotaExceeded, "Only %v projects are allowed", vm.maxProjects)
+	}
+
+	return nil
+}
+
+// applyVersion stores the version of the given project and the projects linked with it, recording it in their
+// history, and applies the bumps cascading from it. If any of them fails, all of them are restored, so a failed
+// change can be retried without applying it twice.
+func (vm *VersionManager) applyVersion(project string, currentProject model.Project, version model.Version, action string, ref string, linked []string, cascaded []model.Bump) error {
+	changed := append(append([]string{project}, linked...), bumpedProjects(cascaded)...)
+	states, err := vm.readStates(changed)
+	if err != nil {
+		return err
+	}
+
+	if err := vm.storeProject(project, currentProject, version); err != nil {
+		return err
+	}
+	err = vm.recordHistory(project, action, version, ref)
+	if err == nil {
+		err = vm.applyLinked(linked, version, action, ref)
+		if err == nil {
+			err = vm.applyCascade(cascaded)
+		}
+		if err != nil {
+			err = errors.Wrapf(err, "Failed to change projects linked with or depending on project %v", project)
+		}
+	}
+	if err != nil {
+		if rollbackErr := vm.rollback(changed, states); rollbackErr != nil {
+			return errors.Wrapf(err, "Failed to roll back: %v", rollbackErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (vm *VersionManager) storeProject(project string, currentProject model.Project, version model.Version) error {
+	newProject := model.Project{
+		Version:  version,
+		Metadata: currentProject.Metadata.Touch(vm.modifier, vm.now().UTC()),
+	}
+
+	return vm.storageProvider.StoreProject(project, newProject)
+}
+
+func (vm *VersionManager) recordHistory(project string, action string, version model.Version, ref string) error {
+	entry := model.HistoryEntry{Timestamp: vm.now().UTC(), Action: action, Version: version, Ref: ref}
+
+	err := vm.storageProvider.AppendHistory(project, entry)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to record history for project %v", project)
+	}
+
+	return nil
+}
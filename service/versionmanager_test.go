@@ -2,8 +2,10 @@ package service
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 	"maibornwolff/vbump/adapter"
 	"maibornwolff/vbump/model"
 )
@@ -174,3 +176,207 @@ func TestBumpTransientMinorVersionWithInvalidGivenVersion(t *testing.T) {
 
 	Ω.Expect(err).NotTo(BeNil())
 }
+
+func TestGetVersionAt(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	providerMock := adapter.NewMock(model.NewVersion(1, 0, 0), "A")
+	versionManager := NewVersionManager(providerMock)
+	versionManager.now = func() time.Time { return base }
+	_, _ = versionManager.BumpMinor("A")
+	versionManager.now = func() time.Time { return base.Add(time.Hour) }
+	_, _ = versionManager.BumpMajor("A")
+
+	actual, err := versionManager.GetVersionAt("A", base.Add(time.Minute))
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(actual.String()).To(Equal("1.1.0"))
+
+	_, err = versionManager.GetVersionAt("A", base.Add(-time.Minute))
+	Ω.Expect(err).NotTo(BeNil())
+}
+
+func TestGetVersionsAt(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	providerMock := adapter.NewMock(model.NewVersion(1, 0, 0), "A")
+	versionManager := NewVersionManager(providerMock)
+	versionManager.now = func() time.Time { return base }
+	_, _ = versionManager.BumpPatch("A")
+
+	actual, _ := versionManager.GetVersionsAt(base)
+	Ω.Expect(actual).To(HaveLen(1))
+	Ω.Expect(actual["A"].String()).To(Equal("1.0.1"))
+
+	actual, _ = versionManager.GetVersionsAt(base.Add(-time.Minute))
+	Ω.Expect(actual).To(BeEmpty())
+}
+
+func TestSetVersionRecordsMetadata(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	provider := adapter.NewFileProvider(t.TempDir())
+	versionManager := NewVersionManager(provider)
+	versionManager.now = func() time.Time { return base }
+	_, _ = versionManager.WithModifier("alice").SetVersion("A", "1.0.0")
+	versionManager.now = func() time.Time { return base.Add(time.Hour) }
+	_, _ = versionManager.WithModifier("bob").BumpMinor("A")
+
+	actual, _ := provider.ReadProject("A")
+
+	Ω.Expect(actual.Version.String()).To(Equal("1.1.0"))
+	Ω.Expect(actual.Metadata.Created).To(Equal(base))
+	Ω.Expect(actual.Metadata.Modified).To(Equal(base.Add(time.Hour)))
+	Ω.Expect(actual.Metadata.ModifiedBy).To(Equal("bob"))
+}
+
+func TestErrorKinds(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+
+	_, err := versionManager.BumpPatch("A")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+
+	_, err = versionManager.SetVersion("A", "1.x")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+
+	_, err = versionManager.Bump("A", "build")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+
+	_, _ = versionManager.SetVersion("A", "1.0.0")
+	_, err = versionManager.Bump("A", "build")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+
+	_, err = versionManager.GetVersionAt("A", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+}
+
+func TestSetVersionOnUnavailableStorage(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	fileProvider := adapter.NewFileProvider(t.TempDir())
+	faultProvider := adapter.NewFaultProvider(fileProvider, map[string]adapter.Fault{"ReadProject": {ErrorRate: 1}})
+	_, err := NewVersionManager(faultProvider).SetVersion("A", "1.0.0")
+
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrUnavailable))
+	_, err = fileProvider.ReadProject("A")
+	Ω.Expect(errors.Cause(err)).To(Equal(adapter.ErrNotFound))
+}
+
+func TestBumpRefReusesVersion(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("A", "1.0.0")
+
+	bump, err := versionManager.BumpRef("A", model.PartMinor, "abc")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(bump.Version.String()).To(Equal("1.1.0"))
+	Ω.Expect(bump.Reused).To(BeFalse())
+
+	_, _ = versionManager.BumpPatch("A")
+
+	bump, err = versionManager.BumpRef("A", model.PartMinor, "abc")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(bump.PreviousVersion.String()).To(Equal("1.0.0"))
+	Ω.Expect(bump.Version.String()).To(Equal("1.1.0"))
+	Ω.Expect(bump.Reused).To(BeTrue())
+
+	version, _ := versionManager.GetVersion("A")
+	Ω.Expect(version.String()).To(Equal("1.1.1"))
+
+	version, err = versionManager.GetVersionByRef("A", "abc")
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(version.String()).To(Equal("1.1.0"))
+
+	_, err = versionManager.GetVersionByRef("A", "def")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+
+	_, err = versionManager.BumpRef("A", model.PartMinor, "two words")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+}
+
+func TestChangesWithPrecondition(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("A", "1.0.0")
+	project, _ := versionManager.GetProject("A")
+	stale := versionManager.WithPrecondition(model.Precondition{IfMatch: []string{project.Revision()}})
+
+	_, err := stale.BumpPatch("A")
+	Ω.Expect(err).To(BeNil())
+
+	_, err = stale.BumpPatch("A")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrPreconditionFailed))
+	err = stale.SetSettings("A", map[string]string{"team": "a"})
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrPreconditionFailed))
+
+	version, _ := versionManager.GetVersion("A")
+	Ω.Expect(version.String()).To(Equal("1.0.1"))
+}
+
+func TestBumpTransientMajorVersion(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	providerMock := adapter.NewMock(model.NewVersion(1, 0, 0), "A")
+	versionManager := NewVersionManager(providerMock)
+	actual, _ := versionManager.BumpTransientMajor("1.2")
+
+	Ω.Expect(actual.String()).To(Equal("2.0"))
+	Ω.Expect(providerMock.(*adapter.FileProviderMock).VersionStored).To(Equal(false))
+}
+
+func TestPreviewBump(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	versionManager := NewVersionManager(adapter.NewFileProvider(t.TempDir()))
+	_, _ = versionManager.SetVersion("A", "1.0.0")
+
+	for part, expected := range map[string]string{
+		model.PartMajor:      "2.0.0",
+		model.PartMinor:      "1.1.0",
+		model.PartPatch:      "1.0.1",
+		model.PartPrerelease: "1.0.1-rc.0",
+	} {
+		bump, err := versionManager.PreviewBump("A", part, "")
+		Ω.Expect(err).To(BeNil())
+		Ω.Expect(bump.Version.String()).To(Equal(expected))
+		Ω.Expect(bump.DryRun).To(BeTrue())
+	}
+
+	version, _ := versionManager.GetVersion("A")
+	history, _ := versionManager.storageProvider.ReadHistory("A")
+	Ω.Expect(version.String()).To(Equal("1.0.0"))
+	Ω.Expect(history).To(HaveLen(1))
+}
+
+// failingHistoryProvider fails to append to the history of all projects
+type failingHistoryProvider struct {
+	adapter.StorageProvider
+}
+
+func (provider failingHistoryProvider) AppendHistory(project string, entry model.HistoryEntry) error {
+	return errors.Wrapf(adapter.ErrUnavailable, "Failed to append history of project %v", project)
+}
+
+func TestFailedHistoryRollsBackVersion(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	provider := adapter.NewFileProvider(t.TempDir())
+	_, _ = NewVersionManager(provider).SetVersion("p1", "1.0.0")
+	versionManager := NewVersionManager(failingHistoryProvider{StorageProvider: provider})
+
+	_, err := versionManager.Bump("p1", model.PartMinor)
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrUnavailable))
+	_, err = versionManager.SetVersion("p2", "1.0.0")
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrUnavailable))
+
+	version, _ := provider.ReadVersion("p1")
+	Ω.Expect(version.String()).To(Equal("1.0.0"))
+	projects, _ := provider.ListProjects()
+	Ω.Expect(projects).To(Equal([]string{"p1"}))
+}
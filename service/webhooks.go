@@ -0,0 +1,406 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+)
+
+// Headers of webhook requests
+const (
+	WebhookEventHeader     = "X-Vbump-Event"
+	WebhookDeliveryHeader  = "X-Vbump-Delivery"
+	WebhookSignatureHeader = "X-Vbump-Signature"
+)
+
+const (
+	initialRetryDelay = time.Second
+	maxRetryDelay     = time.Hour
+	webhookTimeout    = 10 * time.Second
+)
+
+// Webhooks manages the webhook subscriptions of all tenants and delivers events to them. Deliveries are queued in a
+// store, so they survive restarts, and failed deliveries are retried with exponential backoff up to a maximum number
+// of attempts. Unless internal hosts are allowed, webhooks must not point to loopback, private or link-local
+// addresses, which is checked when subscribing and again for every address dialed when delivering.
+type Webhooks struct {
+	store         adapter.WebhookStore
+	client        *http.Client
+	now           func() time.Time
+	maxAttempts   int
+	initialDelay  time.Duration
+	maxDelay      time.Duration
+	allowInternal bool
+	mutex         sync.Mutex
+	deleted       map[string]bool
+	delivering    sync.Mutex
+	wake          chan struct{}
+}
+
+// NewWebhooks constructs webhooks persisted in the given store, giving up deliveries after maxAttempts
+func NewWebhooks(store adapter.WebhookStore, maxAttempts int) *Webhooks {
+	webhooks := &Webhooks{
+		store:        store,
+		now:          time.Now,
+		maxAttempts:  maxAttempts,
+		initialDelay: initialRetryDelay,
+		maxDelay:     maxRetryDelay,
+		deleted:      make(map[string]bool),
+		wake:         make(chan struct{}, 1),
+	}
+
+	dialer := &net.Dialer{Timeout: webhookTimeout, Control: webhooks.checkAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	webhooks.client = &http.Client{Timeout: webhookTimeout, Transport: transport}
+
+	return webhooks
+}
+
+// AllowInternalHosts allows webhooks to point to loopback, private and link-local addresses, e.g. to notify a CI
+// server in the same network
+func (webhooks *Webhooks) AllowInternalHosts() {
+	webhooks.allowInternal = true
+}
+
+// List returns the webhooks of the given tenant without their secrets
+func (webhooks *Webhooks) List(tenant string) ([]model.Webhook, error) {
+	all, err := webhooks.store.ReadWebhooks()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read webhooks")
+	}
+
+	list := make([]model.Webhook, 0, len(all))
+	for _, webhook := range all {
+		if webhook.Tenant == tenant {
+			webhook.Secret = ""
+			list = append(list, webhook)
+		}
+	}
+
+	return list, nil
+}
+
+// Add subscribes a new webhook, returning it with its generated ID
+func (webhooks *Webhooks) Add(webhook model.Webhook) (model.Webhook, error) {
+	webhook.Project = model.NormalizeName(webhook.Project)
+	if err := model.ValidateWebhook(webhook); err != nil {
+		return webhook, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+	if parsed, _ := url.Parse(webhook.URL); !webhooks.allowInternal && internalHost(parsed.Hostname()) {
+		return webhook, errors.Wrapf(ErrInvalidInput, "%v points to an internal host", webhook.URL)
+	}
+
+	webhooks.mutex.Lock()
+	defer webhooks.mutex.Unlock()
+
+	all, err := webhooks.store.ReadWebhooks()
+	if err != nil {
+		return webhook, errors.Wrap(err, "Failed to read webhooks")
+	}
+	webhook.ID = randomID()
+
+	if err := webhooks.store.StoreWebhooks(append(all, webhook)); err != nil {
+		return webhook, errors.Wrap(err, "Failed to add webhook")
+	}
+
+	return webhook, nil
+}
+
+// Delete unsubscribes the given webhook of the given tenant. Deliveries stop right away, its pending deliveries are
+// dropped by the delivery loop.
+func (webhooks *Webhooks) Delete(tenant string, id string) error {
+	webhooks.mutex.Lock()
+	defer webhooks.mutex.Unlock()
+
+	all, err := webhooks.store.ReadWebhooks()
+	if err != nil {
+		return errors.Wrap(err, "Failed to read webhooks")
+	}
+
+	remaining := make([]model.Webhook, 0, len(all))
+	for _, webhook := range all {
+		if webhook.ID != id || webhook.Tenant != tenant {
+			remaining = append(remaining, webhook)
+		}
+	}
+	if len(remaining) == len(all) {
+		return errors.Wrapf(ErrNotFound, "Webhook %v does not exist", id)
+	}
+	if err := webhooks.store.StoreWebhooks(remaining); err != nil {
+		return errors.Wrapf(err, "Failed to delete webhook %v", id)
+	}
+	webhooks.deleted[id] = true
+	webhooks.wakeUp()
+
+	return nil
+}
+
+// Publish queues the given events for delivery to all webhooks subscribing to them
+func (webhooks *Webhooks) Publish(events ...model.Event) error {
+	all, err := webhooks.store.ReadWebhooks()
+	if err != nil {
+		return errors.Wrap(err, "Failed to read webhooks")
+	}
+
+	queued := 0
+	now := webhooks.now().UTC()
+	for _, event := range events {
+		for _, webhook := range all {
+			if !webhook.Matches(event) {
+				continue
+			}
+			// deliveries queued at once keep their order
+			delivery := model.Delivery{ID: randomID(), Webhook: webhook.ID, Event: event, Queued: now.Add(time.Duration(queued)), NextAttempt: now}
+			if err := webhooks.store.StoreDelivery(delivery); err != nil {
+				return errors.Wrapf(err, "Failed to queue event %v for webhook %v", event.ID, webhook.ID)
+			}
+			queued++
+		}
+	}
+
+	if queued > 0 {
+		webhooks.wakeUp()
+	}
+
+	return nil
+}
+
+// wakeUp makes the delivery loop check the queue right away
+func (webhooks *Webhooks) wakeUp() {
+	select {
+	case webhooks.wake <- struct{}{}:
+	default:
+	}
+}
+
+// isDeleted checks if the webhook was deleted since the running deliveries started
+func (webhooks *Webhooks) isDeleted(id string) bool {
+	webhooks.mutex.Lock()
+	defer webhooks.mutex.Unlock()
+
+	return webhooks.deleted[id]
+}
+
+// Run delivers queued events until stopped, checking the queue at the given interval and right after events were
+// published
+func (webhooks *Webhooks) Run(stop <-chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := webhooks.Deliver(); err != nil {
+			log.Error().Err(err).Msg("Failed to deliver webhooks")
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		case <-webhooks.wake:
+		}
+	}
+}
+
+// Deliver attempts all deliveries which are due and returns the number of successful ones. The webhooks are
+// delivered to concurrently, each one in the order its deliveries were queued: a failed delivery is rescheduled with
+// exponential backoff, holding back the later deliveries to its webhook until it succeeds or is dropped after the
+// maximum number of attempts. Deliveries of deleted webhooks are dropped.
+func (webhooks *Webhooks) Deliver() (int, error) {
+	webhooks.delivering.Lock()
+	defer webhooks.delivering.Unlock()
+
+	webhooks.mutex.Lock()
+	all, err := webhooks.store.ReadWebhooks()
+	webhooks.deleted = make(map[string]bool)
+	webhooks.mutex.Unlock()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to read webhooks")
+	}
+	deliveries, err := webhooks.store.ReadDeliveries()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to read deliveries")
+	}
+
+	byID := make(map[string]model.Webhook, len(all))
+	for _, webhook := range all {
+		byID[webhook.ID] = webhook
+	}
+	queues := make(map[string][]model.Delivery)
+	for _, delivery := range deliveries {
+		if _, exists := byID[delivery.Webhook]; !exists {
+			if err := webhooks.drop(delivery); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		queues[delivery.Webhook] = append(queues[delivery.Webhook], delivery)
+	}
+
+	var group sync.WaitGroup
+	var mutex sync.Mutex
+	delivered := 0
+	var firstErr error
+	for id, queue := range queues {
+		group.Add(1)
+		go func(webhook model.Webhook, queue []model.Delivery) {
+			defer group.Done()
+			count, err := webhooks.deliverTo(webhook, queue)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			delivered += count
+			if firstErr == nil {
+				firstErr = err
+			}
+		}(byID[id], queue)
+	}
+	group.Wait()
+
+	return delivered, firstErr
+}
+
+// deliverTo attempts the queued deliveries to one webhook in order until one of them is not due or fails, returning
+// the number of successful ones
+func (webhooks *Webhooks) deliverTo(webhook model.Webhook, queue []model.Delivery) (int, error) {
+	delivered := 0
+	for index, delivery := range queue {
+		if webhooks.isDeleted(webhook.ID) {
+			for _, dropped := range queue[index:] {
+				if err := webhooks.drop(dropped); err != nil {
+					return delivered, err
+				}
+			}
+			return delivered, nil
+		}
+		now := webhooks.now().UTC()
+		if delivery.NextAttempt.After(now) {
+			return delivered, nil
+		}
+
+		err := webhooks.send(webhook, delivery)
+		if err == nil {
+			delivered++
+			log.Info().Str("delivery", delivery.ID).Str("webhook", webhook.ID).Str("event", delivery.Event.ID).Msg("Delivered webhook")
+			if err := webhooks.store.DeleteDelivery(delivery.ID); err != nil {
+				return delivered, errors.Wrapf(err, "Failed to remove delivery %v", delivery.ID)
+			}
+			continue
+		}
+
+		delivery.Attempts++
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= webhooks.maxAttempts {
+			log.Error().Str("delivery", delivery.ID).Str("webhook", webhook.ID).Int("attempts", delivery.Attempts).Err(err).Msg("Gave up delivering webhook")
+			if err := webhooks.store.DeleteDelivery(delivery.ID); err != nil {
+				return delivered, errors.Wrapf(err, "Failed to drop delivery %v", delivery.ID)
+			}
+			continue
+		}
+
+		delivery.NextAttempt = now.Add(model.RetryDelay(delivery.Attempts, webhooks.initialDelay, webhooks.maxDelay))
+		log.Warn().Str("delivery", delivery.ID).Str("webhook", webhook.ID).Int("attempts", delivery.Attempts).Time("nextAttempt", delivery.NextAttempt).Err(err).Msg("Failed to deliver webhook")
+		if err := webhooks.store.StoreDelivery(delivery); err != nil {
+			return delivered, errors.Wrapf(err, "Failed to reschedule delivery %v", delivery.ID)
+		}
+		return delivered, nil
+	}
+
+	return delivered, nil
+}
+
+// drop removes a delivery of a deleted webhook from the queue
+func (webhooks *Webhooks) drop(delivery model.Delivery) error {
+	log.Info().Str("delivery", delivery.ID).Str("webhook", delivery.Webhook).Msg("Dropped delivery of deleted webhook")
+	if err := webhooks.store.DeleteDelivery(delivery.ID); err != nil {
+		return errors.Wrapf(err, "Failed to drop delivery %v", delivery.ID)
+	}
+
+	return nil
+}
+
+// send posts the delivery's event to the webhook, signing it with the webhook's secret
+func (webhooks *Webhooks) send(webhook model.Webhook, delivery model.Delivery) error {
+	payload, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode event")
+	}
+
+	request, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrapf(err, "Failed to create request to %v", webhook.URL)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(WebhookEventHeader, delivery.Event.Type)
+	request.Header.Set(WebhookDeliveryHeader, delivery.ID)
+	if webhook.Secret != "" {
+		request.Header.Set(WebhookSignatureHeader, model.Signature(webhook.Secret, payload))
+	}
+
+	response, err := webhooks.client.Do(request)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to post to %v", webhook.URL)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return errors.Errorf("%v answered with status %v", webhook.URL, response.StatusCode)
+	}
+
+	return nil
+}
+
+// checkAddress rejects dialing internal addresses, also if a webhook's host name resolves to one or redirects to one
+func (webhooks *Webhooks) checkAddress(network string, address string, _ syscall.RawConn) error {
+	if webhooks.allowInternal {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to parse address %v", address)
+	}
+	if internalHost(host) {
+		return errors.Errorf("%v is an internal address", host)
+	}
+
+	return nil
+}
+
+// internalHost checks if the host name or IP address is localhost or a loopback, private, link-local or unspecified
+// address
+func internalHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+// randomID returns a random hex encoded ID
+func randomID() string {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		panic(errors.Wrap(err, "Failed to generate ID"))
+	}
+
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"maibornwolff/vbump/adapter"
+	"maibornwolff/vbump/model"
+)
+
+func TestManageWebhooks(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	webhooks := NewWebhooks(adapter.NewFileWebhookStore(t.TempDir()), 3)
+	added, err := webhooks.Add(model.Webhook{URL: "https://example.com/hook", Secret: "s3cret", Project: "/team/", Tenant: model.DefaultTenant})
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(added.ID).NotTo(BeEmpty())
+	Ω.Expect(added.Project).To(Equal("team"))
+
+	_, err = webhooks.Add(model.Webhook{URL: "ftp://example.com", Secret: "s3cret"})
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+	_, err = webhooks.Add(model.Webhook{URL: "https://example.com/hook"})
+	Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput))
+
+	list, _ := webhooks.List(model.DefaultTenant)
+	Ω.Expect(list).To(Equal([]model.Webhook{{ID: added.ID, URL: "https://example.com/hook", Project: "team", Tenant: model.DefaultTenant}}))
+	other, _ := webhooks.List("team-a")
+	Ω.Expect(other).To(BeEmpty())
+
+	Ω.Expect(errors.Cause(webhooks.Delete("team-a", added.ID))).To(Equal(ErrNotFound))
+	Ω.Expect(webhooks.Delete(model.DefaultTenant, added.ID)).To(Succeed())
+	list, _ = webhooks.List(model.DefaultTenant)
+	Ω.Expect(list).To(BeEmpty())
+}
+
+func TestDeliverSignedEvents(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	var body []byte
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ = ioutil.ReadAll(request.Body)
+		header = request.Header
+	}))
+	defer server.Close()
+
+	basePath := t.TempDir()
+	webhooks := NewWebhooks(adapter.NewFileWebhookStore(basePath), 3)
+	webhooks.AllowInternalHosts()
+	_, _ = webhooks.Add(model.Webhook{URL: server.URL, Secret: "s3cret", Project: "team"})
+	Ω.Expect(webhooks.Publish(
+		model.Event{ID: "1", Type: model.EventBump, Project: "team/p1"},
+		model.Event{ID: "2", Type: model.EventBump, Project: "other"},
+	)).To(Succeed())
+	queued, _ := ioutil.ReadDir(path.Join(basePath, ".deliveries"))
+	Ω.Expect(queued).To(HaveLen(1))
+	data, _ := ioutil.ReadFile(path.Join(basePath, ".deliveries", queued[0].Name()))
+	Ω.Expect(string(data)).NotTo(ContainSubstring("s3cret"))
+
+	delivered, err := webhooks.Deliver()
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(delivered).To(Equal(1))
+	Ω.Expect(string(body)).To(ContainSubstring(`"project":"team/p1"`))
+	Ω.Expect(header.Get(WebhookEventHeader)).To(Equal(model.EventBump))
+	Ω.Expect(header.Get(WebhookSignatureHeader)).To(Equal(model.Signature("s3cret", body)))
+
+	delivered, _ = webhooks.Deliver()
+	Ω.Expect(delivered).To(Equal(0))
+}
+
+func TestRetryFailedDeliveries(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	failures := 1
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		calls++
+		if calls <= failures {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	basePath := t.TempDir()
+	now := time.Now()
+	webhooks := NewWebhooks(adapter.NewFileWebhookStore(basePath), 3)
+	webhooks.now = func() time.Time { return now }
+	webhooks.AllowInternalHosts()
+	_, _ = webhooks.Add(model.Webhook{URL: server.URL, Secret: "s3cret"})
+	_ = webhooks.Publish(model.Event{ID: "1", Type: model.EventSet, Project: "p1"})
+
+	delivered, _ := webhooks.Deliver()
+	Ω.Expect(delivered).To(Equal(0))
+	Ω.Expect(calls).To(Equal(1))
+
+	// the delivery survives a restart and waits for its backoff
+	restarted := NewWebhooks(adapter.NewFileWebhookStore(basePath), 3)
+	restarted.now = func() time.Time { return now }
+	restarted.AllowInternalHosts()
+	delivered, _ = restarted.Deliver()
+	Ω.Expect(delivered).To(Equal(0))
+	Ω.Expect(calls).To(Equal(1))
+
+	restarted.now = func() time.Time { return now.Add(initialRetryDelay) }
+	delivered, _ = restarted.Deliver()
+	Ω.Expect(delivered).To(Equal(1))
+	Ω.Expect(calls).To(Equal(2))
+}
+
+func TestGiveUpDeliveries(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		calls++
+		writer.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	store := adapter.NewFileWebhookStore(t.TempDir())
+	now := time.Now()
+	webhooks := NewWebhooks(store, 2)
+	webhooks.now = func() time.Time { return now }
+	webhooks.AllowInternalHosts()
+	_, _ = webhooks.Add(model.Webhook{URL: server.URL, Secret: "s3cret"})
+	_ = webhooks.Publish(model.Event{ID: "1", Type: model.EventSet, Project: "p1"})
+
+	_, _ = webhooks.Deliver()
+	now = now.Add(time.Hour)
+	_, _ = webhooks.Deliver()
+
+	deliveries, _ := store.ReadDeliveries()
+	Ω.Expect(calls).To(Equal(2))
+	Ω.Expect(deliveries).To(BeEmpty())
+}
+
+func TestRejectInternalWebhooks(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		calls++
+	}))
+	defer server.Close()
+
+	store := adapter.NewFileWebhookStore(t.TempDir())
+	webhooks := NewWebhooks(store, 3)
+	for _, url := range []string{server.URL, "http://localhost:8080", "http://10.0.0.1", "https://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data", "http://[::1]", "http://0.0.0.0"} {
+		_, err := webhooks.Add(model.Webhook{URL: url, Secret: "s3cret"})
+		Ω.Expect(errors.Cause(err)).To(Equal(ErrInvalidInput), url)
+	}
+
+	// a webhook subscribed while internal hosts were allowed is not delivered to anymore
+	_ = store.StoreWebhooks([]model.Webhook{{ID: "1", URL: server.URL, Secret: "s3cret"}})
+	_ = webhooks.Publish(model.Event{ID: "1", Type: model.EventSet, Project: "p1"})
+	delivered, _ := webhooks.Deliver()
+	Ω.Expect(delivered).To(Equal(0))
+	Ω.Expect(calls).To(Equal(0))
+	deliveries, _ := store.ReadDeliveries()
+	Ω.Expect(deliveries).To(HaveLen(1))
+	Ω.Expect(deliveries[0].LastError).To(ContainSubstring("internal address"))
+}
+
+func TestDeliverToWebhooksConcurrently(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	fastCalled := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		select {
+		case <-fastCalled:
+		case <-time.After(5 * time.Second):
+			writer.WriteHeader(http.StatusGatewayTimeout)
+		}
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		close(fastCalled)
+	}))
+	defer fast.Close()
+
+	webhooks := NewWebhooks(adapter.NewFileWebhookStore(t.TempDir()), 3)
+	webhooks.AllowInternalHosts()
+	_, _ = webhooks.Add(model.Webhook{URL: slow.URL, Secret: "s3cret"})
+	_, _ = webhooks.Add(model.Webhook{URL: fast.URL, Secret: "s3cret"})
+	_ = webhooks.Publish(model.Event{ID: "1", Type: model.EventSet, Project: "p1"})
+
+	delivered, err := webhooks.Deliver()
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(delivered).To(Equal(2))
+}
+
+func TestDropDeliveriesOfDeletedWebhooks(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	store := adapter.NewFileWebhookStore(t.TempDir())
+	webhooks := NewWebhooks(store, 3)
+	_ = store.StoreDelivery(model.Delivery{ID: "1", Webhook: "deleted", NextAttempt: time.Now()})
+
+	delivered, err := webhooks.Deliver()
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(delivered).To(Equal(0))
+	deliveries, _ := store.ReadDeliveries()
+	Ω.Expect(deliveries).To(BeEmpty())
+}
+
+func TestDeliverInOrderOfEvents(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	failing := true
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if failing {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received = append(received, request.Header.Get(WebhookEventHeader))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	webhooks := NewWebhooks(adapter.NewFileWebhookStore(t.TempDir()), 3)
+	webhooks.now = func() time.Time { return now }
+	webhooks.AllowInternalHosts()
+	_, _ = webhooks.Add(model.Webhook{URL: server.URL, Secret: "s3cret"})
+	_ = webhooks.Publish(model.Event{ID: "1", Type: model.EventSet, Project: "p1"}, model.Event{ID: "2", Type: model.EventBump, Project: "p1"})
+
+	// the failed first event holds back the second one until its retry
+	delivered, _ := webhooks.Deliver()
+	Ω.Expect(delivered).To(Equal(0))
+	failing = false
+	now = now.Add(time.Millisecond)
+	_ = webhooks.Publish(model.Event{ID: "3", Type: model.EventBump, Project: "p1"})
+	delivered, _ = webhooks.Deliver()
+	Ω.Expect(delivered).To(Equal(0))
+	Ω.Expect(received).To(BeEmpty())
+
+	now = now.Add(initialRetryDelay)
+	delivered, _ = webhooks.Deliver()
+	Ω.Expect(delivered).To(Equal(3))
+	Ω.Expect(received).To(Equal([]string{model.EventSet, model.EventBump, model.EventBump}))
+}
+
+func TestDeleteWebhookWhileDelivering(t *testing.T) {
+	Ω := NewGomegaWithT(t)
+
+	calls := make(chan struct{}, 10)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		calls <- struct{}{}
+		<-release
+	}))
+	defer server.Close()
+
+	store := adapter.NewFileWebhookStore(t.TempDir())
+	webhooks := NewWebhooks(store, 3)
+	webhooks.AllowInternalHosts()
+	added, _ := webhooks.Add(model.Webhook{URL: server.URL, Secret: "s3cret"})
+	_ = webhooks.Publish(model.Event{ID: "1", Type: model.EventSet, Project: "p1"}, model.Event{ID: "2", Type: model.EventBump, Project: "p1"})
+
+	done := make(chan int)
+	go func() {
+		delivered, _ := webhooks.Deliver()
+		done <- delivered
+	}()
+	<-calls
+
+	// deleting does not wait for the running delivery, which drops the remaining queue
+	err := webhooks.Delete("", added.ID)
+	close(release)
+	Ω.Expect(err).To(BeNil())
+	Ω.Expect(<-done).To(Equal(1))
+	Ω.Expect(calls).To(BeEmpty())
+	deliveries, _ := store.ReadDeliveries()
+	Ω.Expect(deliveries).To(BeEmpty())
+}